@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"songs-library/internal/config"
+	"songs-library/internal/models"
+	"songs-library/internal/respository"
+	"songs-library/internal/service"
+	"songs-library/pkg/logger/sl"
+	"songs-library/pkg/principal"
+	"time"
+)
+
+const apiKeyCommand = "apikey"
+
+// apiKeyAuthor is recorded as the issuer of keys issued from the command line.
+const apiKeyAuthor = "cli"
+
+// runAPIKey issues an API key and writes it to stdout. It is how the first admin key is made,
+// before there is one to call the admin endpoints with.
+//
+//	apikey -name name [-role reader|editor|admin] [-ttl duration]
+func runAPIKey(cfg *config.Config, args []string) int {
+	log := slog.New(
+		slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelInfo}),
+	)
+
+	flags := flag.NewFlagSet(apiKeyCommand, flag.ContinueOnError)
+	name := flags.String("name", "", "key name, recorded as the author of the changes made with the key")
+	role := flags.String("role", string(principal.RoleAdmin), "key role: reader, editor or admin")
+	ttl := flags.Duration("ttl", 0, "time until the key expires; by default it doesn't")
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	in := models.IssueAPIKey{Name: *name, Role: *role}
+	if *ttl > 0 {
+		expiresAt := time.Now().Add(*ttl)
+		in.ExpiresAt = &expiresAt
+	}
+
+	if err := in.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, "usage: apikey -name name [-role reader|editor|admin] [-ttl duration]")
+		return 2
+	}
+
+	db, err := respository.NewRepository(cfg.PgDsn, respository.Options{
+		PlaylistOnSongDelete: cfg.PlaylistOnSongDelete,
+	})
+	if err != nil {
+		log.Error("database init error", sl.Err(err))
+		return 1
+	}
+	defer db.Close()
+
+	s := service.NewService(log, db)
+
+	ctx := principal.With(context.Background(), apiKeyAuthor)
+
+	key, err := s.IssueAPIKey(ctx, &in)
+	if err != nil {
+		log.Error("failed to issue api key", sl.Err(err))
+		return 1
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(key)
+
+	return 0
+}
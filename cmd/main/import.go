@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"songs-library/internal"
+	"songs-library/internal/config"
+	"songs-library/internal/models"
+	"songs-library/internal/respository"
+	"songs-library/internal/service"
+	"songs-library/pkg/logger/sl"
+	"songs-library/pkg/principal"
+	"syscall"
+)
+
+const importCommand = "import"
+
+// importAuthor is recorded as the author of the revisions of imported songs.
+const importAuthor = "import"
+
+// importFormats maps file extensions to import formats.
+var importFormats = map[string]string{
+	".csv":    models.ImportFormatCSV,
+	".json":   models.ImportFormatJSON,
+	".ndjson": models.ImportFormatNDJSON,
+	".jsonl":  models.ImportFormatNDJSON,
+}
+
+// runImport imports songs from files, or from stdin for "-", and writes a report per file to stdout.
+// Songs queued for enrichment are processed by the workers of a running server.
+//
+//	import [-format csv|json|ndjson] [-enrich] [-batch-size n] file...
+func runImport(cfg *config.Config, args []string) int {
+	log := slog.New(
+		slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelInfo}),
+	)
+
+	flags := flag.NewFlagSet(importCommand, flag.ContinueOnError)
+	format := flags.String("format", "", "document format: csv, json or ndjson; by default taken from the file extension")
+	enrich := flags.Bool("enrich", false, "queue songs missing details for enrichment")
+	batchSize := flags.Int("batch-size", models.DefaultImportBatchSize, "rows per transaction")
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if flags.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: import [-format csv|json|ndjson] [-enrich] [-batch-size n] file...")
+		return 2
+	}
+
+	db, err := respository.NewRepository(cfg.PgDsn, respository.Options{
+		PlaylistOnSongDelete: cfg.PlaylistOnSongDelete,
+	})
+	if err != nil {
+		log.Error("database init error", sl.Err(err))
+		return 1
+	}
+	defer db.Close()
+
+	s := service.NewService(log, db)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	ctx = principal.With(ctx, importAuthor)
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+
+	code := 0
+	for _, path := range flags.Args() {
+		opts := models.ImportSongs{
+			Format:    *format,
+			Enrich:    *enrich,
+			BatchSize: *batchSize,
+		}
+		if opts.Format == "" {
+			opts.Format = importFormats[filepath.Ext(path)]
+		}
+
+		if err = opts.Validate(); err != nil {
+			log.Error("invalid import options", slog.String("file", path), sl.Err(err))
+			code = 1
+			continue
+		}
+
+		report, err := importFile(ctx, s, path, &opts)
+		if err != nil {
+			log.Error("import failed", slog.String("file", path), sl.Err(err))
+			code = 1
+		}
+
+		if report != nil {
+			_ = enc.Encode(struct {
+				File string `json:"file"`
+				*models.ImportReport
+			}{path, report})
+		}
+
+		if ctx.Err() != nil {
+			return 1
+		}
+	}
+
+	return code
+}
+
+func importFile(ctx context.Context, s internal.Service, path string, opts *models.ImportSongs) (*models.ImportReport, error) {
+	if path == "-" {
+		return s.ImportSongs(ctx, os.Stdin, opts)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return s.ImportSongs(ctx, f, opts)
+}
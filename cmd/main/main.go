@@ -2,52 +2,185 @@ package main
 
 import (
 	"context"
+	"errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	_ "songs-library/docs"
 	api "songs-library/internal/api/http"
 	"songs-library/internal/config"
+	"songs-library/internal/migrate"
+	"songs-library/internal/provider"
 	"songs-library/internal/respository"
 	"songs-library/internal/router"
 	"songs-library/internal/service"
+	"songs-library/migrations"
+	"songs-library/pkg/health"
 	"songs-library/pkg/logger/sl"
+	"songs-library/pkg/middlewares"
+	"songs-library/pkg/ratelimit"
+	"songs-library/pkg/tracing"
 	"syscall"
 	"time"
 )
 
+// rateLimitSweepInterval is how often the instance deletes the rate limit buckets left idle.
+const rateLimitSweepInterval = 10 * time.Minute
+
 // @title Songs Library
 // @version 0.1
 // @host      localhost:8080
 // @description Онлайн библиотека песен
 // @BasePath /api/v1
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description JWT signed with HS256 as "Bearer <token>", with sub, role and exp claims
 func main() {
 	cfg := config.MustLoad()
 
+	if len(os.Args) > 1 && os.Args[1] == importCommand {
+		os.Exit(runImport(cfg, os.Args[2:]))
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == apiKeyCommand {
+		os.Exit(runAPIKey(cfg, os.Args[2:]))
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == migrateCommand {
+		os.Exit(runMigrate(cfg, os.Args[2:]))
+	}
+
 	log := slog.New(
 		slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
 	)
 
-	db, err := respository.NewRepository(cfg.PgDsn)
+	tracer, err := tracing.NewProvider(log, tracing.Options{
+		Exporter:     cfg.Tracing.Exporter,
+		OTLPEndpoint: cfg.Tracing.OTLPEndpoint,
+		Stdout:       os.Stdout,
+		ServiceName:  cfg.Tracing.ServiceName,
+	})
+	if err != nil {
+		log.Error("tracing init error", sl.Err(err))
+		os.Exit(1)
+	}
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	db, err := respository.NewRepository(cfg.PgDsn, respository.Options{
+		PlaylistOnSongDelete: cfg.PlaylistOnSongDelete,
+		Metrics:              reg,
+	})
 	if err != nil {
 		slog.Error("database init error", sl.Err(err))
 		os.Exit(1)
 	}
 
-	s := service.NewService(log, db, cfg.SongsInfoAPIURL)
+	// The server's migrator shares the repository's pool; the schema health check runs on it too.
+	migrator, err := migrate.New(log, db.DB(), migrations.FS)
+	if err != nil {
+		log.Error("migrations init error", sl.Err(err))
+		os.Exit(1)
+	}
+
+	if cfg.AutoMigrate {
+		if err = migrator.Up(context.Background()); err != nil {
+			log.Error("migration failed", sl.Err(err))
+			os.Exit(1)
+		}
+	}
+
+	// Serving a schema the code doesn't expect fails request by request; better not to start.
+	if err = migrator.CheckSchema(context.Background()); err != nil {
+		log.Error("database schema check failed, run the migrate command or set AUTO_MIGRATE", sl.Err(err))
+		os.Exit(1)
+	}
+
+	detailProvider, err := provider.Build(log, cfg.SongDetailProviders, provider.Options{
+		SongsInfoAPIURL: cfg.SongsInfoAPIURL,
+		RequestTimeout:  cfg.Enrichment.RequestTimeout,
+		LyricsPath:      cfg.LyricsPath,
+		Metrics:         reg,
+	})
+	if err != nil {
+		log.Error("song detail provider init error", sl.Err(err))
+		os.Exit(1)
+	}
+
+	var (
+		rateLimitStore ratelimit.Store
+		sharedLimits   *respository.RateLimitStore
+	)
+	switch cfg.RateLimit.Store {
+	case "memory":
+		rateLimitStore = ratelimit.NewMemoryStore()
+	case "postgres":
+		sharedLimits = db.RateLimitStore(max(
+			cfg.RateLimit.IP.Period,
+			cfg.RateLimit.Reads.Period,
+			cfg.RateLimit.Writes.Period,
+			cfg.RateLimit.Enrichment.Period,
+		))
+		rateLimitStore = sharedLimits
+	}
+
+	service.RegisterLibraryMetrics(reg, db)
+
+	checks := []health.Check{
+		{Name: "postgres", Required: true, Check: db.Ping},
+		{Name: "schema", Required: true, Check: migrator.CheckSchema},
+	}
+	if cfg.Health.CheckSongsInfoAPI && cfg.SongsInfoAPIURL != "" {
+		checks = append(checks, health.Check{Name: "songs_info_api", Check: provider.CheckSongsInfoAPI(cfg.SongsInfoAPIURL)})
+	}
+	checker := health.NewChecker(cfg.Health.CheckTimeout, checks...)
+
+	s := service.NewService(log, db)
 	h := api.NewHandler(log, s)
-	r := router.NewRouter(log, h)
+	r := router.NewRouter(log, h, router.Options{
+		Auth: middlewares.AuthOptions{
+			Keys:      s,
+			JWTSecret: []byte(cfg.Auth.JWTSecret),
+		},
+		RateLimitStore: rateLimitStore,
+		RateLimits: router.RateLimits{
+			IP:         cfg.RateLimit.IP,
+			Reads:      cfg.RateLimit.Reads,
+			Writes:     cfg.RateLimit.Writes,
+			Enrichment: cfg.RateLimit.Enrichment,
+		},
+		Metrics: reg,
+		Health:  checker,
+	})
+
+	// Handlers inherit baseCtx, so work left running after the shutdown deadline can be cancelled.
+	baseCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
 
 	server := &http.Server{
 		Addr:         ":" + cfg.Port,
 		Handler:      r.Init(),
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 10 * time.Second,
+		BaseContext: func(net.Listener) context.Context {
+			return baseCtx
+		},
 	}
 
 	go func() {
-		if err = server.ListenAndServe(); err != nil {
+		if err = server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			log.Error("server listen error", sl.Err(err))
 			os.Exit(1)
 		}
@@ -55,23 +188,86 @@ func main() {
 
 	log.Info("server started", slog.String("port", cfg.Port))
 
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	workersDone := make(chan struct{})
+
+	worker := service.NewEnrichmentWorker(log, db, detailProvider, service.EnrichmentConfig{
+		Workers:      cfg.Enrichment.Workers,
+		MaxAttempts:  cfg.Enrichment.MaxAttempts,
+		PollInterval: cfg.Enrichment.PollInterval,
+		Lease:        cfg.Enrichment.Lease,
+	})
+
+	go func() {
+		worker.Run(workerCtx)
+		close(workersDone)
+	}()
+
+	if sharedLimits != nil {
+		go sweepRateLimits(workerCtx, log, sharedLimits)
+	}
+
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGTERM, syscall.SIGINT)
 
 	<-quit
 	log.Info("shutting down server...")
 
+	// Report not ready first, so the orchestrator stops routing requests here before the server stops taking them.
+	checker.ShutDown()
+	time.Sleep(cfg.Health.ShutdownDelay)
+
 	ctx, shutdown := context.WithTimeout(context.Background(), 5*time.Second)
 	defer shutdown()
 
-	err = db.Close()
+	stopWorkers()
+
+	shutdownErr := server.Shutdown(ctx)
+	if shutdownErr != nil {
+		log.Error("shutdown server error", sl.Err(shutdownErr))
+	}
+	cancelRequests()
 
+	select {
+	case <-workersDone:
+	case <-ctx.Done():
+		log.Error("enrichment workers did not stop in time")
+	}
+
+	err = db.Close()
 	if err != nil {
 		log.Error("close db client error", sl.Err(err))
 	}
-	err = server.Shutdown(ctx)
-	if err != nil {
-		log.Error("shutdown server error", sl.Err(err))
+
+	if err = tracer.Shutdown(ctx); err != nil {
+		log.Error("tracing shutdown error", sl.Err(err))
+	}
+
+	if shutdownErr != nil {
 		os.Exit(1)
 	}
 }
+
+// sweepRateLimits deletes the idle rate limit buckets every rateLimitSweepInterval until ctx is done.
+func sweepRateLimits(ctx context.Context, log *slog.Logger, store *respository.RateLimitStore) {
+	log = log.With(slog.String("component", "ratelimit/sweeper"))
+
+	ticker := time.NewTicker(rateLimitSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		n, err := store.Sweep(ctx)
+		if err != nil {
+			log.Error("failed to sweep rate limit buckets", sl.Err(err))
+			continue
+		}
+
+		log.Debug("rate limit buckets swept", slog.Int64("deleted", n))
+	}
+}
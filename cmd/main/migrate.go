@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	_ "github.com/lib/pq"
+	"log/slog"
+	"os"
+	"songs-library/internal/config"
+	"songs-library/internal/migrate"
+	"songs-library/migrations"
+	"songs-library/pkg/logger/sl"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+const migrateCommand = "migrate"
+
+const migrateUsage = "usage: migrate up|down|redo|status|to version"
+
+// runMigrate applies the migrations embedded in the binary, or lists them.
+//
+//	migrate up|down|redo|status|to version
+func runMigrate(cfg *config.Config, args []string) int {
+	log := slog.New(
+		slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelInfo}),
+	)
+
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	migrator, db, err := openMigrator(log, cfg.PgDsn)
+	if err != nil {
+		log.Error("migrations init error", sl.Err(err))
+		return 1
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	switch command := args[0]; {
+	case command == "up" && len(args) == 1:
+		err = migrator.Up(ctx)
+	case command == "down" && len(args) == 1:
+		err = migrator.Down(ctx)
+	case command == "redo" && len(args) == 1:
+		err = migrator.Redo(ctx)
+	case command == "status" && len(args) == 1:
+		err = printMigrationStatus(ctx, migrator)
+	case command == "to" && len(args) == 2:
+		version, parseErr := strconv.ParseInt(args[1], 10, 64)
+		if parseErr != nil || version < 0 {
+			fmt.Fprintln(os.Stderr, migrateUsage)
+			return 2
+		}
+		err = migrator.To(ctx, version)
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	if errors.Is(err, migrate.ErrNoMigrations) || errors.Is(err, migrate.ErrUnknownVersion) {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if err != nil {
+		log.Error("migration failed", sl.Err(err))
+		return 1
+	}
+
+	return 0
+}
+
+func printMigrationStatus(ctx context.Context, migrator *migrate.Migrator) error {
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+	for _, s := range statuses {
+		appliedAt := "pending"
+		if s.AppliedAt != nil {
+			appliedAt = s.AppliedAt.Format(time.DateTime)
+		}
+
+		fmt.Fprintf(w, "%d\t%s\t%s\n", s.Version, s.Name, appliedAt)
+	}
+
+	return w.Flush()
+}
+
+// openMigrator connects to the database for the migrations embedded in the binary.
+func openMigrator(log *slog.Logger, dsn string) (*migrate.Migrator, *sql.DB, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, nil, err
+	}
+	db.SetMaxOpenConns(2)
+
+	migrator, err := migrate.New(log, db, migrations.FS)
+	if err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+
+	return migrator, db, nil
+}
@@ -0,0 +1,308 @@
+package http
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"io"
+	"log/slog"
+	"net/http"
+	"songs-library/internal/models"
+	"songs-library/internal/respository"
+	"songs-library/pkg/api/response"
+	"songs-library/pkg/logger/sl"
+	"songs-library/pkg/tracing"
+	"strconv"
+)
+
+// CreateAlbum godoc
+// @Summary      Create an album
+// @Description  Добавление альбома с треклистом. Тип по умолчанию lp; трек без номера диска попадает на первый диск,
+// @Description  без номера трека - следует за предыдущим треком своего диска
+// @Tags         Albums
+// @Accept       json
+// @Produce      json
+// @Param        album  body      models.CreateAlbum  true                          "album and tracklist"
+// @Success      200    {object}  response.Response{data=models.AlbumWithTracks}  "OK"
+// @Failure      400    {object}  response.Response                               "Bad Request"
+// @Failure      404    {object}  response.Response                               "Song Not Found"
+// @Failure      500    {object}  response.Response                               "Internal Server Error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /albums [post]
+func (h *Handler) CreateAlbum(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.CreateAlbum"
+	r, span := tracing.StartRequest(r, op)
+	defer span.End()
+
+	log := h.setLogger(r.Context(), op, h.log)
+
+	var req models.CreateAlbum
+
+	err := render.DecodeJSON(r.Body, &req)
+	if errors.Is(err, io.EOF) {
+		log.Error("request body is empty")
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("request body is empty"))
+		return
+	}
+	if err != nil {
+		log.Error("failed to decode request body", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("failed to decode request body"))
+		return
+	}
+
+	log.Info("request body decoded", slog.Any("request", req))
+
+	err = req.Validate()
+	if err != nil {
+		log.Error("failed to validate request", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	}
+
+	album, err := h.service.CreateAlbum(r.Context(), &req)
+	if errors.Is(err, respository.ErrSongNotFound) {
+		log.Error("song not found", sl.Err(err))
+
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, response.Error("song not found"))
+		return
+	}
+	if err != nil {
+		log.Error("failed to create album", sl.Err(err))
+
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("failed to create album"))
+		return
+	}
+
+	render.JSON(w, r, response.OK(album))
+}
+
+// GetAlbum godoc
+// @Summary      Get an album
+// @Description  Получение альбома с треклистом в порядке дисков и треков
+// @Tags         Albums
+// @Produce      json
+// @Param        id   path      int  true                                          "album_id"
+// @Success      200  {object}  response.Response{data=models.AlbumWithTracks}  "OK"
+// @Failure      400  {object}  response.Response                               "Bad Request"
+// @Failure      404  {object}  response.Response                               "Album Not Found"
+// @Failure      500  {object}  response.Response                               "Internal Server Error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /albums/{id} [get]
+func (h *Handler) GetAlbum(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.GetAlbum"
+	r, span := tracing.StartRequest(r, op)
+	defer span.End()
+
+	log := h.setLogger(r.Context(), op, h.log)
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id <= 0 {
+		log.Error("invalid album_id", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(models.ErrInvalidAlbumID.Error()))
+		return
+	}
+
+	album, err := h.service.GetAlbum(r.Context(), id)
+	if errors.Is(err, respository.ErrAlbumNotFound) {
+		log.Error("album not found", sl.Err(err))
+
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, response.Error("album not found"))
+		return
+	}
+	if err != nil {
+		log.Error("failed to get album", sl.Err(err))
+
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("failed to get album"))
+		return
+	}
+
+	render.JSON(w, r, response.OK(album))
+}
+
+// ListAlbums godoc
+// @Summary      Get list of albums
+// @Description  Получение списка альбомов с количеством треков, фильтрацией по названию, исполнителю и типу
+// @Description  и пагинацией. Песни альбома - POST /songs/list с album_id
+// @Tags         Albums
+// @Accept       json
+// @Produce      json
+// @Param        filter  body      models.AlbumsFilter  false                "albums filters"
+// @Success      200     {object}  response.Response{data=models.Albums}  "OK"
+// @Failure      400     {object}  response.Response                      "Bad Request"
+// @Failure      500     {object}  response.Response                      "Internal Server Error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /albums/list [post]
+func (h *Handler) ListAlbums(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.ListAlbums"
+	r, span := tracing.StartRequest(r, op)
+	defer span.End()
+
+	log := h.setLogger(r.Context(), op, h.log)
+
+	var req models.AlbumsFilter
+
+	err := render.DecodeJSON(r.Body, &req)
+	if err != nil && !errors.Is(err, io.EOF) {
+		log.Error("failed to decode request body", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("failed to decode request body"))
+		return
+	}
+
+	err = req.Validate()
+	if err != nil {
+		log.Error("failed to validate request", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	}
+
+	list, err := h.service.ListAlbums(r.Context(), &req)
+	if err != nil {
+		log.Error("failed to list albums", sl.Err(err))
+
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("failed to list albums"))
+		return
+	}
+
+	render.JSON(w, r, response.OK(list))
+}
+
+// UpdateAlbum godoc
+// @Summary      Update an album
+// @Description  Изменение альбома. Треклист заменяется целиком
+// @Tags         Albums
+// @Accept       json
+// @Produce      json
+// @Param        album  body      models.UpdateAlbum  true                          "album and tracklist"
+// @Success      200    {object}  response.Response{data=models.AlbumWithTracks}  "OK"
+// @Failure      400    {object}  response.Response                               "Bad Request"
+// @Failure      404    {object}  response.Response                               "Album or Song Not Found"
+// @Failure      500    {object}  response.Response                               "Internal Server Error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /albums [put]
+func (h *Handler) UpdateAlbum(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.UpdateAlbum"
+	r, span := tracing.StartRequest(r, op)
+	defer span.End()
+
+	log := h.setLogger(r.Context(), op, h.log)
+
+	var req models.UpdateAlbum
+
+	err := render.DecodeJSON(r.Body, &req)
+	if errors.Is(err, io.EOF) {
+		log.Error("request body is empty")
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("request body is empty"))
+		return
+	}
+	if err != nil {
+		log.Error("failed to decode request body", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("failed to decode request body"))
+		return
+	}
+
+	log.Info("request body decoded", slog.Any("request", req))
+
+	err = req.Validate()
+	if err != nil {
+		log.Error("failed to validate request", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	}
+
+	album, err := h.service.UpdateAlbum(r.Context(), &req)
+	if errors.Is(err, respository.ErrAlbumNotFound) {
+		log.Error("album not found", sl.Err(err))
+
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, response.Error("album not found"))
+		return
+	}
+	if errors.Is(err, respository.ErrSongNotFound) {
+		log.Error("song not found", sl.Err(err))
+
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, response.Error("song not found"))
+		return
+	}
+	if err != nil {
+		log.Error("failed to update album", sl.Err(err))
+
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("failed to update album"))
+		return
+	}
+
+	render.JSON(w, r, response.OK(album))
+}
+
+// DeleteAlbum godoc
+// @Summary      Delete an album
+// @Description  Удаление альбома. Песни альбома остаются в библиотеке
+// @Tags         Albums
+// @Param        id   path      int  true             "album_id"
+// @Success      200  {object}  response.Response  "OK"
+// @Failure      400  {object}  response.Response  "Bad Request"
+// @Failure      404  {object}  response.Response  "Album Not Found"
+// @Failure      500  {object}  response.Response  "Internal Server Error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /albums/{id} [delete]
+func (h *Handler) DeleteAlbum(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.DeleteAlbum"
+	r, span := tracing.StartRequest(r, op)
+	defer span.End()
+
+	log := h.setLogger(r.Context(), op, h.log)
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id <= 0 {
+		log.Error("invalid album_id", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(models.ErrInvalidAlbumID.Error()))
+		return
+	}
+
+	err = h.service.DeleteAlbum(r.Context(), id)
+	if errors.Is(err, respository.ErrAlbumNotFound) {
+		log.Error("album not found", sl.Err(err))
+
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, response.Error("album not found"))
+		return
+	}
+	if err != nil {
+		log.Error("failed to delete album", sl.Err(err))
+
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("failed to delete album"))
+		return
+	}
+
+	render.JSON(w, r, response.OK(nil))
+}
@@ -0,0 +1,201 @@
+package http
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"io"
+	"log/slog"
+	"net/http"
+	"songs-library/internal/models"
+	"songs-library/internal/respository"
+	"songs-library/pkg/api/response"
+	"songs-library/pkg/logger/sl"
+	"songs-library/pkg/tracing"
+	"strconv"
+)
+
+// IssueAPIKey godoc
+// @Summary      Issue an API key
+// @Description  Выпуск ключа API с ролью reader, editor или admin. Ключ возвращается только в этом ответе,
+// @Description  библиотека хранит лишь его хеш. Ключ передается в заголовке X-API-Key или как Bearer токен
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Param        key  body      models.IssueAPIKey  true                       "key name, role and expiry"
+// @Success      200  {object}  response.Response{data=models.IssuedAPIKey}  "OK"
+// @Failure      400  {object}  response.Response                            "Bad Request"
+// @Failure      500  {object}  response.Response                            "Internal Server Error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /admin/keys [post]
+func (h *Handler) IssueAPIKey(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.IssueAPIKey"
+	r, span := tracing.StartRequest(r, op)
+	defer span.End()
+
+	log := h.setLogger(r.Context(), op, h.log)
+
+	var req models.IssueAPIKey
+
+	err := render.DecodeJSON(r.Body, &req)
+	if errors.Is(err, io.EOF) {
+		log.Error("request body is empty")
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("request body is empty"))
+		return
+	}
+	if err != nil {
+		log.Error("failed to decode request body", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("failed to decode request body"))
+		return
+	}
+
+	log.Info("request body decoded", slog.Any("request", req))
+
+	err = req.Validate()
+	if err != nil {
+		log.Error("failed to validate request", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	}
+
+	key, err := h.service.IssueAPIKey(r.Context(), &req)
+	if err != nil {
+		log.Error("failed to issue api key", sl.Err(err))
+
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("failed to issue api key"))
+		return
+	}
+
+	render.JSON(w, r, response.OK(key))
+}
+
+// ListAPIKeys godoc
+// @Summary      Get list of API keys
+// @Description  Получение всех ключей API, включая отозванные и истекшие, от новых к старым. Сами ключи не возвращаются
+// @Tags         Admin
+// @Produce      json
+// @Success      200  {object}  response.Response{data=models.APIKeys}  "OK"
+// @Failure      500  {object}  response.Response                       "Internal Server Error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /admin/keys [get]
+func (h *Handler) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.ListAPIKeys"
+	r, span := tracing.StartRequest(r, op)
+	defer span.End()
+
+	log := h.setLogger(r.Context(), op, h.log)
+
+	keys, err := h.service.ListAPIKeys(r.Context())
+	if err != nil {
+		log.Error("failed to list api keys", sl.Err(err))
+
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("failed to list api keys"))
+		return
+	}
+
+	render.JSON(w, r, response.OK(keys))
+}
+
+// RotateAPIKey godoc
+// @Summary      Rotate an API key
+// @Description  Замена ключа API новым с тем же именем и ролью. Старый ключ сразу перестает действовать
+// @Tags         Admin
+// @Produce      json
+// @Param        id   path      int  true                                    "key_id"
+// @Success      200  {object}  response.Response{data=models.IssuedAPIKey}  "OK"
+// @Failure      400  {object}  response.Response                            "Bad Request"
+// @Failure      404  {object}  response.Response                            "Key Not Found or Revoked"
+// @Failure      500  {object}  response.Response                            "Internal Server Error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /admin/keys/{id}/rotate [post]
+func (h *Handler) RotateAPIKey(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.RotateAPIKey"
+	r, span := tracing.StartRequest(r, op)
+	defer span.End()
+
+	log := h.setLogger(r.Context(), op, h.log)
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id <= 0 {
+		log.Error("invalid key_id", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(models.ErrInvalidAPIKeyID.Error()))
+		return
+	}
+
+	key, err := h.service.RotateAPIKey(r.Context(), id)
+	if errors.Is(err, respository.ErrAPIKeyNotFound) {
+		log.Error("api key not found", sl.Err(err))
+
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, response.Error("api key not found"))
+		return
+	}
+	if err != nil {
+		log.Error("failed to rotate api key", sl.Err(err))
+
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("failed to rotate api key"))
+		return
+	}
+
+	render.JSON(w, r, response.OK(key))
+}
+
+// RevokeAPIKey godoc
+// @Summary      Revoke an API key
+// @Description  Отзыв ключа API. Ключ остается в списке, чтобы было видно, кто внес изменения под его именем
+// @Tags         Admin
+// @Param        id   path      int  true             "key_id"
+// @Success      200  {object}  response.Response  "OK"
+// @Failure      400  {object}  response.Response  "Bad Request"
+// @Failure      404  {object}  response.Response  "Key Not Found or Revoked"
+// @Failure      500  {object}  response.Response  "Internal Server Error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /admin/keys/{id} [delete]
+func (h *Handler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.RevokeAPIKey"
+	r, span := tracing.StartRequest(r, op)
+	defer span.End()
+
+	log := h.setLogger(r.Context(), op, h.log)
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id <= 0 {
+		log.Error("invalid key_id", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(models.ErrInvalidAPIKeyID.Error()))
+		return
+	}
+
+	err = h.service.RevokeAPIKey(r.Context(), id)
+	if errors.Is(err, respository.ErrAPIKeyNotFound) {
+		log.Error("api key not found", sl.Err(err))
+
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, response.Error("api key not found"))
+		return
+	}
+	if err != nil {
+		log.Error("failed to revoke api key", sl.Err(err))
+
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("failed to revoke api key"))
+		return
+	}
+
+	render.JSON(w, r, response.OK(nil))
+}
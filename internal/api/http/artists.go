@@ -0,0 +1,329 @@
+package http
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"io"
+	"log/slog"
+	"net/http"
+	"songs-library/internal/models"
+	"songs-library/internal/respository"
+	"songs-library/pkg/api/response"
+	"songs-library/pkg/logger/sl"
+	"songs-library/pkg/tracing"
+	"strconv"
+)
+
+// CreateArtist godoc
+// @Summary      Create an artist
+// @Description  Добавление нового исполнителя. Имена сравниваются без учета регистра и лишних пробелов
+// @Tags         Artists
+// @Accept       json
+// @Produce      json
+// @Param        artist  body      models.CreateArtist  true                "artist name"
+// @Success      200     {object}  response.Response{data=models.Artist}  "OK"
+// @Failure      400     {object}  response.Response                      "Bad Request"
+// @Failure      409     {object}  response.Response                      "Artist Already Exists"
+// @Failure      500     {object}  response.Response                      "Internal Server Error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /artists [post]
+func (h *Handler) CreateArtist(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.CreateArtist"
+	r, span := tracing.StartRequest(r, op)
+	defer span.End()
+
+	log := h.setLogger(r.Context(), op, h.log)
+
+	var req models.CreateArtist
+
+	err := render.DecodeJSON(r.Body, &req)
+	if errors.Is(err, io.EOF) {
+		log.Error("request body is empty")
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("request body is empty"))
+		return
+	}
+	if err != nil {
+		log.Error("failed to decode request body", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("failed to decode request body"))
+		return
+	}
+
+	log.Info("request body decoded", slog.Any("request", req))
+
+	err = req.Validate()
+	if err != nil {
+		log.Error("failed to validate request", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	}
+
+	artist, err := h.service.CreateArtist(r.Context(), &req)
+	if errors.Is(err, respository.ErrArtistAlreadyExists) {
+		log.Error("artist already exists", sl.Err(err))
+
+		w.WriteHeader(http.StatusConflict)
+		render.JSON(w, r, response.Error("artist already exists"))
+		return
+	}
+	if err != nil {
+		log.Error("failed to create artist", sl.Err(err))
+
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("failed to create artist"))
+		return
+	}
+
+	render.JSON(w, r, response.OK(artist))
+}
+
+// GetArtist godoc
+// @Summary      Get an artist
+// @Description  Получение исполнителя с количеством песен
+// @Tags         Artists
+// @Produce      json
+// @Param        id   path      int  true                                "artist_id"
+// @Success      200  {object}  response.Response{data=models.Artist}  "OK"
+// @Failure      400  {object}  response.Response                      "Bad Request"
+// @Failure      404  {object}  response.Response                      "Artist Not Found"
+// @Failure      500  {object}  response.Response                      "Internal Server Error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /artists/{id} [get]
+func (h *Handler) GetArtist(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.GetArtist"
+	r, span := tracing.StartRequest(r, op)
+	defer span.End()
+
+	log := h.setLogger(r.Context(), op, h.log)
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id <= 0 {
+		log.Error("invalid artist_id")
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(models.ErrInvalidArtistID.Error()))
+		return
+	}
+
+	artist, err := h.service.GetArtist(r.Context(), id)
+	if errors.Is(err, respository.ErrArtistNotFound) {
+		log.Error("artist not found", sl.Err(err))
+
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, response.Error("artist not found"))
+		return
+	}
+	if err != nil {
+		log.Error("failed to get artist", sl.Err(err))
+
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("failed to get artist"))
+		return
+	}
+
+	render.JSON(w, r, response.OK(artist))
+}
+
+// ListArtists godoc
+// @Summary      Get list of artists
+// @Description  Получение списка исполнителей с количеством песен, фильтрацией по имени и пагинацией
+// @Tags         Artists
+// @Accept       json
+// @Produce      json
+// @Param        filter  body      models.ArtistsFilter  false                "artists filters"
+// @Success      200     {object}  response.Response{data=models.Artists}  "OK"
+// @Failure      400     {object}  response.Response                       "Bad Request"
+// @Failure      500     {object}  response.Response                       "Internal Server Error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /artists/list [post]
+func (h *Handler) ListArtists(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.ListArtists"
+	r, span := tracing.StartRequest(r, op)
+	defer span.End()
+
+	log := h.setLogger(r.Context(), op, h.log)
+
+	var req models.ArtistsFilter
+
+	err := render.DecodeJSON(r.Body, &req)
+	if err != nil && !errors.Is(err, io.EOF) {
+		log.Error("failed to decode request body", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("failed to decode request body"))
+		return
+	}
+
+	list, err := h.service.ListArtists(r.Context(), &req)
+	if err != nil {
+		log.Error("failed to list artists", sl.Err(err))
+
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("failed to list artists"))
+		return
+	}
+
+	render.JSON(w, r, response.OK(list))
+}
+
+// UpdateArtist godoc
+// @Summary      Rename an artist
+// @Description  Переименование исполнителя. Новое имя применяется ко всем его песням
+// @Tags         Artists
+// @Accept       json
+// @Produce      json
+// @Param        artist  body      models.UpdateArtist  true                "artist id and new name"
+// @Success      200     {object}  response.Response{data=models.Artist}  "OK"
+// @Failure      400     {object}  response.Response                      "Bad Request"
+// @Failure      404     {object}  response.Response                      "Artist Not Found"
+// @Failure      409     {object}  response.Response                      "Artist Already Exists"
+// @Failure      500     {object}  response.Response                      "Internal Server Error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /artists [put]
+func (h *Handler) UpdateArtist(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.UpdateArtist"
+	r, span := tracing.StartRequest(r, op)
+	defer span.End()
+
+	log := h.setLogger(r.Context(), op, h.log)
+
+	var req models.UpdateArtist
+
+	err := render.DecodeJSON(r.Body, &req)
+	if errors.Is(err, io.EOF) {
+		log.Error("request body is empty")
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("request body is empty"))
+		return
+	}
+	if err != nil {
+		log.Error("failed to decode request body", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("failed to decode request body"))
+		return
+	}
+
+	log.Info("request body decoded", slog.Any("request", req))
+
+	err = req.Validate()
+	if err != nil {
+		log.Error("failed to validate request", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	}
+
+	artist, err := h.service.UpdateArtist(r.Context(), &req)
+	if errors.Is(err, respository.ErrArtistNotFound) {
+		log.Error("artist not found", sl.Err(err))
+
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, response.Error("artist not found"))
+		return
+	}
+	if errors.Is(err, respository.ErrArtistAlreadyExists) {
+		log.Error("artist already exists", sl.Err(err))
+
+		w.WriteHeader(http.StatusConflict)
+		render.JSON(w, r, response.Error("artist with this name already exists, merge them instead"))
+		return
+	}
+	if err != nil {
+		log.Error("failed to update artist", sl.Err(err))
+
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("failed to update artist"))
+		return
+	}
+
+	render.JSON(w, r, response.OK(artist))
+}
+
+// MergeArtists godoc
+// @Summary      Merge duplicate artists
+// @Description  Объединение дубликатов: песни исполнителей из source_ids переносятся к исполнителю id, дубликаты удаляются
+// @Tags         Artists
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int                  true                "target artist_id"
+// @Param        sources  body      models.MergeArtists  true                "duplicate artist ids"
+// @Success      200      {object}  response.Response{data=models.Artist}  "OK"
+// @Failure      400      {object}  response.Response                      "Bad Request"
+// @Failure      404      {object}  response.Response                      "Artist Not Found"
+// @Failure      500      {object}  response.Response                      "Internal Server Error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /artists/{id}/merge [post]
+func (h *Handler) MergeArtists(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.MergeArtists"
+	r, span := tracing.StartRequest(r, op)
+	defer span.End()
+
+	log := h.setLogger(r.Context(), op, h.log)
+
+	var req models.MergeArtists
+
+	err := render.DecodeJSON(r.Body, &req)
+	if errors.Is(err, io.EOF) {
+		log.Error("request body is empty")
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("request body is empty"))
+		return
+	}
+	if err != nil {
+		log.Error("failed to decode request body", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("failed to decode request body"))
+		return
+	}
+
+	req.TargetID, err = strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error("failed to decode id parameter", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(models.ErrInvalidArtistID.Error()))
+		return
+	}
+
+	log.Info("request body decoded", slog.Any("request", req))
+
+	err = req.Validate()
+	if err != nil {
+		log.Error("failed to validate request", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	}
+
+	artist, err := h.service.MergeArtists(r.Context(), &req)
+	if errors.Is(err, respository.ErrArtistNotFound) {
+		log.Error("artist not found", sl.Err(err))
+
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, response.Error("artist not found"))
+		return
+	}
+	if err != nil {
+		log.Error("failed to merge artists", sl.Err(err))
+
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("failed to merge artists"))
+		return
+	}
+
+	render.JSON(w, r, response.OK(artist))
+}
@@ -0,0 +1,158 @@
+package http
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"io"
+	"log/slog"
+	"net/http"
+	"songs-library/internal/models"
+	"songs-library/internal/respository"
+	"songs-library/pkg/api/response"
+	"songs-library/pkg/logger/sl"
+	"songs-library/pkg/tracing"
+	"strconv"
+)
+
+// ListDuplicates godoc
+// @Summary      List duplicate songs
+// @Description  Поиск возможных дубликатов: песни с похожими названием и исполнителем объединяются в группы.
+// @Description  Названия сравниваются без учёта регистра, пунктуации и "feat."; score - среднее сходства
+// @Description  названия и исполнителя
+// @Tags         Songs
+// @Produce      json
+// @Param        threshold  query     number  false  "minimal similarity, 0 to 1"  default(0.6)
+// @Param        limit      query     int     false  "max clusters"                default(50)
+// @Success      200        {object}  response.Response{data=models.DuplicateClusters}  "OK"
+// @Failure      400        {object}  response.Response                                 "Bad Request"
+// @Failure      500        {object}  response.Response                                 "Internal Server Error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /songs/duplicates [get]
+func (h *Handler) ListDuplicates(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.ListDuplicates"
+	r, span := tracing.StartRequest(r, op)
+	defer span.End()
+
+	log := h.setLogger(r.Context(), op, h.log)
+
+	var (
+		err error
+		req models.DuplicatesFilter
+	)
+
+	if v := r.URL.Query().Get("threshold"); v != "" {
+		req.Threshold, err = strconv.ParseFloat(v, 64)
+		if err != nil {
+			req.Threshold = -1
+		}
+	}
+
+	if v := r.URL.Query().Get("limit"); v != "" {
+		req.Limit, err = strconv.Atoi(v)
+		if err != nil {
+			req.Limit = -1
+		}
+	}
+
+	err = req.Validate()
+	if err != nil {
+		log.Error("failed to validate request", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	}
+
+	clusters, err := h.service.ListDuplicates(r.Context(), &req)
+	if err != nil {
+		log.Error("failed to list duplicates", sl.Err(err))
+
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("failed to list duplicates"))
+		return
+	}
+
+	render.JSON(w, r, response.OK(clusters))
+}
+
+// MergeSongs godoc
+// @Summary      Merge duplicate songs
+// @Description  Объединение дубликатов: песня id остаётся, песни из source_ids удаляются. Недостающие ссылка и
+// @Description  текст берутся из дубликатов (текст - самый длинный, вместе с синхронизированным текстом),
+// @Description  дата выхода - самая точная
+// @Tags         Songs
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int                true                                      "target song_id"
+// @Param        sources  body      models.MergeSongs  true                                      "duplicate song ids"
+// @Success      200      {object}  response.Response{data=models.SongWithText}  "OK"
+// @Failure      400      {object}  response.Response                            "Bad Request"
+// @Failure      404      {object}  response.Response                            "Song Not Found"
+// @Failure      500      {object}  response.Response                            "Internal Server Error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /songs/{id}/merge [post]
+func (h *Handler) MergeSongs(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.MergeSongs"
+	r, span := tracing.StartRequest(r, op)
+	defer span.End()
+
+	log := h.setLogger(r.Context(), op, h.log)
+
+	var req models.MergeSongs
+
+	err := render.DecodeJSON(r.Body, &req)
+	if errors.Is(err, io.EOF) {
+		log.Error("request body is empty")
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("request body is empty"))
+		return
+	}
+	if err != nil {
+		log.Error("failed to decode request body", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("failed to decode request body"))
+		return
+	}
+
+	req.TargetID, err = strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error("failed to decode id parameter", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(models.ErrInvalidSongID.Error()))
+		return
+	}
+
+	log.Info("request body decoded", slog.Any("request", req))
+
+	err = req.Validate()
+	if err != nil {
+		log.Error("failed to validate request", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	}
+
+	song, err := h.service.MergeSongs(r.Context(), &req)
+	if errors.Is(err, respository.ErrSongNotFound) {
+		log.Error("song not found", sl.Err(err))
+
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, response.Error("song not found"))
+		return
+	}
+	if err != nil {
+		log.Error("failed to merge songs", sl.Err(err))
+
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("failed to merge songs"))
+		return
+	}
+
+	render.JSON(w, r, response.OK(song))
+}
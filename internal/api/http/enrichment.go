@@ -0,0 +1,118 @@
+package http
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"io"
+	"log/slog"
+	"net/http"
+	"songs-library/internal/models"
+	"songs-library/internal/respository"
+	"songs-library/pkg/api/response"
+	"songs-library/pkg/logger/sl"
+	"songs-library/pkg/tracing"
+	"strconv"
+)
+
+// GetEnrichment godoc
+// @Summary      Get song enrichment status
+// @Description  Статус загрузки деталей песни из внешнего API: pending, ok или failed
+// @Tags         Enrichment
+// @Produce      json
+// @Param        id   path      int  true                                    "song_id"
+// @Success      200  {object}  response.Response{data=models.Enrichment}  "OK"
+// @Failure      400  {object}  response.Response                          "Bad Request"
+// @Failure      404  {object}  response.Response                          "Song Not Found"
+// @Failure      500  {object}  response.Response                          "Internal Server Error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /songs/{id}/enrichment [get]
+func (h *Handler) GetEnrichment(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.GetEnrichment"
+	r, span := tracing.StartRequest(r, op)
+	defer span.End()
+
+	log := h.setLogger(r.Context(), op, h.log)
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id <= 0 {
+		log.Error("invalid song_id")
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(models.ErrInvalidSongID.Error()))
+		return
+	}
+
+	enrichment, err := h.service.GetEnrichment(r.Context(), id)
+	if errors.Is(err, respository.ErrSongNotFound) {
+		log.Error("song not found", sl.Err(err))
+
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, response.Error("song not found"))
+		return
+	}
+	if err != nil {
+		log.Error("failed to get enrichment", sl.Err(err))
+
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("failed to get enrichment"))
+		return
+	}
+
+	render.JSON(w, r, response.OK(enrichment))
+}
+
+// RequeueEnrichment godoc
+// @Summary      Requeue song enrichment
+// @Description  Повторная загрузка деталей песен из внешнего API. Без ids в очередь возвращаются все песни со статусом failed
+// @Tags         Enrichment
+// @Accept       json
+// @Produce      json
+// @Param        songs  body      models.RequeueEnrichment  false                "song ids"
+// @Success      200    {object}  response.Response{data=models.Requeued}  "OK"
+// @Failure      400    {object}  response.Response                        "Bad Request"
+// @Failure      500    {object}  response.Response                        "Internal Server Error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /songs/enrichment/requeue [post]
+func (h *Handler) RequeueEnrichment(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.RequeueEnrichment"
+	r, span := tracing.StartRequest(r, op)
+	defer span.End()
+
+	log := h.setLogger(r.Context(), op, h.log)
+
+	var req models.RequeueEnrichment
+
+	err := render.DecodeJSON(r.Body, &req)
+	if err != nil && !errors.Is(err, io.EOF) {
+		log.Error("failed to decode request body", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("failed to decode request body"))
+		return
+	}
+
+	log.Info("request body decoded", slog.Any("request", req))
+
+	err = req.Validate()
+	if err != nil {
+		log.Error("failed to validate request", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	}
+
+	requeued, err := h.service.RequeueEnrichment(r.Context(), &req)
+	if err != nil {
+		log.Error("failed to requeue enrichment", sl.Err(err))
+
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("failed to requeue enrichment"))
+		return
+	}
+
+	render.JSON(w, r, response.OK(requeued))
+}
@@ -0,0 +1,39 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const MergePatchContentType = "application/merge-patch+json"
+
+// etag is the strong entity tag of a song version.
+func etag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// ifMatch returns the song versions listed in the If-Match header, nil if there is none or it is "*".
+// Tags that are not song versions, weak ones included, are kept as 0 so they never match.
+func ifMatch(r *http.Request) []int {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return nil
+	}
+
+	tags := strings.Split(header, ",")
+
+	versions := make([]int, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+
+		version, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(tag, `"`), `"`))
+		if err != nil || !strings.HasPrefix(tag, `"`) || !strings.HasSuffix(tag, `"`) {
+			version = 0
+		}
+
+		versions = append(versions, version)
+	}
+
+	return versions
+}
@@ -0,0 +1,180 @@
+package http
+
+import (
+	"github.com/go-chi/render"
+	"net/http"
+	"net/url"
+	"songs-library/internal/models"
+	"songs-library/pkg/api/response"
+	"songs-library/pkg/logger/sl"
+	"songs-library/pkg/tracing"
+	"strconv"
+	"time"
+)
+
+var exportContentTypes = map[string]string{
+	models.ExportFormatCSV:    "text/csv; charset=utf-8",
+	models.ExportFormatNDJSON: "application/x-ndjson",
+	models.ExportFormatJSON:   "application/json",
+}
+
+// ExportSongs godoc
+// @Summary      Export songs
+// @Description  Выгрузка всех песен, подходящих под фильтр, в CSV, NDJSON или JSON. Фильтры те же, что у /songs/list,
+// @Description  передаются в query (ids через запятую), пагинации нет. Песни читаются курсором и отдаются потоком,
+// @Description  поэтому ограничения времени запроса на выгрузку не действуют. include=text добавляет текст песен
+// @Tags         Songs
+// @Produce      plain
+// @Param        format         query     string  false  "export format" Enums(csv, ndjson, json)
+// @Param        include        query     string  false  "embedded resources" Enums(text)
+// @Param        ids            query     string  false  "comma separated song ids"
+// @Param        song           query     string  false  "song"
+// @Param        group          query     string  false  "group"
+// @Param        release_date   query     string  false  "release date period"
+// @Param        released_from  query     string  false  "released on or after"
+// @Param        released_to    query     string  false  "released on or before"
+// @Param        decade         query     int     false  "decade, e.g. 1990"
+// @Param        year           query     int     false  "year"
+// @Param        link           query     string  false  "link"
+// @Param        album_id       query     int     false  "album id"
+// @Param        tags_any       query     string  false  "comma separated tags, any of"
+// @Param        tags_all       query     string  false  "comma separated tags, all of"
+// @Param        tags_none      query     string  false  "comma separated tags, none of"
+// @Param        genre_ids      query     string  false  "comma separated genre ids, subgenres included"
+// @Param        lyrics         query     string  false  "lyrics search"
+// @Param        lyrics_mode    query     string  false  "lyrics search mode" Enums(plain, phrase, websearch)
+// @Param        sort           query     string  false  "sort, e.g. release_date:desc,song"
+// @Success      200            {string}  string             "CSV, NDJSON or JSON document"
+// @Failure      400            {object}  response.Response  "Bad Request"
+// @Failure      500            {object}  response.Response  "Internal Server Error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /songs/export [get]
+func (h *Handler) ExportSongs(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.ExportSongs"
+	r, span := tracing.StartRequest(r, op)
+	defer span.End()
+
+	log := h.setLogger(r.Context(), op, h.log)
+
+	query := r.URL.Query()
+
+	req := models.ExportSongs{
+		Format:  query.Get("format"),
+		Include: queryList(query, "include"),
+	}
+	if req.Format == "" {
+		req.Format = models.ExportFormatCSV
+	}
+
+	err := songsFilterFromQuery(query, &req.Filter)
+	if err == nil {
+		err = req.Validate()
+	}
+	if err != nil {
+		log.Error("failed to validate request", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	}
+
+	// The export takes as long as the library is large, the server write timeout doesn't apply to it.
+	rc := http.NewResponseController(w)
+	if err = rc.SetWriteDeadline(time.Time{}); err != nil {
+		log.Warn("failed to clear write deadline", sl.Err(err))
+	}
+
+	w.Header().Set("Content-Type", exportContentTypes[req.Format])
+	w.Header().Set("Content-Disposition", "attachment; filename=\"songs."+req.Format+"\"")
+
+	out := &exportWriter{w: w, rc: rc}
+
+	err = h.service.ExportSongs(r.Context(), &req, out)
+	if err != nil && !out.written {
+		log.Error("failed to export songs", sl.Err(err))
+
+		w.Header().Del("Content-Disposition")
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("failed to export songs"))
+		return
+	}
+	if err != nil {
+		// The status is sent already; aborting the connection tells the client the document is incomplete.
+		log.Error("export interrupted", sl.Err(err))
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// exportWriter records whether the response has started and flushes it to the client.
+type exportWriter struct {
+	w       http.ResponseWriter
+	rc      *http.ResponseController
+	written bool
+}
+
+func (e *exportWriter) Write(p []byte) (int, error) {
+	e.written = true
+
+	return e.w.Write(p)
+}
+
+func (e *exportWriter) Flush() error {
+	return e.rc.Flush()
+}
+
+// songsFilterFromQuery reads the filter fields of models.SongsFilter from query parameters of the same names.
+func songsFilterFromQuery(query url.Values, filter *models.SongsFilter) error {
+	filter.Song = query.Get("song")
+	filter.Group = query.Get("group")
+	filter.ReleaseDate = query.Get("release_date")
+	filter.ReleasedFrom = query.Get("released_from")
+	filter.ReleasedTo = query.Get("released_to")
+	filter.Link = query.Get("link")
+	filter.Lyrics = query.Get("lyrics")
+	filter.LyricsMode = query.Get("lyrics_mode")
+	filter.Sort = query.Get("sort")
+
+	for _, id := range queryList(query, "ids") {
+		songID, err := strconv.Atoi(id)
+		if err != nil {
+			return models.ErrInvalidSongID
+		}
+
+		filter.IDs = append(filter.IDs, songID)
+	}
+
+	filter.TagsAny = queryList(query, "tags_any")
+	filter.TagsAll = queryList(query, "tags_all")
+	filter.TagsNone = queryList(query, "tags_none")
+
+	for _, id := range queryList(query, "genre_ids") {
+		genreID, err := strconv.Atoi(id)
+		if err != nil {
+			return models.ErrInvalidGenreID
+		}
+
+		filter.GenreIDs = append(filter.GenreIDs, genreID)
+	}
+
+	var err error
+	if decade := query.Get("decade"); decade != "" {
+		if filter.Decade, err = strconv.Atoi(decade); err != nil {
+			return models.ErrInvalidDecade
+		}
+	}
+
+	if year := query.Get("year"); year != "" {
+		if filter.Year, err = strconv.Atoi(year); err != nil {
+			return models.ErrInvalidYear
+		}
+	}
+
+	if albumID := query.Get("album_id"); albumID != "" {
+		if filter.AlbumID, err = strconv.Atoi(albumID); err != nil {
+			return models.ErrInvalidAlbumID
+		}
+	}
+
+	return nil
+}
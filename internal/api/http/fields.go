@@ -0,0 +1,43 @@
+package http
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+)
+
+// queryList returns the comma separated values of the query parameter, repeated parameters included.
+func queryList(query url.Values, name string) []string {
+	var list []string
+	for _, value := range query[name] {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+	}
+
+	return list
+}
+
+// sparseFields keeps only the given JSON members of v.
+func sparseFields(v any, fields []string) (map[string]any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var all map[string]any
+	if err = json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+
+	sparse := make(map[string]any, len(fields))
+	for _, field := range fields {
+		if value, ok := all[field]; ok {
+			sparse[field] = value
+		}
+	}
+
+	return sparse, nil
+}
@@ -0,0 +1,309 @@
+package http
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"io"
+	"log/slog"
+	"net/http"
+	"songs-library/internal/models"
+	"songs-library/internal/respository"
+	"songs-library/pkg/api/response"
+	"songs-library/pkg/logger/sl"
+	"songs-library/pkg/tracing"
+	"strconv"
+)
+
+// CreateGenre godoc
+// @Summary      Create a genre
+// @Description  Добавление жанра. С parent_id жанр становится поджанром, например Rock > Alternative Rock.
+// @Description  Названия жанров одного родителя сравниваются без учета регистра
+// @Tags         Genres
+// @Accept       json
+// @Produce      json
+// @Param        genre  body      models.CreateGenre  true               "genre name and parent"
+// @Success      200    {object}  response.Response{data=models.Genre}  "OK"
+// @Failure      400    {object}  response.Response                     "Bad Request"
+// @Failure      404    {object}  response.Response                     "Parent Genre Not Found"
+// @Failure      409    {object}  response.Response                     "Genre Already Exists"
+// @Failure      500    {object}  response.Response                     "Internal Server Error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /genres [post]
+func (h *Handler) CreateGenre(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.CreateGenre"
+	r, span := tracing.StartRequest(r, op)
+	defer span.End()
+
+	log := h.setLogger(r.Context(), op, h.log)
+
+	var req models.CreateGenre
+
+	err := render.DecodeJSON(r.Body, &req)
+	if errors.Is(err, io.EOF) {
+		log.Error("request body is empty")
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("request body is empty"))
+		return
+	}
+	if err != nil {
+		log.Error("failed to decode request body", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("failed to decode request body"))
+		return
+	}
+
+	log.Info("request body decoded", slog.Any("request", req))
+
+	err = req.Validate()
+	if err != nil {
+		log.Error("failed to validate request", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	}
+
+	genre, err := h.service.CreateGenre(r.Context(), &req)
+	if errors.Is(err, respository.ErrGenreNotFound) {
+		log.Error("parent genre not found", sl.Err(err))
+
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, response.Error("parent genre not found"))
+		return
+	}
+	if errors.Is(err, respository.ErrGenreAlreadyExists) {
+		log.Error("genre already exists", sl.Err(err))
+
+		w.WriteHeader(http.StatusConflict)
+		render.JSON(w, r, response.Error("genre already exists"))
+		return
+	}
+	if err != nil {
+		log.Error("failed to create genre", sl.Err(err))
+
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("failed to create genre"))
+		return
+	}
+
+	render.JSON(w, r, response.OK(genre))
+}
+
+// GetGenre godoc
+// @Summary      Get a genre
+// @Description  Получение жанра с путём от корня дерева и количеством песен
+// @Tags         Genres
+// @Produce      json
+// @Param        id   path      int  true                               "genre_id"
+// @Success      200  {object}  response.Response{data=models.Genre}  "OK"
+// @Failure      400  {object}  response.Response                     "Bad Request"
+// @Failure      404  {object}  response.Response                     "Genre Not Found"
+// @Failure      500  {object}  response.Response                     "Internal Server Error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /genres/{id} [get]
+func (h *Handler) GetGenre(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.GetGenre"
+	r, span := tracing.StartRequest(r, op)
+	defer span.End()
+
+	log := h.setLogger(r.Context(), op, h.log)
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id <= 0 {
+		log.Error("invalid genre_id", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(models.ErrInvalidGenreID.Error()))
+		return
+	}
+
+	genre, err := h.service.GetGenre(r.Context(), id)
+	if errors.Is(err, respository.ErrGenreNotFound) {
+		log.Error("genre not found", sl.Err(err))
+
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, response.Error("genre not found"))
+		return
+	}
+	if err != nil {
+		log.Error("failed to get genre", sl.Err(err))
+
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("failed to get genre"))
+		return
+	}
+
+	render.JSON(w, r, response.OK(genre))
+}
+
+// ListGenres godoc
+// @Summary      Get the genre tree
+// @Description  Получение всех жанров: каждый жанр следует за родителем, поджанры одного родителя - по названию
+// @Tags         Genres
+// @Produce      json
+// @Success      200  {object}  response.Response{data=models.Genres}  "OK"
+// @Failure      500  {object}  response.Response                      "Internal Server Error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /genres [get]
+func (h *Handler) ListGenres(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.ListGenres"
+	r, span := tracing.StartRequest(r, op)
+	defer span.End()
+
+	log := h.setLogger(r.Context(), op, h.log)
+
+	genres, err := h.service.ListGenres(r.Context())
+	if err != nil {
+		log.Error("failed to list genres", sl.Err(err))
+
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("failed to list genres"))
+		return
+	}
+
+	render.JSON(w, r, response.OK(genres))
+}
+
+// UpdateGenre godoc
+// @Summary      Update a genre
+// @Description  Переименование жанра и перенос под другой жанр; без parent_id жанр становится корневым.
+// @Description  Жанр нельзя перенести под самого себя или свой поджанр
+// @Tags         Genres
+// @Accept       json
+// @Produce      json
+// @Param        genre  body      models.UpdateGenre  true               "genre id, name and parent"
+// @Success      200    {object}  response.Response{data=models.Genre}  "OK"
+// @Failure      400    {object}  response.Response                     "Bad Request"
+// @Failure      404    {object}  response.Response                     "Genre Not Found"
+// @Failure      409    {object}  response.Response                     "Genre Already Exists or Cycle"
+// @Failure      500    {object}  response.Response                     "Internal Server Error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /genres [put]
+func (h *Handler) UpdateGenre(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.UpdateGenre"
+	r, span := tracing.StartRequest(r, op)
+	defer span.End()
+
+	log := h.setLogger(r.Context(), op, h.log)
+
+	var req models.UpdateGenre
+
+	err := render.DecodeJSON(r.Body, &req)
+	if errors.Is(err, io.EOF) {
+		log.Error("request body is empty")
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("request body is empty"))
+		return
+	}
+	if err != nil {
+		log.Error("failed to decode request body", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("failed to decode request body"))
+		return
+	}
+
+	log.Info("request body decoded", slog.Any("request", req))
+
+	err = req.Validate()
+	if err != nil {
+		log.Error("failed to validate request", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	}
+
+	genre, err := h.service.UpdateGenre(r.Context(), &req)
+	if errors.Is(err, respository.ErrGenreNotFound) {
+		log.Error("genre not found", sl.Err(err))
+
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, response.Error("genre not found"))
+		return
+	}
+	if errors.Is(err, respository.ErrGenreAlreadyExists) {
+		log.Error("genre already exists", sl.Err(err))
+
+		w.WriteHeader(http.StatusConflict)
+		render.JSON(w, r, response.Error("genre already exists"))
+		return
+	}
+	if errors.Is(err, respository.ErrGenreCycle) {
+		log.Error("genre cannot be moved", sl.Err(err))
+
+		w.WriteHeader(http.StatusConflict)
+		render.JSON(w, r, response.Error(respository.ErrGenreCycle.Error()))
+		return
+	}
+	if err != nil {
+		log.Error("failed to update genre", sl.Err(err))
+
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("failed to update genre"))
+		return
+	}
+
+	render.JSON(w, r, response.OK(genre))
+}
+
+// DeleteGenre godoc
+// @Summary      Delete a genre
+// @Description  Удаление жанра без поджанров. Песни жанра его теряют
+// @Tags         Genres
+// @Param        id   path      int  true             "genre_id"
+// @Success      200  {object}  response.Response  "OK"
+// @Failure      400  {object}  response.Response  "Bad Request"
+// @Failure      404  {object}  response.Response  "Genre Not Found"
+// @Failure      409  {object}  response.Response  "Genre Has Subgenres"
+// @Failure      500  {object}  response.Response  "Internal Server Error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /genres/{id} [delete]
+func (h *Handler) DeleteGenre(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.DeleteGenre"
+	r, span := tracing.StartRequest(r, op)
+	defer span.End()
+
+	log := h.setLogger(r.Context(), op, h.log)
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id <= 0 {
+		log.Error("invalid genre_id", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(models.ErrInvalidGenreID.Error()))
+		return
+	}
+
+	err = h.service.DeleteGenre(r.Context(), id)
+	if errors.Is(err, respository.ErrGenreNotFound) {
+		log.Error("genre not found", sl.Err(err))
+
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, response.Error("genre not found"))
+		return
+	}
+	if errors.Is(err, respository.ErrGenreHasSubgenres) {
+		log.Error("genre has subgenres", sl.Err(err))
+
+		w.WriteHeader(http.StatusConflict)
+		render.JSON(w, r, response.Error("genre has subgenres"))
+		return
+	}
+	if err != nil {
+		log.Error("failed to delete genre", sl.Err(err))
+
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("failed to delete genre"))
+		return
+	}
+
+	render.JSON(w, r, response.OK(nil))
+}
@@ -4,16 +4,18 @@ import (
 	"context"
 	"errors"
 	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
 	"io"
 	"log/slog"
+	"mime"
 	"net/http"
 	"songs-library/internal"
+	"songs-library/internal/consts"
 	"songs-library/internal/models"
 	"songs-library/internal/respository"
 	"songs-library/pkg/api/response"
 	"songs-library/pkg/logger/sl"
+	"songs-library/pkg/tracing"
 	"strconv"
 )
 
@@ -31,17 +33,26 @@ func NewHandler(log *slog.Logger, service internal.Service) *Handler {
 
 // CreateSong godoc
 // @Summary      Create a song
-// @Description  Добавление новой песни
+// @Description  Добавление новой песни. Дата выхода, текст и ссылка загружаются из внешнего API в фоне,
+// @Description  статус загрузки возвращается в поле enrichment_status. on_duplicate задаёт поведение, если у
+// @Description  исполнителя уже есть песня с таким названием: allow - добавить, reject - ошибка 409 с
+// @Description  существующей песней, return - вернуть существующую песню
 // @Tags         Songs
 // @Accept       json
 // @Produce      json
 // @Param        song  body      models.CreateSong  true              "song and group"
 // @Success      200   {object}  response.Response{data=models.Song}  "OK"
 // @Failure      400   {object}  response.Response                    "Bad Request"
+// @Failure      409   {object}  response.Response{data=models.Song}  "Song Already Exists"
 // @Failure      500   {object}  response.Response                    "Internal Server Error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
 // @Router       /songs [post]
 func (h *Handler) CreateSong(w http.ResponseWriter, r *http.Request) {
 	const op = "handler.CreateSong"
+	r, span := tracing.StartRequest(r, op)
+	defer span.End()
+
 	log := h.setLogger(r.Context(), op, h.log)
 
 	var req models.CreateSong
@@ -72,7 +83,14 @@ func (h *Handler) CreateSong(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	song, err := h.service.CreateSong(&req)
+	song, err := h.service.CreateSong(r.Context(), &req)
+	var dup *models.DuplicateSongError
+	if errors.As(err, &dup) {
+		log.Error("song already exists", sl.Err(err))
+		w.WriteHeader(http.StatusConflict)
+		render.JSON(w, r, response.ErrorData(dup.Error(), dup.Song))
+		return
+	}
 	if err != nil {
 		log.Error("failed to create song", sl.Err(err))
 		w.WriteHeader(http.StatusInternalServerError)
@@ -83,6 +101,97 @@ func (h *Handler) CreateSong(w http.ResponseWriter, r *http.Request) {
 	render.JSON(w, r, response.OK(song))
 }
 
+// GetSong godoc
+// @Summary      Get a song
+// @Description  Получение песни по id. fields ограничивает ответ перечисленными полями (через запятую),
+// @Description  include=text добавляет в ответ текст песни
+// @Tags         Songs
+// @Produce      json
+// @Param        id       path      int     true   "song_id"
+// @Param        fields   query     string  false  "comma separated fields, e.g. song,group,release_date"
+// @Param        include  query     string  false  "embedded resources" Enums(text)
+// @Success      200      {object}  response.Response{data=models.SongWithText}  "OK"
+// @Header       200      {string}  ETag  "version of the song"
+// @Failure      400      {object}  response.Response                            "Bad Request"
+// @Failure      404      {object}  response.Response                            "Song Not Found"
+// @Failure      500      {object}  response.Response                            "Internal Server Error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /songs/{id} [get]
+func (h *Handler) GetSong(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.GetSong"
+	r, span := tracing.StartRequest(r, op)
+	defer span.End()
+
+	log := h.setLogger(r.Context(), op, h.log)
+
+	var (
+		err error
+		req models.GetSong
+	)
+
+	req.ID, err = strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		req.ID = 0
+	}
+
+	req.Fields = queryList(r.URL.Query(), "fields")
+	req.Include = queryList(r.URL.Query(), "include")
+
+	err = req.Validate()
+	if err != nil {
+		log.Error("failed to validate request", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	}
+
+	song, err := h.service.GetSong(r.Context(), &req)
+	if errors.Is(err, respository.ErrSongNotFound) {
+		log.Error("song not found", sl.Err(err))
+
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, response.Error("song not found"))
+		return
+	}
+	if err != nil {
+		log.Error("failed to get song", sl.Err(err))
+
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("failed to get song"))
+		return
+	}
+
+	w.Header().Set("ETag", etag(song.Version))
+
+	if !req.IncludesText() && len(req.Fields) == 0 {
+		render.JSON(w, r, response.OK(song.Song))
+		return
+	}
+
+	if len(req.Fields) == 0 {
+		render.JSON(w, r, response.OK(song))
+		return
+	}
+
+	fields := req.Fields
+	if req.IncludesText() {
+		fields = append(fields, models.IncludeText)
+	}
+
+	sparse, err := sparseFields(song, fields)
+	if err != nil {
+		log.Error("failed to select fields", sl.Err(err))
+
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("failed to get song"))
+		return
+	}
+
+	render.JSON(w, r, response.OK(sparse))
+}
+
 // DeleteSong godoc
 // @Summary      Delete a song
 // @Description  Удаление песни
@@ -92,9 +201,14 @@ func (h *Handler) CreateSong(w http.ResponseWriter, r *http.Request) {
 // @Failure      400  {object}  response.Response "Bad Request"
 // @Failure      404  {object}  response.Response "Song Not Found"
 // @Failure      500  {object}  response.Response "Internal Server Error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
 // @Router       /songs/{id} [delete]
 func (h *Handler) DeleteSong(w http.ResponseWriter, r *http.Request) {
 	const op = "handler.DeleteSong"
+	r, span := tracing.StartRequest(r, op)
+	defer span.End()
+
 	log := h.setLogger(r.Context(), op, h.log)
 
 	str := chi.URLParam(r, "id")
@@ -115,7 +229,7 @@ func (h *Handler) DeleteSong(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.service.DeleteSong(id)
+	err = h.service.DeleteSong(r.Context(), id)
 	if errors.Is(err, respository.ErrSongNotFound) {
 		log.Error("song not found", sl.Err(err))
 
@@ -136,17 +250,26 @@ func (h *Handler) DeleteSong(w http.ResponseWriter, r *http.Request) {
 
 // UpdateSong godoc
 // @Summary      Update a song
-// @Description  Изменение данных песни
+// @Description  Изменение данных песни. С заголовком If-Match изменение применяется, только если песня не менялась
+// @Description  с этой версии
 // @Tags         Songs
 // @Accept       json
-// @Param        song  body      models.UpdateSong  true "Song Attrs"
-// @Success      200   {object}  response.Response{data=models.UpdateSong}  "OK"
-// @Failure      400   {object}  response.Response                          "Bad Request"
-// @Failure      404   {object}  response.Response                          "Song Not Found"
-// @Failure      500   {object}  response.Response                          "Internal Server Error"
+// @Param        song      body      models.UpdateSong  true   "Song Attrs"
+// @Param        If-Match  header    string             false  "ETag of the song version the update applies to"
+// @Success      200       {object}  response.Response{data=models.UpdateSong}  "OK"
+// @Header       200       {string}  ETag  "version of the updated song"
+// @Failure      400       {object}  response.Response                          "Bad Request"
+// @Failure      404       {object}  response.Response                          "Song Not Found"
+// @Failure      412       {object}  response.Response                          "Precondition Failed"
+// @Failure      500       {object}  response.Response                          "Internal Server Error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
 // @Router       /songs [put]
 func (h *Handler) UpdateSong(w http.ResponseWriter, r *http.Request) {
 	const op = "handler.UpdateSong"
+	r, span := tracing.StartRequest(r, op)
+	defer span.End()
+
 	log := h.setLogger(r.Context(), op, h.log)
 
 	var req models.UpdateSong
@@ -166,6 +289,8 @@ func (h *Handler) UpdateSong(w http.ResponseWriter, r *http.Request) {
 
 	log.Info("request body decoded", slog.Any("request", req))
 
+	req.IfMatch = ifMatch(r)
+
 	err = req.Validate()
 	if err != nil {
 		log.Error("failed to validate request", sl.Err(err))
@@ -175,7 +300,7 @@ func (h *Handler) UpdateSong(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	song, err := h.service.UpdateSong(&req)
+	song, err := h.service.UpdateSong(r.Context(), &req)
 	if errors.Is(err, respository.ErrSongNotFound) {
 		log.Error("song not found", sl.Err(err))
 
@@ -183,6 +308,13 @@ func (h *Handler) UpdateSong(w http.ResponseWriter, r *http.Request) {
 		render.JSON(w, r, response.Error("song not found"))
 		return
 	}
+	if errors.Is(err, respository.ErrSongVersionMismatch) {
+		log.Error("song version mismatch", sl.Err(err))
+
+		w.WriteHeader(http.StatusPreconditionFailed)
+		render.JSON(w, r, response.Error("song was changed since the version in If-Match"))
+		return
+	}
 	if err != nil {
 		log.Error("failed to update song", sl.Err(err))
 		w.WriteHeader(http.StatusInternalServerError)
@@ -190,22 +322,141 @@ func (h *Handler) UpdateSong(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	w.Header().Set("ETag", etag(song.Version))
+	render.JSON(w, r, response.OK(song))
+}
+
+// PatchSong godoc
+// @Summary      Patch a song
+// @Description  Частичное изменение песни в формате JSON Merge Patch (RFC 7396): переданные поля заменяются,
+// @Description  поля со значением null очищаются, остальные не меняются. Изменяемые поля: song, group, release_date,
+// @Description  text, link. С заголовком If-Match изменение применяется, только если песня не менялась с этой версии
+// @Tags         Songs
+// @Accept       json
+// @Accept       application/merge-patch+json
+// @Produce      json
+// @Param        id        path      int                true   "song_id"
+// @Param        patch     body      models.SongFields  true   "merge patch"
+// @Param        If-Match  header    string             false  "ETag of the song version the patch applies to"
+// @Success      200       {object}  response.Response{data=models.Song}  "OK"
+// @Header       200       {string}  ETag  "version of the patched song"
+// @Failure      400       {object}  response.Response  "Bad Request"
+// @Failure      404       {object}  response.Response  "Song Not Found"
+// @Failure      412       {object}  response.Response  "Precondition Failed"
+// @Failure      415       {object}  response.Response  "Unsupported Media Type"
+// @Failure      500       {object}  response.Response  "Internal Server Error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /songs/{id} [patch]
+func (h *Handler) PatchSong(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.PatchSong"
+	r, span := tracing.StartRequest(r, op)
+	defer span.End()
+
+	log := h.setLogger(r.Context(), op, h.log)
+
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if contentType != MergePatchContentType && contentType != "application/json" {
+		log.Error("unsupported content type", slog.String("contentType", contentType))
+
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+		render.JSON(w, r, response.Error("content type must be "+MergePatchContentType))
+		return
+	}
+
+	var (
+		err error
+		req models.PatchSong
+	)
+
+	req.ID, err = strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		req.ID = 0
+	}
+
+	req.Patch, err = io.ReadAll(http.MaxBytesReader(w, r.Body, consts.MaxPatchSize))
+	if err != nil {
+		log.Error("failed to read request body", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("failed to read request body"))
+		return
+	}
+
+	req.IfMatch = ifMatch(r)
+
+	err = req.Validate()
+	if err != nil {
+		log.Error("failed to validate request", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	}
+
+	song, err := h.service.PatchSong(r.Context(), &req)
+	if errors.Is(err, models.ErrInvalidPatch) {
+		log.Error("invalid merge patch", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	}
+	if errors.Is(err, respository.ErrSongNotFound) {
+		log.Error("song not found", sl.Err(err))
+
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, response.Error("song not found"))
+		return
+	}
+	if errors.Is(err, respository.ErrSongVersionMismatch) {
+		log.Error("song version mismatch", sl.Err(err))
+
+		w.WriteHeader(http.StatusPreconditionFailed)
+		render.JSON(w, r, response.Error("song was changed since the version in If-Match"))
+		return
+	}
+	if err != nil {
+		log.Error("failed to patch song", sl.Err(err))
+
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("failed to patch song"))
+		return
+	}
+
+	w.Header().Set("ETag", etag(song.Version))
 	render.JSON(w, r, response.OK(song))
 }
 
 // ListSongs godoc
 // @Summary      Get list of songs
-// @Description  Получение данных библиотеки с фильтрацией по всем полям и пагинацией
+// @Description  Получение данных библиотеки с фильтрацией по всем полям и пагинацией.
+// @Description  Поле lyrics включает полнотекстовый поиск по тексту песни: результаты сортируются по релевантности
+// @Description  и содержат подсвеченный фрагмент текста. lyrics_mode: plain (по словам), phrase (фраза целиком),
+// @Description  websearch (синтаксис поисковика: "фраза", or, -исключение).
+// @Description  Даты выхода принимаются в форматах 2006-07-16, 16.07.2006, 2006-07 или 2006 и возвращаются в ISO 8601
+// @Description  с известной точностью. release_date выбирает весь указанный период, released_from и released_to задают
+// @Description  диапазон, decade (например, 1990) и year выбирают десятилетие и год.
+// @Description  sort задаёт порядок: song, group, release_date, created_at, relevance с :asc или :desc через запятую,
+// @Description  например "release_date:desc,song". page.next_cursor и page.prev_cursor передаются в cursor для перехода
+// @Description  к соседней странице без смещения; with_total добавляет общее число найденных песен. album_id оставляет
+// @Description  песни альбома. tags_any, tags_all и tags_none отбирают песни с любым, со всеми или без указанных тегов,
+// @Description  genre_ids - песни указанных жанров и их поджанров.
 // @Tags         Songs
 // @Accept       json
 // @Produce      json
 // @Param        song  body      models.SongsFilter  false             "songs filters"
-// @Success      200   {object}  response.Response{data=models.Songs}  "OK"
-// @Failure      400   {object}  response.Response                     "Bad Request"
-// @Failure      500   {object}  response.Response                     "Internal Server Error"
+// @Success      200   {object}  response.Response{data=models.Songs,page=response.Page}  "OK"
+// @Failure      400   {object}  response.Response                                        "Bad Request"
+// @Failure      500   {object}  response.Response                                        "Internal Server Error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
 // @Router       /songs/list [post]
 func (h *Handler) ListSongs(w http.ResponseWriter, r *http.Request) {
 	const op = "handler.ListSongs"
+	r, span := tracing.StartRequest(r, op)
+	defer span.End()
+
 	log := h.setLogger(r.Context(), op, h.log)
 
 	var req models.SongsFilter
@@ -219,7 +470,16 @@ func (h *Handler) ListSongs(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	list, err := h.service.ListSongs(&req)
+	err = req.Validate()
+	if err != nil {
+		log.Error("failed to validate request", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	}
+
+	list, err := h.service.ListSongs(r.Context(), &req)
 	if err != nil {
 		log.Error("failed to list songs", sl.Err(err))
 
@@ -228,25 +488,38 @@ func (h *Handler) ListSongs(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	render.JSON(w, r, response.OK(list))
+	render.JSON(w, r, response.OKPage(list.Songs, &response.Page{
+		NextCursor: list.NextCursor,
+		PrevCursor: list.PrevCursor,
+		Total:      list.Total,
+	}))
 }
 
 // GetTextBySongID godoc
 // @Summary      Get song's text
-// @Description  Получение текста песни с пагинацией по куплетам
+// @Description  Получение текста песни с пагинацией по куплетам, секциям или строкам.
+// @Description  Секции (куплет, припев, бридж и т.д.) размечаются по маркерам вида [Chorus], [Verse 2], Bridge:
+// @Description  и по повторяющимся блокам. Параметр section оставляет только секции указанного типа
 // @Tags         Texts
 // @Accept       json
 // @Produce      json
 // @Param        id   query     int     true  "song_id"
 // @Param        page   query     int     false  "page"
 // @Param        perPage   query     int     false  "per page"
+// @Param        by   query     string     false  "paginate by" Enums(verse, section, line)
+// @Param        section   query     string     false  "section type" Enums(verse, chorus, pre-chorus, bridge, intro, outro, hook, other)
 // @Success      200   {object}  response.Response{data=models.Text}  "OK"
 // @Failure      400   {object}  response.Response                    "Bad Request"
 // @Failure      404   {object}  response.Response                    "Song Not Found"
 // @Failure      500   {object}  response.Response                    "Internal Server Error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
 // @Router       /songs/texts [get]
 func (h *Handler) GetTextBySongID(w http.ResponseWriter, r *http.Request) {
 	const op = "handler.GetTextBySongID"
+	r, span := tracing.StartRequest(r, op)
+	defer span.End()
+
 	log := h.setLogger(r.Context(), op, h.log)
 
 	var (
@@ -273,6 +546,9 @@ func (h *Handler) GetTextBySongID(w http.ResponseWriter, r *http.Request) {
 		req.PerPage = 0
 	}
 
+	req.By = r.URL.Query().Get("by")
+	req.Section = r.URL.Query().Get("section")
+
 	err = req.Validate()
 	if err != nil {
 		log.Error("failed to validate request", sl.Err(err))
@@ -282,7 +558,7 @@ func (h *Handler) GetTextBySongID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	text, err := h.service.GetTextBySongID(&req)
+	text, err := h.service.GetTextBySongID(r.Context(), &req)
 
 	if errors.Is(err, respository.ErrSongNotFound) {
 		log.Error("song not found", sl.Err(err))
@@ -305,6 +581,8 @@ func (h *Handler) GetTextBySongID(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) setLogger(ctx context.Context, op string, log *slog.Logger) *slog.Logger {
 	return log.With(
 		slog.String("op", op),
-		slog.String("request_id", middleware.GetReqID(ctx)),
+		sl.RequestID(ctx),
+		sl.Principal(ctx),
+		sl.TraceID(ctx),
 	)
 }
@@ -0,0 +1,127 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-chi/render"
+	"mime"
+	"net/http"
+	"songs-library/internal/consts"
+	"songs-library/internal/importer"
+	"songs-library/internal/models"
+	"songs-library/pkg/api/response"
+	"songs-library/pkg/logger/sl"
+	"songs-library/pkg/tracing"
+	"strconv"
+	"time"
+)
+
+// importFormats maps the content types of import documents to their format.
+var importFormats = map[string]string{
+	"text/csv":             models.ImportFormatCSV,
+	"application/json":     models.ImportFormatJSON,
+	"application/x-ndjson": models.ImportFormatNDJSON,
+	"application/ndjson":   models.ImportFormatNDJSON,
+	"application/jsonl":    models.ImportFormatNDJSON,
+}
+
+// ImportSongs godoc
+// @Summary      Import songs
+// @Description  Массовая загрузка песен из CSV (с заголовком song,group,release_date,text,link,album,disc_number,track_number), JSON-массива или NDJSON.
+// @Description  Формат берётся из параметра format или из Content-Type. Каждая строка проверяется по правилам создания
+// @Description  песни, песни сохраняются пачками по batch_size в одной транзакции. Песня того же исполнителя с тем же
+// @Description  названием считается дубликатом. С enrich=true песни без даты, текста или ссылки ставятся в очередь
+// @Description  на загрузку данных. Необязательные album, disc_number и track_number добавляют песню в альбом исполнителя.
+// @Description  Документ ограничен 64 МиБ, запрос — 5 минутами; большие каталоги удобнее загружать командой import
+// @Tags         Songs
+// @Accept       plain
+// @Produce      json
+// @Param        format      query     string  false  "document format" Enums(csv, json, ndjson)
+// @Param        enrich      query     bool    false  "enrich songs missing details"
+// @Param        batch_size  query     int     false  "rows per transaction, 100 by default"
+// @Param        document    body      string  true   "CSV, JSON or NDJSON document"
+// @Success      200         {object}  response.Response{data=models.ImportReport}  "OK"
+// @Failure      400         {object}  response.Response{data=models.ImportReport}  "Bad Request"
+// @Failure      413         {object}  response.Response{data=models.ImportReport}  "Request Entity Too Large"
+// @Failure      500         {object}  response.Response{data=models.ImportReport}  "Internal Server Error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /songs/import [post]
+func (h *Handler) ImportSongs(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.ImportSongs"
+	r, span := tracing.StartRequest(r, op)
+	defer span.End()
+
+	log := h.setLogger(r.Context(), op, h.log)
+
+	// A document takes longer to upload and import than the server deadlines allow, so it gets its own.
+	deadline := time.Now().Add(consts.ImportTimeout)
+	rc := http.NewResponseController(w)
+	if err := rc.SetReadDeadline(deadline); err != nil {
+		log.Warn("failed to extend read deadline", sl.Err(err))
+	}
+	if err := rc.SetWriteDeadline(deadline); err != nil {
+		log.Warn("failed to extend write deadline", sl.Err(err))
+	}
+
+	req := models.ImportSongs{
+		Format:    r.URL.Query().Get("format"),
+		BatchSize: models.DefaultImportBatchSize,
+	}
+
+	if req.Format == "" {
+		contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		req.Format = importFormats[contentType]
+	}
+
+	if enrich := r.URL.Query().Get("enrich"); enrich != "" {
+		var err error
+		if req.Enrich, err = strconv.ParseBool(enrich); err != nil {
+			log.Error("invalid enrich parameter", sl.Err(err))
+
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("enrich must be true or false"))
+			return
+		}
+	}
+
+	if batchSize := r.URL.Query().Get("batch_size"); batchSize != "" {
+		req.BatchSize, _ = strconv.Atoi(batchSize)
+	}
+
+	err := req.Validate()
+	if err != nil {
+		log.Error("failed to validate request", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	}
+
+	report, err := h.service.ImportSongs(r.Context(), http.MaxBytesReader(w, r.Body, consts.MaxImportSize), &req)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		log.Error("import document too large", sl.Err(err))
+
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		render.JSON(w, r, response.ErrorData(
+			fmt.Sprintf("document is larger than %d bytes, use the import command", tooLarge.Limit), report))
+		return
+	}
+	if errors.Is(err, importer.ErrInvalidDocument) {
+		log.Error("invalid import document", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.ErrorData(errors.Unwrap(err).Error(), report))
+		return
+	}
+	if err != nil {
+		log.Error("failed to import songs", sl.Err(err))
+
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.ErrorData("failed to import songs", report))
+		return
+	}
+
+	render.JSON(w, r, response.OK(report))
+}
@@ -0,0 +1,676 @@
+package http
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"io"
+	"log/slog"
+	"net/http"
+	"songs-library/internal/models"
+	"songs-library/internal/respository"
+	"songs-library/pkg/api/response"
+	"songs-library/pkg/logger/sl"
+	"songs-library/pkg/tracing"
+	"strconv"
+)
+
+var playlistContentTypes = map[string]string{
+	models.PlaylistFormatM3U:  "audio/x-mpegurl; charset=utf-8",
+	models.PlaylistFormatXSPF: "application/xspf+xml; charset=utf-8",
+}
+
+// CreatePlaylist godoc
+// @Summary      Create a playlist
+// @Description  Создание пустого плейлиста. Песни добавляются через POST /playlists/{id}/entries
+// @Tags         Playlists
+// @Accept       json
+// @Produce      json
+// @Param        playlist  body      models.CreatePlaylist  true                              "playlist name and description"
+// @Success      200       {object}  response.Response{data=models.PlaylistWithEntries}  "OK"
+// @Failure      400       {object}  response.Response                                   "Bad Request"
+// @Failure      500       {object}  response.Response                                   "Internal Server Error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /playlists [post]
+func (h *Handler) CreatePlaylist(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.CreatePlaylist"
+	r, span := tracing.StartRequest(r, op)
+	defer span.End()
+
+	log := h.setLogger(r.Context(), op, h.log)
+
+	var req models.CreatePlaylist
+
+	err := render.DecodeJSON(r.Body, &req)
+	if errors.Is(err, io.EOF) {
+		log.Error("request body is empty")
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("request body is empty"))
+		return
+	}
+	if err != nil {
+		log.Error("failed to decode request body", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("failed to decode request body"))
+		return
+	}
+
+	log.Info("request body decoded", slog.Any("request", req))
+
+	err = req.Validate()
+	if err != nil {
+		log.Error("failed to validate request", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	}
+
+	playlist, err := h.service.CreatePlaylist(r.Context(), &req)
+	if err != nil {
+		log.Error("failed to create playlist", sl.Err(err))
+
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("failed to create playlist"))
+		return
+	}
+
+	render.JSON(w, r, response.OK(playlist))
+}
+
+// GetPlaylist godoc
+// @Summary      Get a playlist
+// @Description  Получение плейлиста с песнями по порядку. Записи удаленных песен отмечены available=false
+// @Description  и содержат только название песни и исполнителя
+// @Tags         Playlists
+// @Produce      json
+// @Param        id   path      int  true                                             "playlist_id"
+// @Success      200  {object}  response.Response{data=models.PlaylistWithEntries}  "OK"
+// @Failure      400  {object}  response.Response                                   "Bad Request"
+// @Failure      404  {object}  response.Response                                   "Playlist Not Found"
+// @Failure      500  {object}  response.Response                                   "Internal Server Error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /playlists/{id} [get]
+func (h *Handler) GetPlaylist(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.GetPlaylist"
+	r, span := tracing.StartRequest(r, op)
+	defer span.End()
+
+	log := h.setLogger(r.Context(), op, h.log)
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id <= 0 {
+		log.Error("invalid playlist_id", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(models.ErrInvalidPlaylistID.Error()))
+		return
+	}
+
+	playlist, err := h.service.GetPlaylist(r.Context(), id)
+	if errors.Is(err, respository.ErrPlaylistNotFound) {
+		log.Error("playlist not found", sl.Err(err))
+
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, response.Error("playlist not found"))
+		return
+	}
+	if err != nil {
+		log.Error("failed to get playlist", sl.Err(err))
+
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("failed to get playlist"))
+		return
+	}
+
+	render.JSON(w, r, response.OK(playlist))
+}
+
+// ListPlaylists godoc
+// @Summary      Get list of playlists
+// @Description  Получение списка плейлистов с количеством записей, фильтрацией по названию и пагинацией
+// @Tags         Playlists
+// @Accept       json
+// @Produce      json
+// @Param        filter  body      models.PlaylistsFilter  false                    "playlists filters"
+// @Success      200     {object}  response.Response{data=models.Playlists}  "OK"
+// @Failure      400     {object}  response.Response                         "Bad Request"
+// @Failure      500     {object}  response.Response                         "Internal Server Error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /playlists/list [post]
+func (h *Handler) ListPlaylists(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.ListPlaylists"
+	r, span := tracing.StartRequest(r, op)
+	defer span.End()
+
+	log := h.setLogger(r.Context(), op, h.log)
+
+	var req models.PlaylistsFilter
+
+	err := render.DecodeJSON(r.Body, &req)
+	if err != nil && !errors.Is(err, io.EOF) {
+		log.Error("failed to decode request body", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("failed to decode request body"))
+		return
+	}
+
+	list, err := h.service.ListPlaylists(r.Context(), &req)
+	if err != nil {
+		log.Error("failed to list playlists", sl.Err(err))
+
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("failed to list playlists"))
+		return
+	}
+
+	render.JSON(w, r, response.OK(list))
+}
+
+// UpdatePlaylist godoc
+// @Summary      Update a playlist
+// @Description  Переименование плейлиста и замена описания. Записи плейлиста не меняются
+// @Tags         Playlists
+// @Accept       json
+// @Produce      json
+// @Param        playlist  body      models.UpdatePlaylist  true                              "playlist id, name and description"
+// @Success      200       {object}  response.Response{data=models.PlaylistWithEntries}  "OK"
+// @Failure      400       {object}  response.Response                                   "Bad Request"
+// @Failure      404       {object}  response.Response                                   "Playlist Not Found"
+// @Failure      500       {object}  response.Response                                   "Internal Server Error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /playlists [put]
+func (h *Handler) UpdatePlaylist(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.UpdatePlaylist"
+	r, span := tracing.StartRequest(r, op)
+	defer span.End()
+
+	log := h.setLogger(r.Context(), op, h.log)
+
+	var req models.UpdatePlaylist
+
+	err := render.DecodeJSON(r.Body, &req)
+	if errors.Is(err, io.EOF) {
+		log.Error("request body is empty")
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("request body is empty"))
+		return
+	}
+	if err != nil {
+		log.Error("failed to decode request body", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("failed to decode request body"))
+		return
+	}
+
+	log.Info("request body decoded", slog.Any("request", req))
+
+	err = req.Validate()
+	if err != nil {
+		log.Error("failed to validate request", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	}
+
+	playlist, err := h.service.UpdatePlaylist(r.Context(), &req)
+	if errors.Is(err, respository.ErrPlaylistNotFound) {
+		log.Error("playlist not found", sl.Err(err))
+
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, response.Error("playlist not found"))
+		return
+	}
+	if err != nil {
+		log.Error("failed to update playlist", sl.Err(err))
+
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("failed to update playlist"))
+		return
+	}
+
+	render.JSON(w, r, response.OK(playlist))
+}
+
+// DeletePlaylist godoc
+// @Summary      Delete a playlist
+// @Description  Удаление плейлиста вместе с записями. Песни остаются в библиотеке
+// @Tags         Playlists
+// @Param        id   path      int  true             "playlist_id"
+// @Success      200  {object}  response.Response  "OK"
+// @Failure      400  {object}  response.Response  "Bad Request"
+// @Failure      404  {object}  response.Response  "Playlist Not Found"
+// @Failure      500  {object}  response.Response  "Internal Server Error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /playlists/{id} [delete]
+func (h *Handler) DeletePlaylist(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.DeletePlaylist"
+	r, span := tracing.StartRequest(r, op)
+	defer span.End()
+
+	log := h.setLogger(r.Context(), op, h.log)
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id <= 0 {
+		log.Error("invalid playlist_id", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(models.ErrInvalidPlaylistID.Error()))
+		return
+	}
+
+	err = h.service.DeletePlaylist(r.Context(), id)
+	if errors.Is(err, respository.ErrPlaylistNotFound) {
+		log.Error("playlist not found", sl.Err(err))
+
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, response.Error("playlist not found"))
+		return
+	}
+	if err != nil {
+		log.Error("failed to delete playlist", sl.Err(err))
+
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("failed to delete playlist"))
+		return
+	}
+
+	render.JSON(w, r, response.OK(nil))
+}
+
+// DuplicatePlaylist godoc
+// @Summary      Duplicate a playlist
+// @Description  Копирование плейлиста со всеми записями в том же порядке. Без name копия называется "<название> (copy)"
+// @Tags         Playlists
+// @Accept       json
+// @Produce      json
+// @Param        id        path      int                       true   "playlist_id"
+// @Param        playlist  body      models.DuplicatePlaylist  false  "name of the copy"
+// @Success      200       {object}  response.Response{data=models.PlaylistWithEntries}  "OK"
+// @Failure      400       {object}  response.Response                                   "Bad Request"
+// @Failure      404       {object}  response.Response                                   "Playlist Not Found"
+// @Failure      500       {object}  response.Response                                   "Internal Server Error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /playlists/{id}/duplicate [post]
+func (h *Handler) DuplicatePlaylist(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.DuplicatePlaylist"
+	r, span := tracing.StartRequest(r, op)
+	defer span.End()
+
+	log := h.setLogger(r.Context(), op, h.log)
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id <= 0 {
+		log.Error("invalid playlist_id", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(models.ErrInvalidPlaylistID.Error()))
+		return
+	}
+
+	var req models.DuplicatePlaylist
+
+	err = render.DecodeJSON(r.Body, &req)
+	if err != nil && !errors.Is(err, io.EOF) {
+		log.Error("failed to decode request body", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("failed to decode request body"))
+		return
+	}
+
+	err = req.Validate()
+	if err != nil {
+		log.Error("failed to validate request", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	}
+
+	playlist, err := h.service.DuplicatePlaylist(r.Context(), id, &req)
+	if errors.Is(err, respository.ErrPlaylistNotFound) {
+		log.Error("playlist not found", sl.Err(err))
+
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, response.Error("playlist not found"))
+		return
+	}
+	if err != nil {
+		log.Error("failed to duplicate playlist", sl.Err(err))
+
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("failed to duplicate playlist"))
+		return
+	}
+
+	render.JSON(w, r, response.OK(playlist))
+}
+
+// AddPlaylistEntries godoc
+// @Summary      Add songs to a playlist
+// @Description  Добавление песен в плейлист по порядку на позицию position (с 1); без позиции или за концом
+// @Description  плейлиста - в конец. Одна песня может входить в плейлист несколько раз
+// @Tags         Playlists
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int                        true  "playlist_id"
+// @Param        entries  body      models.AddPlaylistEntries  true  "song ids and position"
+// @Success      200      {object}  response.Response{data=models.PlaylistWithEntries}  "OK"
+// @Failure      400      {object}  response.Response                                   "Bad Request"
+// @Failure      404      {object}  response.Response                                   "Playlist or Song Not Found"
+// @Failure      500      {object}  response.Response                                   "Internal Server Error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /playlists/{id}/entries [post]
+func (h *Handler) AddPlaylistEntries(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.AddPlaylistEntries"
+	r, span := tracing.StartRequest(r, op)
+	defer span.End()
+
+	log := h.setLogger(r.Context(), op, h.log)
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id <= 0 {
+		log.Error("invalid playlist_id", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(models.ErrInvalidPlaylistID.Error()))
+		return
+	}
+
+	var req models.AddPlaylistEntries
+
+	err = render.DecodeJSON(r.Body, &req)
+	if errors.Is(err, io.EOF) {
+		log.Error("request body is empty")
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("request body is empty"))
+		return
+	}
+	if err != nil {
+		log.Error("failed to decode request body", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("failed to decode request body"))
+		return
+	}
+
+	log.Info("request body decoded", slog.Any("request", req))
+
+	err = req.Validate()
+	if err != nil {
+		log.Error("failed to validate request", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	}
+
+	playlist, err := h.service.AddPlaylistEntries(r.Context(), id, &req)
+	if errors.Is(err, respository.ErrPlaylistNotFound) {
+		log.Error("playlist not found", sl.Err(err))
+
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, response.Error("playlist not found"))
+		return
+	}
+	if errors.Is(err, respository.ErrSongNotFound) {
+		log.Error("song not found", sl.Err(err))
+
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, response.Error("song not found"))
+		return
+	}
+	if err != nil {
+		log.Error("failed to add playlist entries", sl.Err(err))
+
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("failed to add playlist entries"))
+		return
+	}
+
+	render.JSON(w, r, response.OK(playlist))
+}
+
+// MovePlaylistEntry godoc
+// @Summary      Move a playlist entry
+// @Description  Перемещение записи на позицию position (с 1); за концом плейлиста запись становится последней.
+// @Description  Остальные записи не перенумеровываются
+// @Tags         Playlists
+// @Accept       json
+// @Produce      json
+// @Param        id        path      int                       true  "playlist_id"
+// @Param        entry_id  path      int                       true  "entry_id"
+// @Param        move      body      models.MovePlaylistEntry  true  "new position"
+// @Success      200       {object}  response.Response{data=models.PlaylistWithEntries}  "OK"
+// @Failure      400       {object}  response.Response                                   "Bad Request"
+// @Failure      404       {object}  response.Response                                   "Playlist or Entry Not Found"
+// @Failure      500       {object}  response.Response                                   "Internal Server Error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /playlists/{id}/entries/{entry_id}/move [post]
+func (h *Handler) MovePlaylistEntry(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.MovePlaylistEntry"
+	r, span := tracing.StartRequest(r, op)
+	defer span.End()
+
+	log := h.setLogger(r.Context(), op, h.log)
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id <= 0 {
+		log.Error("invalid playlist_id", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(models.ErrInvalidPlaylistID.Error()))
+		return
+	}
+
+	entryID, err := strconv.Atoi(chi.URLParam(r, "entry_id"))
+	if err != nil || entryID <= 0 {
+		log.Error("invalid entry_id", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(models.ErrInvalidEntryID.Error()))
+		return
+	}
+
+	var req models.MovePlaylistEntry
+
+	err = render.DecodeJSON(r.Body, &req)
+	if errors.Is(err, io.EOF) {
+		log.Error("request body is empty")
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("request body is empty"))
+		return
+	}
+	if err != nil {
+		log.Error("failed to decode request body", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("failed to decode request body"))
+		return
+	}
+
+	err = req.Validate()
+	if err != nil {
+		log.Error("failed to validate request", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	}
+
+	playlist, err := h.service.MovePlaylistEntry(r.Context(), id, entryID, &req)
+	if errors.Is(err, respository.ErrPlaylistNotFound) {
+		log.Error("playlist not found", sl.Err(err))
+
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, response.Error("playlist not found"))
+		return
+	}
+	if errors.Is(err, respository.ErrPlaylistEntryNotFound) {
+		log.Error("playlist entry not found", sl.Err(err))
+
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, response.Error("playlist entry not found"))
+		return
+	}
+	if err != nil {
+		log.Error("failed to move playlist entry", sl.Err(err))
+
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("failed to move playlist entry"))
+		return
+	}
+
+	render.JSON(w, r, response.OK(playlist))
+}
+
+// RemovePlaylistEntry godoc
+// @Summary      Remove a playlist entry
+// @Description  Удаление записи из плейлиста. Песня остается в библиотеке
+// @Tags         Playlists
+// @Param        id        path      int  true             "playlist_id"
+// @Param        entry_id  path      int  true             "entry_id"
+// @Success      200       {object}  response.Response  "OK"
+// @Failure      400       {object}  response.Response  "Bad Request"
+// @Failure      404       {object}  response.Response  "Playlist or Entry Not Found"
+// @Failure      500       {object}  response.Response  "Internal Server Error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /playlists/{id}/entries/{entry_id} [delete]
+func (h *Handler) RemovePlaylistEntry(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.RemovePlaylistEntry"
+	r, span := tracing.StartRequest(r, op)
+	defer span.End()
+
+	log := h.setLogger(r.Context(), op, h.log)
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id <= 0 {
+		log.Error("invalid playlist_id", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(models.ErrInvalidPlaylistID.Error()))
+		return
+	}
+
+	entryID, err := strconv.Atoi(chi.URLParam(r, "entry_id"))
+	if err != nil || entryID <= 0 {
+		log.Error("invalid entry_id", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(models.ErrInvalidEntryID.Error()))
+		return
+	}
+
+	err = h.service.RemovePlaylistEntry(r.Context(), id, entryID)
+	if errors.Is(err, respository.ErrPlaylistNotFound) {
+		log.Error("playlist not found", sl.Err(err))
+
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, response.Error("playlist not found"))
+		return
+	}
+	if errors.Is(err, respository.ErrPlaylistEntryNotFound) {
+		log.Error("playlist entry not found", sl.Err(err))
+
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, response.Error("playlist entry not found"))
+		return
+	}
+	if err != nil {
+		log.Error("failed to remove playlist entry", sl.Err(err))
+
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("failed to remove playlist entry"))
+		return
+	}
+
+	render.JSON(w, r, response.OK(nil))
+}
+
+// ExportPlaylist godoc
+// @Summary      Export a playlist
+// @Description  Выгрузка плейлиста в M3U или XSPF со ссылками песен (link). Недоступные записи и песни
+// @Description  без ссылки пропускаются
+// @Tags         Playlists
+// @Produce      plain
+// @Param        id      path      int     true   "playlist_id"
+// @Param        format  query     string  false  "export format" Enums(m3u, xspf)
+// @Success      200     {string}  string             "M3U or XSPF document"
+// @Failure      400     {object}  response.Response  "Bad Request"
+// @Failure      404     {object}  response.Response  "Playlist Not Found"
+// @Failure      500     {object}  response.Response  "Internal Server Error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /playlists/{id}/export [get]
+func (h *Handler) ExportPlaylist(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.ExportPlaylist"
+	r, span := tracing.StartRequest(r, op)
+	defer span.End()
+
+	log := h.setLogger(r.Context(), op, h.log)
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id <= 0 {
+		log.Error("invalid playlist_id", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(models.ErrInvalidPlaylistID.Error()))
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = models.PlaylistFormatM3U
+	}
+
+	if err = models.ValidatePlaylistFormat(format); err != nil {
+		log.Error("failed to validate request", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	}
+
+	out := &exportWriter{w: w, rc: http.NewResponseController(w)}
+
+	w.Header().Set("Content-Type", playlistContentTypes[format])
+	w.Header().Set("Content-Disposition", "attachment; filename=\"playlist-"+strconv.Itoa(id)+"."+format+"\"")
+
+	err = h.service.ExportPlaylist(r.Context(), id, format, out)
+	if err != nil && !out.written {
+		w.Header().Del("Content-Disposition")
+		w.Header().Del("Content-Type")
+
+		if errors.Is(err, respository.ErrPlaylistNotFound) {
+			log.Error("playlist not found", sl.Err(err))
+
+			w.WriteHeader(http.StatusNotFound)
+			render.JSON(w, r, response.Error("playlist not found"))
+			return
+		}
+
+		log.Error("failed to export playlist", sl.Err(err))
+
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("failed to export playlist"))
+		return
+	}
+	if err != nil {
+		log.Error("export interrupted", sl.Err(err))
+		panic(http.ErrAbortHandler)
+	}
+}
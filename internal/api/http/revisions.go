@@ -0,0 +1,249 @@
+package http
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"net/http"
+	"songs-library/internal/diff"
+	"songs-library/internal/models"
+	"songs-library/internal/respository"
+	"songs-library/pkg/api/response"
+	"songs-library/pkg/logger/sl"
+	"songs-library/pkg/tracing"
+	"strconv"
+)
+
+// ListRevisions godoc
+// @Summary      List song revisions
+// @Description  История изменений песни: кто, когда и как её менял. Снимки приводятся без текста
+// @Tags         Revisions
+// @Produce      json
+// @Param        id   path      int  true                                   "song_id"
+// @Success      200  {object}  response.Response{data=models.Revisions}  "OK"
+// @Failure      400  {object}  response.Response                         "Bad Request"
+// @Failure      404  {object}  response.Response                         "Song Not Found"
+// @Failure      500  {object}  response.Response                         "Internal Server Error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /songs/{id}/revisions [get]
+func (h *Handler) ListRevisions(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.ListRevisions"
+	r, span := tracing.StartRequest(r, op)
+	defer span.End()
+
+	log := h.setLogger(r.Context(), op, h.log)
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id <= 0 {
+		log.Error("invalid song_id")
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(models.ErrInvalidSongID.Error()))
+		return
+	}
+
+	revisions, err := h.service.ListRevisions(r.Context(), id)
+	if errors.Is(err, respository.ErrSongNotFound) {
+		log.Error("song not found", sl.Err(err))
+
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, response.Error("song not found"))
+		return
+	}
+	if err != nil {
+		log.Error("failed to list revisions", sl.Err(err))
+
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("failed to list revisions"))
+		return
+	}
+
+	render.JSON(w, r, response.OK(revisions))
+}
+
+// GetRevision godoc
+// @Summary      Get song revision
+// @Description  Состояние песни, включая текст, на момент ревизии
+// @Tags         Revisions
+// @Produce      json
+// @Param        id        path      int  true                                  "song_id"
+// @Param        revision  path      int  true                                  "revision"
+// @Success      200       {object}  response.Response{data=models.Revision}  "OK"
+// @Failure      400       {object}  response.Response                        "Bad Request"
+// @Failure      404       {object}  response.Response                        "Revision Not Found"
+// @Failure      500       {object}  response.Response                        "Internal Server Error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /songs/{id}/revisions/{revision} [get]
+func (h *Handler) GetRevision(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.GetRevision"
+	r, span := tracing.StartRequest(r, op)
+	defer span.End()
+
+	log := h.setLogger(r.Context(), op, h.log)
+
+	req := parseGetRevision(r)
+
+	err := req.Validate()
+	if err != nil {
+		log.Error("failed to validate request", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	}
+
+	revision, err := h.service.GetRevision(r.Context(), &req)
+	if errors.Is(err, respository.ErrRevisionNotFound) {
+		log.Error("revision not found", sl.Err(err))
+
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, response.Error("revision not found"))
+		return
+	}
+	if err != nil {
+		log.Error("failed to get revision", sl.Err(err))
+
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("failed to get revision"))
+		return
+	}
+
+	render.JSON(w, r, response.OK(revision))
+}
+
+// DiffRevisions godoc
+// @Summary      Diff song revisions
+// @Description  Сравнение двух ревизий песни: изменённые поля и построчный diff текста
+// @Tags         Revisions
+// @Produce      json
+// @Param        id    path      int  true                                      "song_id"
+// @Param        from  query     int  true                                      "revision to compare from"
+// @Param        to    query     int  true                                      "revision to compare to"
+// @Success      200   {object}  response.Response{data=models.RevisionDiff}  "OK"
+// @Failure      400   {object}  response.Response                            "Bad Request"
+// @Failure      404   {object}  response.Response                            "Revision Not Found"
+// @Failure      422   {object}  response.Response                            "Texts Too Long To Compare"
+// @Failure      500   {object}  response.Response                            "Internal Server Error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /songs/{id}/revisions/diff [get]
+func (h *Handler) DiffRevisions(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.DiffRevisions"
+	r, span := tracing.StartRequest(r, op)
+	defer span.End()
+
+	log := h.setLogger(r.Context(), op, h.log)
+
+	var req models.DiffRevisions
+
+	req.SongID, _ = strconv.Atoi(chi.URLParam(r, "id"))
+	req.From, _ = strconv.Atoi(r.URL.Query().Get("from"))
+	req.To, _ = strconv.Atoi(r.URL.Query().Get("to"))
+
+	err := req.Validate()
+	if err != nil {
+		log.Error("failed to validate request", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	}
+
+	revisionDiff, err := h.service.DiffRevisions(r.Context(), &req)
+	if errors.Is(err, respository.ErrRevisionNotFound) {
+		log.Error("revision not found", sl.Err(err))
+
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, response.Error("revision not found"))
+		return
+	}
+	if errors.Is(err, diff.ErrTooLarge) {
+		log.Error("revision texts too long to diff", sl.Err(err))
+
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, response.Error(diff.ErrTooLarge.Error()))
+		return
+	}
+	if err != nil {
+		log.Error("failed to diff revisions", sl.Err(err))
+
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("failed to diff revisions"))
+		return
+	}
+
+	render.JSON(w, r, response.OK(revisionDiff))
+}
+
+// RestoreRevision godoc
+// @Summary      Restore song revision
+// @Description  Возврат песни к состоянию ревизии. Удалённая песня восстанавливается с прежним id.
+// @Description  Восстановление записывается как новая ревизия. Ревизия хранит только поля самой песни: восстановленная
+// @Description  после удаления песня возвращается без альбомов, тегов, жанров, синхронизированного текста и мест
+// @Description  в плейлистах, со статусом обогащения ok и без задания на обогащение
+// @Tags         Revisions
+// @Produce      json
+// @Param        id        path      int  true                                  "song_id"
+// @Param        revision  path      int  true                                  "revision"
+// @Success      200       {object}  response.Response{data=models.Revision}  "OK"
+// @Failure      400       {object}  response.Response                        "Bad Request"
+// @Failure      404       {object}  response.Response                        "Revision Not Found"
+// @Failure      422       {object}  response.Response                        "Unknown Release Date Format"
+// @Failure      500       {object}  response.Response                        "Internal Server Error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /songs/{id}/revisions/{revision}/restore [post]
+func (h *Handler) RestoreRevision(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.RestoreRevision"
+	r, span := tracing.StartRequest(r, op)
+	defer span.End()
+
+	log := h.setLogger(r.Context(), op, h.log)
+
+	req := parseGetRevision(r)
+
+	err := req.Validate()
+	if err != nil {
+		log.Error("failed to validate request", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	}
+
+	revision, err := h.service.RestoreRevision(r.Context(), &req)
+	if errors.Is(err, respository.ErrRevisionNotFound) {
+		log.Error("revision not found", sl.Err(err))
+
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, response.Error("revision not found"))
+		return
+	}
+	if errors.Is(err, models.ErrInvalidReleaseDate) {
+		log.Error("revision release date can't be restored", sl.Err(err))
+
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render.JSON(w, r, response.Error("revision has a release date in an unknown format"))
+		return
+	}
+	if err != nil {
+		log.Error("failed to restore revision", sl.Err(err))
+
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("failed to restore revision"))
+		return
+	}
+
+	render.JSON(w, r, response.OK(revision))
+}
+
+func parseGetRevision(r *http.Request) models.GetRevision {
+	var req models.GetRevision
+
+	req.SongID, _ = strconv.Atoi(chi.URLParam(r, "id"))
+	req.Revision, _ = strconv.Atoi(chi.URLParam(r, "revision"))
+
+	return req
+}
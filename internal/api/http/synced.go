@@ -0,0 +1,269 @@
+package http
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"io"
+	"math"
+	"net/http"
+	"songs-library/internal/consts"
+	"songs-library/internal/lrc"
+	"songs-library/internal/models"
+	"songs-library/internal/respository"
+	"songs-library/pkg/api/response"
+	"songs-library/pkg/logger/sl"
+	"songs-library/pkg/tracing"
+	"strconv"
+)
+
+// UploadSyncedLyrics godoc
+// @Summary      Upload synced lyrics
+// @Description  Загрузка текста с таймкодами в формате LRC ([mm:ss.xx] и теги [ar:], [ti:], [offset:]).
+// @Description  Обычный текст песни заменяется строками из LRC
+// @Tags         Synced Lyrics
+// @Accept       plain
+// @Produce      json
+// @Param        id   path      int     true                                   "song_id"
+// @Param        lrc  body      string  true                                   "LRC document"
+// @Success      200  {object}  response.Response{data=models.SyncedLyrics}  "OK"
+// @Failure      400  {object}  response.Response                            "Bad Request"
+// @Failure      404  {object}  response.Response                            "Song Not Found"
+// @Failure      500  {object}  response.Response                            "Internal Server Error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /songs/{id}/lyrics/synced [put]
+func (h *Handler) UploadSyncedLyrics(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.UploadSyncedLyrics"
+	r, span := tracing.StartRequest(r, op)
+	defer span.End()
+
+	log := h.setLogger(r.Context(), op, h.log)
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id <= 0 {
+		log.Error("invalid song_id")
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(models.ErrInvalidSongID.Error()))
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, consts.MaxLRCSize))
+	if err != nil {
+		log.Error("failed to read request body", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("failed to read request body"))
+		return
+	}
+
+	lyrics, err := h.service.UploadSyncedLyrics(r.Context(), id, string(body))
+	var parseErr *lrc.ParseError
+	if errors.As(err, &parseErr) {
+		log.Error("invalid lrc document", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(parseErr.Error()))
+		return
+	}
+	if errors.Is(err, respository.ErrSongNotFound) {
+		log.Error("song not found", sl.Err(err))
+
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, response.Error("song not found"))
+		return
+	}
+	if err != nil {
+		log.Error("failed to upload synced lyrics", sl.Err(err))
+
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("failed to upload synced lyrics"))
+		return
+	}
+
+	render.JSON(w, r, response.OK(lyrics))
+}
+
+// GetSyncedLyrics godoc
+// @Summary      Get synced lyrics
+// @Description  Получение текста песни с таймкодами
+// @Tags         Synced Lyrics
+// @Produce      json
+// @Param        id   path      int  true                                      "song_id"
+// @Success      200  {object}  response.Response{data=models.SyncedLyrics}  "OK"
+// @Failure      400  {object}  response.Response                            "Bad Request"
+// @Failure      404  {object}  response.Response                            "Synced Lyrics Not Found"
+// @Failure      500  {object}  response.Response                            "Internal Server Error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /songs/{id}/lyrics/synced [get]
+func (h *Handler) GetSyncedLyrics(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.GetSyncedLyrics"
+	r, span := tracing.StartRequest(r, op)
+	defer span.End()
+
+	log := h.setLogger(r.Context(), op, h.log)
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id <= 0 {
+		log.Error("invalid song_id")
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(models.ErrInvalidSongID.Error()))
+		return
+	}
+
+	lyrics, err := h.service.GetSyncedLyrics(r.Context(), id)
+	if errors.Is(err, respository.ErrSyncedLyricsNotFound) {
+		log.Error("synced lyrics not found", sl.Err(err))
+
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, response.Error("synced lyrics not found"))
+		return
+	}
+	if err != nil {
+		log.Error("failed to get synced lyrics", sl.Err(err))
+
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("failed to get synced lyrics"))
+		return
+	}
+
+	render.JSON(w, r, response.OK(lyrics))
+}
+
+// GetActiveLine godoc
+// @Summary      Get the line at a playback position
+// @Description  Строка текста, которая звучит в момент воспроизведения at (в секундах), и следующая за ней
+// @Tags         Synced Lyrics
+// @Produce      json
+// @Param        id   path      int     true                                 "song_id"
+// @Param        at   query     number  true                                 "playback position, seconds"
+// @Success      200  {object}  response.Response{data=models.ActiveLine}  "OK"
+// @Failure      400  {object}  response.Response                          "Bad Request"
+// @Failure      404  {object}  response.Response                          "Synced Lyrics Not Found"
+// @Failure      500  {object}  response.Response                          "Internal Server Error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /songs/{id}/lyrics/synced/active [get]
+func (h *Handler) GetActiveLine(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.GetActiveLine"
+	r, span := tracing.StartRequest(r, op)
+	defer span.End()
+
+	log := h.setLogger(r.Context(), op, h.log)
+
+	var (
+		err error
+		req models.GetActiveLine
+	)
+
+	req.SongID, err = strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || req.SongID <= 0 {
+		log.Error("invalid song_id")
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(models.ErrInvalidSongID.Error()))
+		return
+	}
+
+	at, err := strconv.ParseFloat(r.URL.Query().Get("at"), 64)
+	if err != nil || at < 0 || math.IsInf(at, 0) || math.IsNaN(at) {
+		log.Error("invalid at parameter")
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(models.ErrInvalidOffset.Error()))
+		return
+	}
+	req.AtMs = int(math.Round(at * 1000))
+
+	line, err := h.service.GetActiveLine(r.Context(), &req)
+	if errors.Is(err, respository.ErrSyncedLyricsNotFound) {
+		log.Error("synced lyrics not found", sl.Err(err))
+
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, response.Error("synced lyrics not found"))
+		return
+	}
+	if err != nil {
+		log.Error("failed to get active line", sl.Err(err))
+
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("failed to get active line"))
+		return
+	}
+
+	render.JSON(w, r, response.OK(line))
+}
+
+// ExportSyncedLyrics godoc
+// @Summary      Export synced lyrics
+// @Description  Выгрузка текста с таймкодами в формате LRC или WebVTT
+// @Tags         Synced Lyrics
+// @Produce      plain
+// @Param        id      path      int     true  "song_id"
+// @Param        format  query     string  false "export format" Enums(lrc, vtt)
+// @Success      200     {string}  string             "LRC or WebVTT document"
+// @Failure      400     {object}  response.Response  "Bad Request"
+// @Failure      404     {object}  response.Response  "Synced Lyrics Not Found"
+// @Failure      500     {object}  response.Response  "Internal Server Error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /songs/{id}/lyrics/synced/export [get]
+func (h *Handler) ExportSyncedLyrics(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.ExportSyncedLyrics"
+	r, span := tracing.StartRequest(r, op)
+	defer span.End()
+
+	log := h.setLogger(r.Context(), op, h.log)
+
+	var (
+		err error
+		req models.ExportSyncedLyrics
+	)
+
+	req.SongID, err = strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		req.SongID = 0
+	}
+
+	req.Format = r.URL.Query().Get("format")
+	if req.Format == "" {
+		req.Format = models.ExportFormatLRC
+	}
+
+	err = req.Validate()
+	if err != nil {
+		log.Error("failed to validate request", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	}
+
+	document, err := h.service.ExportSyncedLyrics(r.Context(), &req)
+	if errors.Is(err, respository.ErrSyncedLyricsNotFound) {
+		log.Error("synced lyrics not found", sl.Err(err))
+
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, response.Error("synced lyrics not found"))
+		return
+	}
+	if err != nil {
+		log.Error("failed to export synced lyrics", sl.Err(err))
+
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("failed to export synced lyrics"))
+		return
+	}
+
+	contentType := "text/plain; charset=utf-8"
+	if req.Format == models.ExportFormatVTT {
+		contentType = "text/vtt; charset=utf-8"
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", "attachment; filename=\"song-"+strconv.Itoa(req.SongID)+"."+req.Format+"\"")
+	_, _ = io.WriteString(w, document)
+}
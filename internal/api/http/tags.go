@@ -0,0 +1,398 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"io"
+	"log/slog"
+	"net/http"
+	"songs-library/internal/models"
+	"songs-library/internal/respository"
+	"songs-library/pkg/api/response"
+	"songs-library/pkg/logger/sl"
+	"songs-library/pkg/tracing"
+	"strconv"
+)
+
+// CreateTag godoc
+// @Summary      Create a tag
+// @Description  Добавление тега. Теги сравниваются без учета регистра и лишних пробелов
+// @Tags         Tags
+// @Accept       json
+// @Produce      json
+// @Param        tag  body      models.CreateTag  true               "tag name"
+// @Success      200  {object}  response.Response{data=models.Tag}  "OK"
+// @Failure      400  {object}  response.Response                   "Bad Request"
+// @Failure      409  {object}  response.Response                   "Tag Already Exists"
+// @Failure      500  {object}  response.Response                   "Internal Server Error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /tags [post]
+func (h *Handler) CreateTag(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.CreateTag"
+	r, span := tracing.StartRequest(r, op)
+	defer span.End()
+
+	log := h.setLogger(r.Context(), op, h.log)
+
+	var req models.CreateTag
+
+	err := render.DecodeJSON(r.Body, &req)
+	if errors.Is(err, io.EOF) {
+		log.Error("request body is empty")
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("request body is empty"))
+		return
+	}
+	if err != nil {
+		log.Error("failed to decode request body", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("failed to decode request body"))
+		return
+	}
+
+	log.Info("request body decoded", slog.Any("request", req))
+
+	err = req.Validate()
+	if err != nil {
+		log.Error("failed to validate request", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	}
+
+	tag, err := h.service.CreateTag(r.Context(), &req)
+	if errors.Is(err, respository.ErrTagAlreadyExists) {
+		log.Error("tag already exists", sl.Err(err))
+
+		w.WriteHeader(http.StatusConflict)
+		render.JSON(w, r, response.Error("tag already exists"))
+		return
+	}
+	if err != nil {
+		log.Error("failed to create tag", sl.Err(err))
+
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("failed to create tag"))
+		return
+	}
+
+	render.JSON(w, r, response.OK(tag))
+}
+
+// ListTags godoc
+// @Summary      Get list of tags
+// @Description  Получение списка тегов с количеством песен, фильтрацией по названию и пагинацией
+// @Tags         Tags
+// @Accept       json
+// @Produce      json
+// @Param        filter  body      models.TagsFilter  false               "tags filters"
+// @Success      200     {object}  response.Response{data=models.Tags}  "OK"
+// @Failure      400     {object}  response.Response                    "Bad Request"
+// @Failure      500     {object}  response.Response                    "Internal Server Error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /tags/list [post]
+func (h *Handler) ListTags(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.ListTags"
+	r, span := tracing.StartRequest(r, op)
+	defer span.End()
+
+	log := h.setLogger(r.Context(), op, h.log)
+
+	var req models.TagsFilter
+
+	err := render.DecodeJSON(r.Body, &req)
+	if err != nil && !errors.Is(err, io.EOF) {
+		log.Error("failed to decode request body", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("failed to decode request body"))
+		return
+	}
+
+	list, err := h.service.ListTags(r.Context(), &req)
+	if err != nil {
+		log.Error("failed to list tags", sl.Err(err))
+
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("failed to list tags"))
+		return
+	}
+
+	render.JSON(w, r, response.OK(list))
+}
+
+// UpdateTag godoc
+// @Summary      Rename a tag
+// @Description  Переименование тега. Песни сохраняют тег
+// @Tags         Tags
+// @Accept       json
+// @Produce      json
+// @Param        tag  body      models.UpdateTag  true               "tag id and name"
+// @Success      200  {object}  response.Response{data=models.Tag}  "OK"
+// @Failure      400  {object}  response.Response                   "Bad Request"
+// @Failure      404  {object}  response.Response                   "Tag Not Found"
+// @Failure      409  {object}  response.Response                   "Tag Already Exists"
+// @Failure      500  {object}  response.Response                   "Internal Server Error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /tags [put]
+func (h *Handler) UpdateTag(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.UpdateTag"
+	r, span := tracing.StartRequest(r, op)
+	defer span.End()
+
+	log := h.setLogger(r.Context(), op, h.log)
+
+	var req models.UpdateTag
+
+	err := render.DecodeJSON(r.Body, &req)
+	if errors.Is(err, io.EOF) {
+		log.Error("request body is empty")
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("request body is empty"))
+		return
+	}
+	if err != nil {
+		log.Error("failed to decode request body", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("failed to decode request body"))
+		return
+	}
+
+	log.Info("request body decoded", slog.Any("request", req))
+
+	err = req.Validate()
+	if err != nil {
+		log.Error("failed to validate request", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	}
+
+	tag, err := h.service.UpdateTag(r.Context(), &req)
+	if errors.Is(err, respository.ErrTagNotFound) {
+		log.Error("tag not found", sl.Err(err))
+
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, response.Error("tag not found"))
+		return
+	}
+	if errors.Is(err, respository.ErrTagAlreadyExists) {
+		log.Error("tag already exists", sl.Err(err))
+
+		w.WriteHeader(http.StatusConflict)
+		render.JSON(w, r, response.Error("tag already exists"))
+		return
+	}
+	if err != nil {
+		log.Error("failed to update tag", sl.Err(err))
+
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("failed to update tag"))
+		return
+	}
+
+	render.JSON(w, r, response.OK(tag))
+}
+
+// DeleteTag godoc
+// @Summary      Delete a tag
+// @Description  Удаление тега вместе с его привязками к песням
+// @Tags         Tags
+// @Param        id   path      int  true             "tag_id"
+// @Success      200  {object}  response.Response  "OK"
+// @Failure      400  {object}  response.Response  "Bad Request"
+// @Failure      404  {object}  response.Response  "Tag Not Found"
+// @Failure      500  {object}  response.Response  "Internal Server Error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /tags/{id} [delete]
+func (h *Handler) DeleteTag(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.DeleteTag"
+	r, span := tracing.StartRequest(r, op)
+	defer span.End()
+
+	log := h.setLogger(r.Context(), op, h.log)
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id <= 0 {
+		log.Error("invalid tag_id", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(models.ErrInvalidTagID.Error()))
+		return
+	}
+
+	err = h.service.DeleteTag(r.Context(), id)
+	if errors.Is(err, respository.ErrTagNotFound) {
+		log.Error("tag not found", sl.Err(err))
+
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, response.Error("tag not found"))
+		return
+	}
+	if err != nil {
+		log.Error("failed to delete tag", sl.Err(err))
+
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("failed to delete tag"))
+		return
+	}
+
+	render.JSON(w, r, response.OK(nil))
+}
+
+// GetSongTags godoc
+// @Summary      Get song genres and tags
+// @Description  Получение жанров и тегов песни
+// @Tags         Songs
+// @Produce      json
+// @Param        id   path      int  true                                  "song_id"
+// @Success      200  {object}  response.Response{data=models.SongTags}  "OK"
+// @Failure      400  {object}  response.Response                        "Bad Request"
+// @Failure      404  {object}  response.Response                        "Song Not Found"
+// @Failure      500  {object}  response.Response                        "Internal Server Error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /songs/{id}/tags [get]
+func (h *Handler) GetSongTags(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.GetSongTags"
+	r, span := tracing.StartRequest(r, op)
+	defer span.End()
+
+	log := h.setLogger(r.Context(), op, h.log)
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id <= 0 {
+		log.Error("invalid song_id", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(models.ErrInvalidSongID.Error()))
+		return
+	}
+
+	tags, err := h.service.GetSongTags(r.Context(), id)
+	if errors.Is(err, respository.ErrSongNotFound) {
+		log.Error("song not found", sl.Err(err))
+
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, response.Error("song not found"))
+		return
+	}
+	if err != nil {
+		log.Error("failed to get song tags", sl.Err(err))
+
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("failed to get song tags"))
+		return
+	}
+
+	render.JSON(w, r, response.OK(tags))
+}
+
+// TagSongs godoc
+// @Summary      Tag songs
+// @Description  Массовое добавление тегов и жанров песням за одну транзакцию. Новые теги создаются,
+// @Description  уже имеющиеся у песни теги и жанры пропускаются. Возвращает число добавленных привязок
+// @Tags         Songs
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.TagSongs  true                    "song ids, tags and genre ids"
+// @Success      200      {object}  response.Response{data=models.Tagged}  "OK"
+// @Failure      400      {object}  response.Response                      "Bad Request"
+// @Failure      404      {object}  response.Response                      "Song or Genre Not Found"
+// @Failure      500      {object}  response.Response                      "Internal Server Error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /songs/tags/add [post]
+func (h *Handler) TagSongs(w http.ResponseWriter, r *http.Request) {
+	h.changeSongTags(w, r, "handler.TagSongs", h.service.TagSongs)
+}
+
+// UntagSongs godoc
+// @Summary      Untag songs
+// @Description  Массовое удаление тегов и жанров у песен за одну транзакцию. Поджанры вместе с жанром не удаляются.
+// @Description  Возвращает число удаленных привязок
+// @Tags         Songs
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.TagSongs  true                    "song ids, tags and genre ids"
+// @Success      200      {object}  response.Response{data=models.Tagged}  "OK"
+// @Failure      400      {object}  response.Response                      "Bad Request"
+// @Failure      500      {object}  response.Response                      "Internal Server Error"
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /songs/tags/remove [post]
+func (h *Handler) UntagSongs(w http.ResponseWriter, r *http.Request) {
+	h.changeSongTags(w, r, "handler.UntagSongs", h.service.UntagSongs)
+}
+
+func (h *Handler) changeSongTags(
+	w http.ResponseWriter,
+	r *http.Request,
+	op string,
+	change func(context.Context, *models.TagSongs) (*models.Tagged, error),
+) {
+	log := h.setLogger(r.Context(), op, h.log)
+
+	var req models.TagSongs
+
+	err := render.DecodeJSON(r.Body, &req)
+	if errors.Is(err, io.EOF) {
+		log.Error("request body is empty")
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("request body is empty"))
+		return
+	}
+	if err != nil {
+		log.Error("failed to decode request body", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("failed to decode request body"))
+		return
+	}
+
+	log.Info("request body decoded", slog.Any("request", req))
+
+	err = req.Validate()
+	if err != nil {
+		log.Error("failed to validate request", sl.Err(err))
+
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(err.Error()))
+		return
+	}
+
+	tagged, err := change(r.Context(), &req)
+	if errors.Is(err, respository.ErrSongNotFound) {
+		log.Error("song not found", sl.Err(err))
+
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, response.Error("song not found"))
+		return
+	}
+	if errors.Is(err, respository.ErrGenreNotFound) {
+		log.Error("genre not found", sl.Err(err))
+
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, response.Error("genre not found"))
+		return
+	}
+	if err != nil {
+		log.Error("failed to change song tags", sl.Err(err))
+
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("failed to change song tags"))
+		return
+	}
+
+	render.JSON(w, r, response.OK(tagged))
+}
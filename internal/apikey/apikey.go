@@ -0,0 +1,36 @@
+// Package apikey generates API keys and the hashes they are stored and looked up by.
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+)
+
+const (
+	// keyPrefix marks the keys of this library, so that a leaked one is easy to recognize.
+	keyPrefix = "sl_"
+	// secretSize is the number of random bytes in a key.
+	secretSize = 32
+	// PrefixLength is the length of the start of a key that is kept in the clear to tell keys apart.
+	PrefixLength = len(keyPrefix) + 8
+)
+
+// Generate returns a new random key, its prefix and its hash. Only the prefix and the hash are to be stored.
+func Generate() (key, prefix string, hash []byte, err error) {
+	secret := make([]byte, secretSize)
+	if _, err = rand.Read(secret); err != nil {
+		return "", "", nil, err
+	}
+
+	key = keyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	return key, key[:PrefixLength], Hash(key), nil
+}
+
+// Hash returns the hash a key is stored by. Keys are random and long, so a single fast hash is enough
+// to keep them from being read back from the database.
+func Hash(key string) []byte {
+	sum := sha256.Sum256([]byte(key))
+	return sum[:]
+}
@@ -4,12 +4,72 @@ import (
 	"github.com/joho/godotenv"
 	"log"
 	"os"
+	"slices"
+	"songs-library/pkg/ratelimit"
+	"songs-library/pkg/tracing"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// minJWTSecretLength is the size of the HS256 hash: a shorter key makes the signature weaker.
+const minJWTSecretLength = 32
+
 type Config struct {
-	PgDsn           string
-	Port            string
-	SongsInfoAPIURL string
+	PgDsn               string
+	Port                string
+	SongsInfoAPIURL     string
+	SongDetailProviders []string
+	LyricsPath          string
+	Enrichment          Enrichment
+	// PlaylistOnSongDelete is what happens to the playlist entries of a deleted song: keep or cascade.
+	PlaylistOnSongDelete string
+	Auth                 Auth
+	RateLimit            RateLimit
+	Tracing              Tracing
+	Health               Health
+	// AutoMigrate applies the embedded migrations at startup, before serving.
+	AutoMigrate bool
+}
+
+type Health struct {
+	// CheckTimeout bounds each readiness check.
+	CheckTimeout time.Duration
+	// CheckSongsInfoAPI adds the songs info API to the readiness report; it never makes the service not ready.
+	CheckSongsInfoAPI bool
+	// ShutdownDelay is how long the service reports not ready before it stops accepting requests.
+	ShutdownDelay time.Duration
+}
+
+type Tracing struct {
+	// Exporter sends the spans: otlp to a collector, stdout as JSON lines, or none to keep them only in the logs.
+	Exporter     string
+	OTLPEndpoint string
+	ServiceName  string
+}
+
+// RateLimit limits the requests of each client, separately for reads, writes and the calls that enqueue enrichment.
+type RateLimit struct {
+	// Store keeps the buckets: memory for each instance on its own, postgres to share them, or none to not limit.
+	Store string
+	// IP limits each address before authentication.
+	IP         ratelimit.Limit
+	Reads      ratelimit.Limit
+	Writes     ratelimit.Limit
+	Enrichment ratelimit.Limit
+}
+
+type Auth struct {
+	// JWTSecret is the HMAC key bearer JWTs are signed with. Without it, only API keys are accepted.
+	JWTSecret string
+}
+
+type Enrichment struct {
+	Workers        int
+	MaxAttempts    int
+	PollInterval   time.Duration
+	Lease          time.Duration
+	RequestTimeout time.Duration
 }
 
 func MustLoad() *Config {
@@ -28,14 +88,167 @@ func MustLoad() *Config {
 		log.Fatal("PORT env var not set")
 	}
 
+	providers := getList("SONG_DETAIL_PROVIDERS", []string{"http"})
+
 	songsInfoAPIURL := os.Getenv("SONGS_INFO_API_URL")
-	if songsInfoAPIURL == "" {
+	if songsInfoAPIURL == "" && slices.Contains(providers, "http") {
 		log.Fatal("SONGS_INFO_API_URL env var not set")
 	}
 
+	lyricsPath := os.Getenv("LYRICS_PATH")
+	if lyricsPath == "" && slices.Contains(providers, "file") {
+		log.Fatal("LYRICS_PATH env var not set")
+	}
+
+	playlistOnSongDelete := os.Getenv("PLAYLIST_ON_SONG_DELETE")
+	if playlistOnSongDelete == "" {
+		playlistOnSongDelete = "keep"
+	}
+	if playlistOnSongDelete != "keep" && playlistOnSongDelete != "cascade" {
+		log.Fatal("PLAYLIST_ON_SONG_DELETE env var must be keep or cascade")
+	}
+
+	jwtSecret := os.Getenv("AUTH_JWT_SECRET")
+	if jwtSecret != "" && len(jwtSecret) < minJWTSecretLength {
+		log.Fatalf("AUTH_JWT_SECRET env var must be at least %d bytes long", minJWTSecretLength)
+	}
+
+	rateLimitStore := os.Getenv("RATE_LIMIT_STORE")
+	if rateLimitStore == "" {
+		rateLimitStore = "memory"
+	}
+	if !slices.Contains([]string{"memory", "postgres", "none"}, rateLimitStore) {
+		log.Fatal("RATE_LIMIT_STORE env var must be memory, postgres or none")
+	}
+
+	tracingExporter := os.Getenv("TRACING_EXPORTER")
+	if tracingExporter == "" {
+		tracingExporter = tracing.ExporterNone
+	}
+	if !slices.Contains([]string{tracing.ExporterOTLP, tracing.ExporterStdout, tracing.ExporterNone}, tracingExporter) {
+		log.Fatal("TRACING_EXPORTER env var must be otlp, stdout or none")
+	}
+
+	otlpEndpoint := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+	if otlpEndpoint == "" {
+		otlpEndpoint = "http://localhost:4318"
+	}
+
+	serviceName := os.Getenv("OTEL_SERVICE_NAME")
+	if serviceName == "" {
+		serviceName = "songs-library"
+	}
+
 	return &Config{
-		PgDsn:           dns,
-		Port:            port,
-		SongsInfoAPIURL: songsInfoAPIURL,
+		PgDsn:               dns,
+		Port:                port,
+		SongsInfoAPIURL:     songsInfoAPIURL,
+		SongDetailProviders: providers,
+		LyricsPath:          lyricsPath,
+		Enrichment: Enrichment{
+			Workers:        getInt("ENRICHMENT_WORKERS", 4),
+			MaxAttempts:    getInt("ENRICHMENT_MAX_ATTEMPTS", 5),
+			PollInterval:   getDuration("ENRICHMENT_POLL_INTERVAL", 2*time.Second),
+			Lease:          getDuration("ENRICHMENT_LEASE", time.Minute),
+			RequestTimeout: getDuration("ENRICHMENT_REQUEST_TIMEOUT", 10*time.Second),
+		},
+		PlaylistOnSongDelete: playlistOnSongDelete,
+		Auth: Auth{
+			JWTSecret: jwtSecret,
+		},
+		RateLimit: RateLimit{
+			Store:      rateLimitStore,
+			IP:         getLimit("RATE_LIMIT_IP", ratelimit.Limit{Requests: 1200, Period: time.Minute}),
+			Reads:      getLimit("RATE_LIMIT_READS", ratelimit.Limit{Requests: 600, Period: time.Minute}),
+			Writes:     getLimit("RATE_LIMIT_WRITES", ratelimit.Limit{Requests: 120, Period: time.Minute}),
+			Enrichment: getLimit("RATE_LIMIT_ENRICHMENT", ratelimit.Limit{Requests: 30, Period: time.Minute}),
+		},
+		Tracing: Tracing{
+			Exporter:     tracingExporter,
+			OTLPEndpoint: otlpEndpoint,
+			ServiceName:  serviceName,
+		},
+		Health: Health{
+			CheckTimeout:      getDuration("HEALTH_CHECK_TIMEOUT", 2*time.Second),
+			CheckSongsInfoAPI: getBool("HEALTH_CHECK_SONGS_INFO_API", false),
+			ShutdownDelay:     getDuration("SHUTDOWN_DELAY", 5*time.Second),
+		},
+		AutoMigrate: getBool("AUTO_MIGRATE", false),
+	}
+}
+
+func getInt(key string, def int) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
 	}
+
+	i, err := strconv.Atoi(v)
+	if err != nil || i < 1 {
+		log.Fatalf("%s env var must be a positive integer", key)
+	}
+
+	return i
+}
+
+func getDuration(key string, def time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		log.Fatalf("%s env var must be a positive duration", key)
+	}
+
+	return d
+}
+
+func getBool(key string, def bool) bool {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		log.Fatalf("%s env var must be true or false", key)
+	}
+
+	return b
+}
+
+func getLimit(key string, def ratelimit.Limit) ratelimit.Limit {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+
+	limit, err := ratelimit.ParseLimit(v)
+	if err != nil {
+		log.Fatalf("%s env var: %s", key, err)
+	}
+
+	return limit
+}
+
+func getList(key string, def []string) []string {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+
+	var list []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+
+	if len(list) == 0 {
+		log.Fatalf("%s env var must be a comma separated list", key)
+	}
+
+	return list
 }
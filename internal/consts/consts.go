@@ -1,12 +1,189 @@
 package consts
 
+import "time"
+
 const (
 	SongsTableName    = "songs"
 	IDColumn          = "id"
 	SongColumn        = "song"
-	GroupColumn       = "author"
+	ArtistIDColumn    = "artist_id"
 	ReleaseDateColumn = "release_date"
 	TextColumn        = "text"
 	LinkColumn        = "link"
 	DefaultLimit      = 10
 )
+
+const (
+	ArtistsTableName     = "artists"
+	NameColumn           = "name"
+	NormalizedNameColumn = "normalized_name"
+	SongsCountColumn     = "songs_count"
+)
+
+// Qualified columns for queries that join songs with artists.
+const (
+	SongsIDColumn       = SongsTableName + "." + IDColumn
+	ArtistsIDColumn     = ArtistsTableName + "." + IDColumn
+	ArtistsNameColumn   = ArtistsTableName + "." + NameColumn
+	SongsArtistIDColumn = SongsTableName + "." + ArtistIDColumn
+)
+
+const (
+	TextSearchColumn    = "text_search"
+	TextSearchConfig    = "simple"
+	RankColumn          = "rank"
+	SnippetColumn       = "snippet"
+	HeadlineOptions     = "StartSel=<mark>, StopSel=</mark>, MaxFragments=3, MaxWords=20, MinWords=5, FragmentDelimiter=\" ... \""
+	LyricsModePlain     = "plain"
+	LyricsModePhrase    = "phrase"
+	LyricsModeWebSearch = "websearch"
+)
+
+const (
+	EnrichmentJobsTableName = "enrichment_jobs"
+	EnrichmentStatusColumn  = "enrichment_status"
+	SongIDColumn            = "song_id"
+	AttemptsColumn          = "attempts"
+	RunAtColumn             = "run_at"
+	LockedAtColumn          = "locked_at"
+	FailedAtColumn          = "failed_at"
+	LastErrorColumn         = "last_error"
+)
+
+const (
+	SongSectionsTableName = "song_sections"
+	PositionColumn        = "position"
+	TypeColumn            = "type"
+	OrdinalColumn         = "ordinal"
+	LabelColumn           = "label"
+)
+
+const (
+	SyncedLyricsTableName      = "synced_lyrics"
+	SyncedLyricsLinesTableName = "synced_lyrics_lines"
+	TagsColumn                 = "tags"
+	OffsetMsColumn             = "offset_ms"
+	TimeMsColumn               = "time_ms"
+	UpdatedAtColumn            = "updated_at"
+	SyncedLastCueMs            = 5000
+	MaxLRCSize                 = 1 << 20
+)
+
+const (
+	SongRevisionsTableName = "song_revisions"
+	RevisionColumn         = "revision"
+	ActionColumn           = "action"
+	AuthorColumn           = "author"
+	SnapshotColumn         = "snapshot"
+	CreatedAtColumn        = "created_at"
+)
+
+const VersionColumn = "version"
+
+const MaxPatchSize = 1 << 20
+
+const (
+	// MaxImportSize bounds the document of an import request; larger catalogues go through the import command.
+	MaxImportSize = 64 << 20
+	// ImportTimeout bounds an import request in place of the request timeout and the server deadlines.
+	ImportTimeout = 5 * time.Minute
+)
+
+const (
+	ReleaseDatePrecisionColumn = "release_date_precision"
+	// ReleaseDateExpr selects the release date as a string to its precision, empty if it is unknown.
+	ReleaseDateExpr = "coalesce(format_release_date(" + ReleaseDateColumn + ", " + ReleaseDatePrecisionColumn + "), '') AS " + ReleaseDateColumn
+)
+
+const (
+	SongsCreatedAtColumn   = "songs.created_at"
+	SongsReleaseDateColumn = "songs.release_date"
+	SortKeyColumnPrefix    = "sort_"
+)
+
+// ExportBatchSize is the number of songs fetched from the export cursor at a time.
+const ExportBatchSize = 500
+
+const ExportCursorName = "songs_export"
+
+const (
+	NormalizedSongColumn = "normalized_song"
+	// MaxDuplicatePairs bounds the similar pairs a duplicates search reads before grouping them into clusters.
+	MaxDuplicatePairs = 5000
+)
+
+const (
+	AlbumsTableName      = "albums"
+	AlbumTracksTableName = "album_tracks"
+	TitleColumn          = "title"
+	CoverLinkColumn      = "cover_link"
+	AlbumIDColumn        = "album_id"
+	DiscNumberColumn     = "disc_number"
+	TrackNumberColumn    = "track_number"
+	TracksCountColumn    = "tracks_count"
+)
+
+// Qualified columns for queries that join albums with artists and tracks.
+const (
+	AlbumsIDColumn       = AlbumsTableName + "." + IDColumn
+	AlbumsTitleColumn    = AlbumsTableName + "." + TitleColumn
+	AlbumsArtistIDColumn = AlbumsTableName + "." + ArtistIDColumn
+	AlbumsTypeColumn     = AlbumsTableName + "." + TypeColumn
+	AlbumTracksSongID    = AlbumTracksTableName + "." + SongIDColumn
+	AlbumTracksAlbumID   = AlbumTracksTableName + "." + AlbumIDColumn
+	// AlbumReleaseDateExpr selects the album release date as a string to its precision, empty if it is unknown.
+	AlbumReleaseDateExpr = "coalesce(format_release_date(" + AlbumsTableName + "." + ReleaseDateColumn + ", " +
+		AlbumsTableName + "." + ReleaseDatePrecisionColumn + "), '') AS " + ReleaseDateColumn
+)
+
+const (
+	GenresTableName     = "genres"
+	SongGenresTableName = "song_genres"
+	TagsTableName       = "tags"
+	SongTagsTableName   = "song_tags"
+	ParentIDColumn      = "parent_id"
+	GenreIDColumn       = "genre_id"
+	TagIDColumn         = "tag_id"
+)
+
+// Qualified columns for queries that join tags with song tags.
+const (
+	TagsIDColumn             = TagsTableName + "." + IDColumn
+	TagsNameColumn           = TagsTableName + "." + NameColumn
+	TagsNormalizedNameColumn = TagsTableName + "." + NormalizedNameColumn
+	SongTagsSongIDColumn     = SongTagsTableName + "." + SongIDColumn
+	SongTagsTagIDColumn      = SongTagsTableName + "." + TagIDColumn
+)
+
+const (
+	PlaylistsTableName       = "playlists"
+	PlaylistEntriesTableName = "playlist_entries"
+	DescriptionColumn        = "description"
+	PlaylistIDColumn         = "playlist_id"
+	GroupNameColumn          = "group_name"
+	EntriesCountColumn       = "entries_count"
+)
+
+// Qualified columns for queries that join playlists with their entries.
+const (
+	PlaylistsIDColumn             = PlaylistsTableName + "." + IDColumn
+	PlaylistsNameColumn           = PlaylistsTableName + "." + NameColumn
+	PlaylistEntriesIDColumn       = PlaylistEntriesTableName + "." + IDColumn
+	PlaylistEntriesPlaylistID     = PlaylistEntriesTableName + "." + PlaylistIDColumn
+	PlaylistEntriesSongID         = PlaylistEntriesTableName + "." + SongIDColumn
+	PlaylistEntriesPositionColumn = PlaylistEntriesTableName + "." + PositionColumn
+)
+
+const (
+	APIKeysTableName = "api_keys"
+	RoleColumn       = "role"
+	PrefixColumn     = "prefix"
+	KeyHashColumn    = "key_hash"
+	CreatedByColumn  = "created_by"
+	RotatedAtColumn  = "rotated_at"
+	ExpiresAtColumn  = "expires_at"
+	LastUsedAtColumn = "last_used_at"
+	RevokedAtColumn  = "revoked_at"
+	// APIKeyTouchInterval is how stale last_used_at may get before a request with the key updates it.
+	APIKeyTouchInterval = "1 minute"
+)
@@ -2,13 +2,30 @@ package converter
 
 import (
 	"github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
 	"songs-library/internal/consts"
 	"songs-library/internal/models"
+	"songs-library/internal/releasedate"
+	"time"
 )
 
+// songsWithTagsQuery selects the ids of songs with any of the normalized tag names, once per tag.
+const songsWithTagsQuery = "SELECT " + consts.SongIDColumn + " FROM " + consts.SongTagsTableName +
+	" JOIN " + consts.TagsTableName + " ON " + consts.TagsIDColumn + " = " + consts.TagIDColumn +
+	" WHERE " + consts.TagsNormalizedNameColumn + " = ANY(?)"
+
+// songsInGenresQuery selects the ids of songs in any of the genres or their subgenres.
+const songsInGenresQuery = "SELECT " + consts.SongIDColumn + " FROM " + consts.SongGenresTableName +
+	" WHERE " + consts.GenreIDColumn + " IN (" +
+	"WITH RECURSIVE subgenres AS (" +
+	"SELECT " + consts.IDColumn + " FROM " + consts.GenresTableName + " WHERE " + consts.IDColumn + " = ANY(?)" +
+	" UNION SELECT g." + consts.IDColumn + " FROM " + consts.GenresTableName + " g" +
+	" JOIN subgenres ON g." + consts.ParentIDColumn + " = subgenres." + consts.IDColumn +
+	") SELECT " + consts.IDColumn + " FROM subgenres)"
+
 func SongFilterToSqlFilters(q squirrel.SelectBuilder, filter *models.SongsFilter) squirrel.SelectBuilder {
 	if len(filter.IDs) != 0 {
-		q = q.Where(squirrel.Eq{consts.IDColumn: filter.IDs})
+		q = q.Where(squirrel.Eq{consts.SongsIDColumn: filter.IDs})
 	}
 
 	if filter.Song != "" {
@@ -16,20 +33,126 @@ func SongFilterToSqlFilters(q squirrel.SelectBuilder, filter *models.SongsFilter
 	}
 
 	if filter.Group != "" {
-		q = q.Where(squirrel.Like{consts.GroupColumn: setLike(filter.Group)})
+		q = q.Where(squirrel.Like{consts.ArtistsNameColumn: setLike(filter.Group)})
+	}
+
+	if date, err := releasedate.Parse(filter.ReleaseDate); err == nil {
+		q = releasedBetween(q, date.Time, date.End())
 	}
 
-	if filter.ReleaseDate != "" {
-		q = q.Where(squirrel.Like{consts.ReleaseDateColumn: setLike(filter.ReleaseDate)})
+	if date, err := releasedate.Parse(filter.ReleasedFrom); err == nil {
+		q = q.Where(squirrel.GtOrEq{consts.ReleaseDateColumn: date.Time.Format(time.DateOnly)})
+	}
+
+	if date, err := releasedate.Parse(filter.ReleasedTo); err == nil {
+		q = q.Where(squirrel.LtOrEq{consts.ReleaseDateColumn: date.End().Format(time.DateOnly)})
+	}
+
+	if filter.Decade != 0 {
+		start := time.Date(filter.Decade, time.January, 1, 0, 0, 0, 0, time.UTC)
+		q = releasedBetween(q, start, start.AddDate(10, 0, -1))
+	}
+
+	if filter.Year != 0 {
+		start := time.Date(filter.Year, time.January, 1, 0, 0, 0, 0, time.UTC)
+		q = releasedBetween(q, start, start.AddDate(1, 0, -1))
 	}
 
 	if filter.Link != "" {
 		q = q.Where(squirrel.Like{consts.LinkColumn: setLike(filter.Link)})
 	}
 
+	if filter.AlbumID != 0 {
+		q = q.Where(
+			consts.SongsIDColumn+" IN (SELECT "+consts.SongIDColumn+" FROM "+consts.AlbumTracksTableName+
+				" WHERE "+consts.AlbumIDColumn+" = ?)",
+			filter.AlbumID,
+		)
+	}
+
+	if tags := models.NormalizeTagNames(filter.TagsAny); len(tags) != 0 {
+		q = q.Where(consts.SongsIDColumn+" IN ("+songsWithTagsQuery+")", pq.Array(tags))
+	}
+
+	if tags := models.NormalizeTagNames(filter.TagsAll); len(tags) != 0 {
+		q = q.Where(
+			consts.SongsIDColumn+" IN ("+songsWithTagsQuery+" GROUP BY "+consts.SongIDColumn+" HAVING count(*) = ?)",
+			pq.Array(tags), len(tags),
+		)
+	}
+
+	if tags := models.NormalizeTagNames(filter.TagsNone); len(tags) != 0 {
+		q = q.Where(consts.SongsIDColumn+" NOT IN ("+songsWithTagsQuery+")", pq.Array(tags))
+	}
+
+	if len(filter.GenreIDs) != 0 {
+		q = q.Where(consts.SongsIDColumn+" IN ("+songsInGenresQuery+")", pq.Array(filter.GenreIDs))
+	}
+
+	if filter.Lyrics != "" {
+		q = q.Where(consts.TextSearchColumn+" @@ "+LyricsTSQuery(filter.LyricsMode), filter.Lyrics)
+	}
+
+	return q
+}
+
+// SongsPagination selects one song more than the page holds, so the caller knows whether there is another page.
+// Pages are counted from the cursor if there is one.
+func SongsPagination(q squirrel.SelectBuilder, filter *models.SongsFilter) squirrel.SelectBuilder {
 	if filter.Page < 1 {
 		filter.Page = 1
+	}
 
+	if filter.Limit < 1 {
+		filter.Limit = consts.DefaultLimit
+	}
+
+	q = q.Limit(uint64(filter.Limit + 1))
+
+	if filter.Cursor == "" {
+		q = q.Offset(uint64((filter.Page - 1) * filter.Limit))
+	}
+
+	return q
+}
+
+// releasedBetween keeps the songs released from the first to the last day, inclusive. Dates are passed
+// as strings so they are compared as dates and not converted through the session time zone.
+func releasedBetween(q squirrel.SelectBuilder, first, last time.Time) squirrel.SelectBuilder {
+	return q.Where(
+		consts.ReleaseDateColumn+" BETWEEN ? AND ?",
+		first.Format(time.DateOnly),
+		last.Format(time.DateOnly),
+	)
+}
+
+// LyricsColumns adds the rank of a lyrics search to the selected columns.
+func LyricsColumns(q squirrel.SelectBuilder, filter *models.SongsFilter) squirrel.SelectBuilder {
+	return q.Column(squirrel.Expr(
+		"ts_rank("+consts.TextSearchColumn+", "+LyricsTSQuery(filter.LyricsMode)+") AS "+consts.RankColumn,
+		filter.Lyrics,
+	))
+}
+
+// LyricsTSQuery returns the tsquery constructor for the lyrics search mode with a single placeholder.
+func LyricsTSQuery(mode string) string {
+	switch mode {
+	case consts.LyricsModePhrase:
+		return "phraseto_tsquery('" + consts.TextSearchConfig + "', ?)"
+	case consts.LyricsModeWebSearch:
+		return "websearch_to_tsquery('" + consts.TextSearchConfig + "', ?)"
+	default:
+		return "plainto_tsquery('" + consts.TextSearchConfig + "', ?)"
+	}
+}
+
+func ArtistsFilterToSqlFilters(q squirrel.SelectBuilder, filter *models.ArtistsFilter) squirrel.SelectBuilder {
+	if filter.Name != "" {
+		q = q.Where(squirrel.ILike{consts.ArtistsNameColumn: setLike(filter.Name)})
+	}
+
+	if filter.Page < 1 {
+		filter.Page = 1
 	}
 
 	if filter.Limit < 1 {
@@ -45,3 +168,72 @@ func SongFilterToSqlFilters(q squirrel.SelectBuilder, filter *models.SongsFilter
 func setLike(s string) string {
 	return "%" + s + "%"
 }
+
+func AlbumsFilterToSqlFilters(q squirrel.SelectBuilder, filter *models.AlbumsFilter) squirrel.SelectBuilder {
+	if filter.Title != "" {
+		q = q.Where(squirrel.ILike{consts.AlbumsTitleColumn: setLike(filter.Title)})
+	}
+
+	if filter.Group != "" {
+		q = q.Where(squirrel.ILike{consts.ArtistsNameColumn: setLike(filter.Group)})
+	}
+
+	if filter.ArtistID != 0 {
+		q = q.Where(squirrel.Eq{consts.AlbumsArtistIDColumn: filter.ArtistID})
+	}
+
+	if filter.Type != "" {
+		q = q.Where(squirrel.Eq{consts.AlbumsTypeColumn: filter.Type})
+	}
+
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+
+	if filter.Limit < 1 {
+		filter.Limit = consts.DefaultLimit
+	}
+
+	q = q.Limit(uint64(filter.Limit))
+	q = q.Offset(uint64((filter.Page - 1) * filter.Limit))
+
+	return q
+}
+
+func TagsFilterToSqlFilters(q squirrel.SelectBuilder, filter *models.TagsFilter) squirrel.SelectBuilder {
+	if filter.Name != "" {
+		q = q.Where(squirrel.ILike{consts.TagsNameColumn: setLike(filter.Name)})
+	}
+
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+
+	if filter.Limit < 1 {
+		filter.Limit = consts.DefaultLimit
+	}
+
+	q = q.Limit(uint64(filter.Limit))
+	q = q.Offset(uint64((filter.Page - 1) * filter.Limit))
+
+	return q
+}
+
+func PlaylistsFilterToSqlFilters(q squirrel.SelectBuilder, filter *models.PlaylistsFilter) squirrel.SelectBuilder {
+	if filter.Name != "" {
+		q = q.Where(squirrel.ILike{consts.PlaylistsNameColumn: setLike(filter.Name)})
+	}
+
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+
+	if filter.Limit < 1 {
+		filter.Limit = consts.DefaultLimit
+	}
+
+	q = q.Limit(uint64(filter.Limit))
+	q = q.Offset(uint64((filter.Page - 1) * filter.Limit))
+
+	return q
+}
@@ -0,0 +1,140 @@
+package converter
+
+import (
+	"github.com/Masterminds/squirrel"
+	"slices"
+	"songs-library/internal/consts"
+	"songs-library/internal/models"
+	"strconv"
+)
+
+// sortColumn is an expression songs are ordered by. Its values are passed through cursors as text and cast back.
+type sortColumn struct {
+	expr string
+	args []any
+	cast string
+	desc bool
+}
+
+// sortColumns returns the columns of the sort keys followed by the id, which makes the order total.
+func sortColumns(filter *models.SongsFilter, keys []models.SortKey) []sortColumn {
+	columns := make([]sortColumn, 0, len(keys)+1)
+
+	for _, key := range keys {
+		column := sortColumn{desc: key.Desc}
+
+		switch key.Field {
+		case models.SortSong:
+			column.expr, column.cast = consts.SongsTableName+"."+consts.SongColumn, "varchar"
+		case models.SortGroup:
+			column.expr, column.cast = consts.ArtistsNameColumn, "varchar"
+		case models.SortReleaseDate:
+			// Unknown dates sort as the earliest, keyset comparisons can't skip over nulls.
+			column.expr, column.cast = "coalesce("+consts.SongsReleaseDateColumn+", '-infinity'::date)", "date"
+		case models.SortCreatedAt:
+			column.expr, column.cast = consts.SongsCreatedAtColumn, "timestamptz"
+		case models.SortRelevance:
+			column.expr = "ts_rank(" + consts.TextSearchColumn + ", " + LyricsTSQuery(filter.LyricsMode) + ")"
+			column.args = []any{filter.Lyrics}
+			column.cast = "real"
+		}
+
+		columns = append(columns, column)
+	}
+
+	return append(columns, sortColumn{expr: consts.SongsIDColumn, cast: "int"})
+}
+
+// SongsOrder orders the songs by the sort keys and selects the key values of every row for its cursor.
+// With a cursor only the songs past it are selected; for a prev cursor the order is reversed.
+func SongsOrder(q squirrel.SelectBuilder, filter *models.SongsFilter, cursor *models.Cursor) squirrel.SelectBuilder {
+	columns := sortColumns(filter, filter.SortKeys())
+	backward := cursor != nil && cursor.Prev
+
+	for i, column := range columns {
+		q = q.Column(squirrel.Expr(
+			"("+column.expr+")::text AS "+consts.SortKeyColumnPrefix+strconv.Itoa(i),
+			column.args...,
+		))
+
+		dir := " ASC"
+		if column.desc != backward {
+			dir = " DESC"
+		}
+
+		q = q.OrderByClause(column.expr+dir, column.args...)
+	}
+
+	if cursor != nil {
+		q = q.Where(keysetAfter(columns, cursor.Values, backward))
+	}
+
+	return q
+}
+
+// LyricsSnippets selects the page of songs and adds the highlighted snippet of a lyrics search after its columns.
+// ts_headline reads the whole text, so it is computed for the songs of the page only and not for every match.
+// The page is put back in order by its key values.
+func LyricsSnippets(page squirrel.SelectBuilder, filter *models.SongsFilter, cursor *models.Cursor) squirrel.SelectBuilder {
+	backward := cursor != nil && cursor.Prev
+
+	q := squirrel.Select(pageAlias+".*").
+		Column(squirrel.Expr(
+			"ts_headline('"+consts.TextSearchConfig+"', coalesce("+consts.SongsTableName+"."+consts.TextColumn+", ''), "+
+				LyricsTSQuery(filter.LyricsMode)+", '"+consts.HeadlineOptions+"') AS "+consts.SnippetColumn,
+			filter.Lyrics,
+		)).
+		PlaceholderFormat(squirrel.Dollar).
+		FromSelect(page, pageAlias).
+		Join(consts.SongsTableName + " ON " + consts.SongsIDColumn + " = " + pageAlias + "." + consts.IDColumn)
+
+	for i, column := range sortColumns(filter, filter.SortKeys()) {
+		dir := " ASC"
+		if column.desc != backward {
+			dir = " DESC"
+		}
+
+		q = q.OrderBy("CAST(" + pageAlias + "." + consts.SortKeyColumnPrefix + strconv.Itoa(i) + " AS " + column.cast + ")" + dir)
+	}
+
+	return q
+}
+
+// pageAlias names the page query LyricsSnippets selects from.
+const pageAlias = "page"
+
+// SortKeysCount is the number of key values SongsOrder selects after the other columns.
+func SortKeysCount(filter *models.SongsFilter) int {
+	return len(filter.SortKeys()) + 1
+}
+
+// keysetAfter matches the rows that come after values in the order of the columns:
+// (a > x) OR (a = x AND b > y) OR ...
+func keysetAfter(columns []sortColumn, values []string, backward bool) squirrel.Sqlizer {
+	or := make(squirrel.Or, 0, len(columns))
+
+	for i, column := range columns {
+		and := make(squirrel.And, 0, i+1)
+
+		for j, prev := range columns[:i] {
+			and = append(and, squirrel.Expr(
+				prev.expr+" = CAST(? AS "+prev.cast+")",
+				slices.Concat(prev.args, []any{values[j]})...,
+			))
+		}
+
+		op := " > "
+		if column.desc != backward {
+			op = " < "
+		}
+
+		and = append(and, squirrel.Expr(
+			column.expr+op+"CAST(? AS "+column.cast+")",
+			slices.Concat(column.args, []any{values[i]})...,
+		))
+
+		or = append(or, and)
+	}
+
+	return or
+}
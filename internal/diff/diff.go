@@ -0,0 +1,119 @@
+// Package diff compares texts line by line.
+package diff
+
+import (
+	"fmt"
+	"songs-library/internal/models"
+)
+
+// MaxLines is the most lines a text may have to be compared: the time a diff takes grows with
+// the product of the line counts.
+const MaxLines = 5000
+
+var ErrTooLarge = fmt.Errorf("texts longer than %d lines can't be compared", MaxLines)
+
+// Lines returns the edit script turning a into b, based on their longest common subsequence.
+// It keeps memory linear in the input by finding the subsequence with Hirschberg's algorithm.
+func Lines(a, b []string) ([]models.DiffLine, error) {
+	if len(a) > MaxLines || len(b) > MaxLines {
+		return nil, ErrTooLarge
+	}
+
+	lines := make([]models.DiffLine, 0, max(len(a), len(b)))
+
+	// The common ends are equal lines whatever the subsequence between them.
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	lines = appendLines(lines, models.DiffEqual, a[:prefix])
+	lines = hirschberg(lines, a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])
+	lines = appendLines(lines, models.DiffEqual, a[len(a)-suffix:])
+
+	return lines, nil
+}
+
+// hirschberg appends the edit script turning a into b to lines. It splits a in half, finds where
+// the longest common subsequence splits b, and recurses on both halves.
+func hirschberg(lines []models.DiffLine, a, b []string) []models.DiffLine {
+	switch {
+	case len(a) == 0:
+		return appendLines(lines, models.DiffInsert, b)
+	case len(b) == 0:
+		return appendLines(lines, models.DiffDelete, a)
+	case len(a) == 1:
+		for j := range b {
+			if b[j] == a[0] {
+				lines = appendLines(lines, models.DiffInsert, b[:j])
+				lines = append(lines, models.DiffLine{Op: models.DiffEqual, Text: a[0]})
+				return appendLines(lines, models.DiffInsert, b[j+1:])
+			}
+		}
+
+		lines = append(lines, models.DiffLine{Op: models.DiffDelete, Text: a[0]})
+		return appendLines(lines, models.DiffInsert, b)
+	}
+
+	mid := len(a) / 2
+	head := lcsHead(a[:mid], b)
+	tail := lcsTail(a[mid:], b)
+
+	split, best := 0, -1
+	for k := range head {
+		if head[k]+tail[k] > best {
+			split, best = k, head[k]+tail[k]
+		}
+	}
+
+	lines = hirschberg(lines, a[:mid], b[:split])
+	return hirschberg(lines, a[mid:], b[split:])
+}
+
+// lcsHead returns, for each k, the length of the longest common subsequence of a and b[:k].
+func lcsHead(a, b []string) []int {
+	prev, cur := make([]int, len(b)+1), make([]int, len(b)+1)
+
+	for i := range a {
+		for j := range b {
+			if a[i] == b[j] {
+				cur[j+1] = prev[j] + 1
+			} else {
+				cur[j+1] = max(prev[j+1], cur[j])
+			}
+		}
+		prev, cur = cur, prev
+	}
+
+	return prev
+}
+
+// lcsTail returns, for each k, the length of the longest common subsequence of a and b[k:].
+func lcsTail(a, b []string) []int {
+	prev, cur := make([]int, len(b)+1), make([]int, len(b)+1)
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				cur[j] = prev[j+1] + 1
+			} else {
+				cur[j] = max(prev[j], cur[j+1])
+			}
+		}
+		prev, cur = cur, prev
+	}
+
+	return prev
+}
+
+func appendLines(lines []models.DiffLine, op string, texts []string) []models.DiffLine {
+	for _, text := range texts {
+		lines = append(lines, models.DiffLine{Op: op, Text: text})
+	}
+
+	return lines
+}
@@ -0,0 +1,109 @@
+package diff
+
+import (
+	"errors"
+	"reflect"
+	"songs-library/internal/models"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		name string
+		a    string
+		b    string
+		want string
+	}{
+		{name: "both empty", a: "", b: "", want: ""},
+		{name: "added text", a: "", b: "x y", want: "+x +y"},
+		{name: "removed text", a: "x y", b: "", want: "-x -y"},
+		{name: "same text", a: "x y z", b: "x y z", want: " x  y  z"},
+		{name: "changed line", a: "x y z", b: "x q z", want: " x -y +q  z"},
+		{name: "inserted line", a: "x z", b: "x y z", want: " x +y  z"},
+		{name: "deleted line", a: "x y z", b: "x z", want: " x -y  z"},
+		{name: "moved line", a: "a b c d", b: "b c d a", want: "-a  b  c  d +a"},
+		{name: "nothing in common", a: "a b", b: "c d", want: "-a -b +c +d"},
+		{name: "repeated lines", a: "a b a b a", b: "b a b", want: "-a  b  a  b -a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Lines(fields(tt.a), fields(tt.b))
+			if err != nil {
+				t.Fatalf("Lines() error = %v", err)
+			}
+
+			if script := format(got); script != tt.want {
+				t.Errorf("Lines(%q, %q) = %q, want %q", tt.a, tt.b, script, tt.want)
+			}
+		})
+	}
+}
+
+func TestLinesLarge(t *testing.T) {
+	a := make([]string, MaxLines)
+	b := make([]string, MaxLines)
+	for i := range a {
+		a[i] = strconv.Itoa(i)
+		// Every third line changes; the rest stay in place.
+		b[i] = a[i]
+		if i%3 == 0 {
+			b[i] = "changed " + a[i]
+		}
+	}
+
+	got, err := Lines(a, b)
+	if err != nil {
+		t.Fatalf("Lines() error = %v", err)
+	}
+
+	from, to, equal := apply(got)
+	if !reflect.DeepEqual(from, a) || !reflect.DeepEqual(to, b) {
+		t.Fatal("Lines() script doesn't turn a into b")
+	}
+	if want := MaxLines - (MaxLines+2)/3; equal != want {
+		t.Errorf("Lines() kept %d equal lines, want %d", equal, want)
+	}
+
+	_, err = Lines(append(a, "one more"), b)
+	if !errors.Is(err, ErrTooLarge) {
+		t.Errorf("Lines() of %d lines error = %v, want ErrTooLarge", MaxLines+1, err)
+	}
+}
+
+func fields(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	return strings.Fields(s)
+}
+
+func format(lines []models.DiffLine) string {
+	parts := make([]string, len(lines))
+	for i, line := range lines {
+		parts[i] = line.Op + line.Text
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// apply returns the texts the script turns from and to, and the number of lines it keeps.
+func apply(lines []models.DiffLine) (from, to []string, equal int) {
+	for _, line := range lines {
+		switch line.Op {
+		case models.DiffEqual:
+			from = append(from, line.Text)
+			to = append(to, line.Text)
+			equal++
+		case models.DiffDelete:
+			from = append(from, line.Text)
+		case models.DiffInsert:
+			to = append(to, line.Text)
+		}
+	}
+
+	return from, to, equal
+}
@@ -0,0 +1,147 @@
+// Package exporter writes songs as CSV, NDJSON or a JSON array one at a time.
+package exporter
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"songs-library/internal/models"
+	"strconv"
+)
+
+type Writer interface {
+	Write(*models.SongWithText) error
+	// Flush writes out what the writer buffers itself.
+	Flush() error
+	// Close ends the document. It doesn't close the underlying writer.
+	Close() error
+}
+
+// NewWriter starts a document of the format. The text is written only with withText.
+func NewWriter(w io.Writer, format string, withText bool) (Writer, error) {
+	switch format {
+	case models.ExportFormatCSV:
+		return newCSVWriter(w, withText)
+	case models.ExportFormatNDJSON:
+		return &ndjsonWriter{enc: json.NewEncoder(w), withText: withText}, nil
+	case models.ExportFormatJSON:
+		return newJSONWriter(w, withText)
+	default:
+		return nil, models.ErrInvalidSongsExportFormat
+	}
+}
+
+// csvHeader matches the columns the importer reads, so an export can be imported back.
+var csvHeader = []string{"id", "song", "artist_id", "group", "release_date", "link", "enrichment_status", "version"}
+
+type csvWriter struct {
+	w        *csv.Writer
+	withText bool
+	record   []string
+}
+
+func newCSVWriter(w io.Writer, withText bool) (*csvWriter, error) {
+	header := csvHeader
+	if withText {
+		header = append(header[:len(header):len(header)], "text")
+	}
+
+	cw := csvWriter{w: csv.NewWriter(w), withText: withText, record: make([]string, len(header))}
+	if err := cw.w.Write(header); err != nil {
+		return nil, err
+	}
+
+	return &cw, nil
+}
+
+func (c *csvWriter) Write(song *models.SongWithText) error {
+	c.record[0] = strconv.Itoa(song.ID)
+	c.record[1] = song.Song.Song
+	c.record[2] = strconv.Itoa(song.ArtistID)
+	c.record[3] = song.Group
+	c.record[4] = song.ReleaseDate
+	c.record[5] = song.Link
+	c.record[6] = song.EnrichmentStatus
+	c.record[7] = strconv.Itoa(song.Version)
+	if c.withText {
+		c.record[8] = song.Text
+	}
+
+	return c.w.Write(c.record)
+}
+
+func (c *csvWriter) Flush() error {
+	c.w.Flush()
+
+	return c.w.Error()
+}
+
+func (c *csvWriter) Close() error {
+	return c.Flush()
+}
+
+type ndjsonWriter struct {
+	enc      *json.Encoder
+	withText bool
+}
+
+func (n *ndjsonWriter) Write(song *models.SongWithText) error {
+	if n.withText {
+		return n.enc.Encode(song)
+	}
+
+	return n.enc.Encode(&song.Song)
+}
+
+func (n *ndjsonWriter) Flush() error {
+	return nil
+}
+
+func (n *ndjsonWriter) Close() error {
+	return nil
+}
+
+type jsonWriter struct {
+	w        io.Writer
+	withText bool
+	started  bool
+}
+
+func newJSONWriter(w io.Writer, withText bool) (*jsonWriter, error) {
+	if _, err := io.WriteString(w, "["); err != nil {
+		return nil, err
+	}
+
+	return &jsonWriter{w: w, withText: withText}, nil
+}
+
+func (j *jsonWriter) Write(song *models.SongWithText) error {
+	var v any = song
+	if !j.withText {
+		v = &song.Song
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	if j.started {
+		data = append([]byte(","), data...)
+	}
+	j.started = true
+
+	_, err = j.w.Write(data)
+
+	return err
+}
+
+func (j *jsonWriter) Flush() error {
+	return nil
+}
+
+func (j *jsonWriter) Close() error {
+	_, err := io.WriteString(j.w, "]\n")
+
+	return err
+}
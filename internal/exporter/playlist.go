@@ -0,0 +1,93 @@
+package exporter
+
+import (
+	"bufio"
+	"encoding/xml"
+	"io"
+	"songs-library/internal/models"
+	"strings"
+)
+
+// WritePlaylist writes the playlist as M3U or XSPF, each song located by its link. Entries that are not
+// available or have no link are left out, since a player has nothing to play for them.
+func WritePlaylist(w io.Writer, format string, playlist *models.PlaylistWithEntries) error {
+	switch format {
+	case models.PlaylistFormatM3U:
+		return writeM3U(w, playlist)
+	case models.PlaylistFormatXSPF:
+		return writeXSPF(w, playlist)
+	default:
+		return models.ErrInvalidPlaylistFormat
+	}
+}
+
+// m3uLine keeps a name on its line of an M3U file.
+var m3uLine = strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ")
+
+func writeM3U(w io.Writer, playlist *models.PlaylistWithEntries) error {
+	bw := bufio.NewWriter(w)
+
+	bw.WriteString("#EXTM3U\n")
+	bw.WriteString("#PLAYLIST:" + m3uLine.Replace(playlist.Name) + "\n")
+
+	for _, entry := range playlist.Entries {
+		if !entry.Available || entry.Song.Link == "" {
+			continue
+		}
+
+		bw.WriteString("#EXTINF:-1," + m3uLine.Replace(entry.Song.Group+" - "+entry.Song.Song) + "\n")
+		bw.WriteString(m3uLine.Replace(entry.Song.Link) + "\n")
+	}
+
+	return bw.Flush()
+}
+
+type xspfPlaylist struct {
+	XMLName    xml.Name    `xml:"http://xspf.org/ns/0/ playlist"`
+	Version    string      `xml:"version,attr"`
+	Title      string      `xml:"title"`
+	Annotation string      `xml:"annotation,omitempty"`
+	Tracks     []xspfTrack `xml:"trackList>track"`
+}
+
+type xspfTrack struct {
+	Location string `xml:"location"`
+	Title    string `xml:"title"`
+	Creator  string `xml:"creator"`
+}
+
+func writeXSPF(w io.Writer, playlist *models.PlaylistWithEntries) error {
+	doc := xspfPlaylist{
+		Version:    "1",
+		Title:      playlist.Name,
+		Annotation: playlist.Description,
+		Tracks:     make([]xspfTrack, 0, len(playlist.Entries)),
+	}
+
+	for _, entry := range playlist.Entries {
+		if !entry.Available || entry.Song.Link == "" {
+			continue
+		}
+
+		doc.Tracks = append(doc.Tracks, xspfTrack{
+			Location: entry.Song.Link,
+			Title:    entry.Song.Song,
+			Creator:  entry.Song.Group,
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+
+	return err
+}
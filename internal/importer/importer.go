@@ -0,0 +1,213 @@
+// Package importer reads songs from CSV, JSON array and NDJSON documents.
+package importer
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"songs-library/internal/models"
+	"strconv"
+	"strings"
+)
+
+// maxLineSize bounds an NDJSON line, lyrics included.
+const maxLineSize = 4 << 20
+
+var (
+	ErrInvalidDocument = errors.New("invalid import document")
+	ErrMissingColumns  = errors.New("csv header must have song and group columns")
+)
+
+// RowFunc is called for every row in order. A row that can't be decoded is passed with its error and
+// a nil song. Returning an error stops the reading.
+type RowFunc func(row int, song *models.ImportSong, err error) error
+
+// Read streams the songs of the document to fn. It returns the error fn stopped it with, or an error wrapping
+// ErrInvalidDocument if the document as a whole can't be read further.
+func Read(r io.Reader, format string, fn RowFunc) error {
+	var stopErr error
+	stop := func(row int, song *models.ImportSong, err error) error {
+		stopErr = fn(row, song, err)
+		return stopErr
+	}
+
+	var err error
+	switch format {
+	case models.ImportFormatCSV:
+		err = readCSV(r, stop)
+	case models.ImportFormatJSON:
+		err = readJSON(r, stop)
+	case models.ImportFormatNDJSON:
+		err = readNDJSON(r, stop)
+	default:
+		return models.ErrInvalidImportFormat
+	}
+
+	if stopErr != nil {
+		return stopErr
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidDocument, err)
+	}
+
+	return nil
+}
+
+// readCSV reads a CSV file with a header naming the columns: song, group, release_date, text, link,
+// album, disc_number, track_number. Other columns are ignored.
+func readCSV(r io.Reader, fn RowFunc) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\uFEFF")))] = i
+	}
+
+	if _, ok := columns["song"]; !ok {
+		return ErrMissingColumns
+	}
+	if _, ok := columns["group"]; !ok {
+		return ErrMissingColumns
+	}
+
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+
+		return strings.TrimSpace(record[i])
+	}
+
+	// number reads an optional positive number; anything else fails the row's validation.
+	number := func(record []string, name string) int {
+		v := field(record, name)
+		if v == "" {
+			return 0
+		}
+
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return -1
+		}
+
+		return n
+	}
+
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		// A malformed record fails its row; a failed read of the document ends it.
+		var parseErr *csv.ParseError
+		if err != nil && !errors.As(err, &parseErr) {
+			return err
+		}
+		if err != nil {
+			if err = fn(row, nil, err); err != nil {
+				return err
+			}
+			continue
+		}
+
+		song := models.ImportSong{
+			Row:         row,
+			Song:        field(record, "song"),
+			Group:       field(record, "group"),
+			ReleaseDate: field(record, "release_date"),
+			Text:        field(record, "text"),
+			Link:        field(record, "link"),
+			Album:       field(record, "album"),
+			DiscNumber:  number(record, "disc_number"),
+			TrackNumber: number(record, "track_number"),
+		}
+
+		if err = fn(row, &song, nil); err != nil {
+			return err
+		}
+	}
+}
+
+// readJSON reads a JSON array of songs without loading it whole. Malformed JSON ends the array, as the decoder
+// can't find where the next element starts.
+func readJSON(r io.Reader, fn RowFunc) error {
+	dec := json.NewDecoder(r)
+
+	tok, err := dec.Token()
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return errors.New("json document must be an array of songs")
+	}
+
+	for row := 1; dec.More(); row++ {
+		var song models.ImportSong
+		if err = dec.Decode(&song); err != nil {
+			if fnErr := fn(row, nil, err); fnErr != nil {
+				return fnErr
+			}
+
+			// A value of the wrong type is skipped whole, the decoder can go on.
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &typeErr) {
+				continue
+			}
+
+			return fmt.Errorf("row %d: %w", row, err)
+		}
+
+		song.Row = row
+		if err = fn(row, &song, nil); err != nil {
+			return err
+		}
+	}
+
+	_, err = dec.Token()
+
+	return err
+}
+
+// readNDJSON reads one song per line. Blank lines are skipped but counted, so rows match line numbers.
+func readNDJSON(r io.Reader, fn RowFunc) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxLineSize)
+
+	for row := 1; scanner.Scan(); row++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var song models.ImportSong
+		if err := json.Unmarshal([]byte(line), &song); err != nil {
+			if err = fn(row, nil, err); err != nil {
+				return err
+			}
+			continue
+		}
+
+		song.Row = row
+		if err := fn(row, &song, nil); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
@@ -0,0 +1,201 @@
+// Package lrc reads and writes time-synced lyrics in the LRC format and exports them as WebVTT.
+package lrc
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"songs-library/internal/models"
+	"strconv"
+	"strings"
+)
+
+var (
+	timestamp = regexp.MustCompile(`^\[(\d{1,3}):(\d{2})(?:[.:](\d{1,3}))?\]`)
+	tag       = regexp.MustCompile(`^\[([a-zA-Z#]+):(.*)\]$`)
+)
+
+// ParseError reports the first invalid line of an LRC document.
+type ParseError struct {
+	Line int
+	Msg  string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("lrc line %d: %s", e.Line, e.Msg)
+}
+
+// Parse reads an LRC document. A line may carry several timestamps, e.g. a repeated chorus line,
+// and is then listed once per timestamp. Lines are returned sorted by time.
+func Parse(text string) (*models.SyncedLyrics, error) {
+	lyrics := &models.SyncedLyrics{Tags: make(map[string]string)}
+
+	for i, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		n := i + 1
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		if !timestamp.MatchString(line) {
+			m := tag.FindStringSubmatch(line)
+			if m == nil {
+				return nil, &ParseError{Line: n, Msg: "expected [mm:ss.xx] timestamp or [tag:value]"}
+			}
+
+			key, value := strings.ToLower(m[1]), strings.TrimSpace(m[2])
+			if key == "offset" {
+				offset, err := strconv.Atoi(strings.TrimPrefix(value, "+"))
+				if err != nil {
+					return nil, &ParseError{Line: n, Msg: "offset must be a number of milliseconds"}
+				}
+				lyrics.OffsetMs = offset
+				continue
+			}
+
+			lyrics.Tags[key] = value
+			continue
+		}
+
+		var times []int
+		for {
+			m := timestamp.FindStringSubmatch(line)
+			if m == nil {
+				break
+			}
+
+			ms, err := parseTime(m[1], m[2], m[3])
+			if err != nil {
+				return nil, &ParseError{Line: n, Msg: err.Error()}
+			}
+
+			times = append(times, ms)
+			line = line[len(m[0]):]
+		}
+
+		line = strings.TrimSpace(line)
+		for _, ms := range times {
+			lyrics.Lines = append(lyrics.Lines, models.SyncedLine{TimeMs: ms, Text: line})
+		}
+	}
+
+	if len(lyrics.Lines) == 0 {
+		return nil, &ParseError{Line: 1, Msg: "no timestamped lines"}
+	}
+
+	slices.SortStableFunc(lyrics.Lines, func(a, b models.SyncedLine) int {
+		return a.TimeMs - b.TimeMs
+	})
+
+	return lyrics, nil
+}
+
+func parseTime(min, sec, frac string) (int, error) {
+	m, _ := strconv.Atoi(min)
+	s, _ := strconv.Atoi(sec)
+	if s >= 60 {
+		return 0, fmt.Errorf("seconds out of range in %s:%s", min, sec)
+	}
+
+	ms := 0
+	if frac != "" {
+		f, _ := strconv.Atoi(frac)
+		switch len(frac) {
+		case 1:
+			ms = f * 100
+		case 2:
+			ms = f * 10
+		default:
+			ms = f
+		}
+	}
+
+	return (m*60+s)*1000 + ms, nil
+}
+
+// Text returns the plain lyrics. Empty timestamped lines, which LRC uses for pauses, become verse breaks.
+func Text(lyrics *models.SyncedLyrics) string {
+	var b strings.Builder
+	for i, line := range lyrics.Lines {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(line.Text)
+	}
+
+	return strings.TrimSpace(collapseBreaks(b.String()))
+}
+
+func collapseBreaks(s string) string {
+	for strings.Contains(s, "\n\n\n") {
+		s = strings.ReplaceAll(s, "\n\n\n", "\n\n")
+	}
+
+	return s
+}
+
+// ActiveLine returns the index of the line shown at playback position atMs, or -1 before the first line.
+func ActiveLine(lyrics *models.SyncedLyrics, atMs int) int {
+	at := atMs + lyrics.OffsetMs
+
+	i, _ := slices.BinarySearchFunc(lyrics.Lines, at+1, func(l models.SyncedLine, t int) int {
+		return l.TimeMs - t
+	})
+
+	return i - 1
+}
+
+// FormatLRC writes the lyrics back as an LRC document with the tags sorted by name.
+func FormatLRC(lyrics *models.SyncedLyrics) string {
+	var b strings.Builder
+
+	keys := make([]string, 0, len(lyrics.Tags))
+	for k := range lyrics.Tags {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	for _, k := range keys {
+		fmt.Fprintf(&b, "[%s:%s]\n", k, lyrics.Tags[k])
+	}
+	if lyrics.OffsetMs != 0 {
+		fmt.Fprintf(&b, "[offset:%+d]\n", lyrics.OffsetMs)
+	}
+
+	for _, line := range lyrics.Lines {
+		fmt.Fprintf(&b, "[%02d:%02d.%02d]%s\n", line.TimeMs/60000, line.TimeMs/1000%60, line.TimeMs%1000/10, line.Text)
+	}
+
+	return b.String()
+}
+
+// FormatVTT writes the lyrics as WebVTT cues. Each cue lasts until the next line starts,
+// the last one for lastCueMs. Empty lines only end the previous cue.
+func FormatVTT(lyrics *models.SyncedLyrics, lastCueMs int) string {
+	var b strings.Builder
+	b.WriteString("WEBVTT\n")
+
+	if title := lyrics.Tags["ti"]; title != "" {
+		fmt.Fprintf(&b, "\nNOTE %s\n", title)
+	}
+
+	for i, line := range lyrics.Lines {
+		if line.Text == "" {
+			continue
+		}
+
+		start := max(line.TimeMs-lyrics.OffsetMs, 0)
+		end := start + lastCueMs
+		if i+1 < len(lyrics.Lines) {
+			end = max(lyrics.Lines[i+1].TimeMs-lyrics.OffsetMs, start)
+		}
+
+		fmt.Fprintf(&b, "\n%s --> %s\n%s\n", vttTime(start), vttTime(end), line.Text)
+	}
+
+	return b.String()
+}
+
+func vttTime(ms int) string {
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
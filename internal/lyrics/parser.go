@@ -0,0 +1,210 @@
+package lyrics
+
+import (
+	"regexp"
+	"songs-library/internal/models"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var (
+	// bracketMarker matches "[Chorus]", "[Verse 2: Artist]" or "[Припев x2]" on a line of its own.
+	bracketMarker = regexp.MustCompile(`^\[([^\]]+)\]$`)
+	// colonMarker matches "Bridge:" or "Verse 2:" on a line of its own. Only known section names count,
+	// so a lyric line that happens to end with a colon is not taken for a marker.
+	colonMarker = regexp.MustCompile(`^([\p{L}][\p{L}\- ]*?)\s*(\d+)?\s*:$`)
+	markerCount = regexp.MustCompile(`(?i)\s*[x×]\s*\d+$`)
+	markerNum   = regexp.MustCompile(`\s(\d+)$`)
+)
+
+var sectionKeywords = []struct {
+	prefix string
+	typ    string
+}{
+	{"pre-chorus", models.SectionPreChorus},
+	{"pre chorus", models.SectionPreChorus},
+	{"prechorus", models.SectionPreChorus},
+	{"предприпев", models.SectionPreChorus},
+	{"chorus", models.SectionChorus},
+	{"refrain", models.SectionChorus},
+	{"припев", models.SectionChorus},
+	{"verse", models.SectionVerse},
+	{"куплет", models.SectionVerse},
+	{"bridge", models.SectionBridge},
+	{"бридж", models.SectionBridge},
+	{"intro", models.SectionIntro},
+	{"вступление", models.SectionIntro},
+	{"интро", models.SectionIntro},
+	{"outro", models.SectionOutro},
+	{"аутро", models.SectionOutro},
+	{"концовка", models.SectionOutro},
+	{"hook", models.SectionHook},
+}
+
+type block struct {
+	typ     string
+	label   string
+	ordinal int
+	lines   []string
+}
+
+// Parse splits lyrics into typed sections. A section starts at a marker line or after a blank line.
+// Unmarked blocks that repeat, or repeat a marked chorus, are choruses and the rest are verses.
+// A marker without lines of its own, like a bare "[Chorus]", repeats the previous section of that type.
+func Parse(text string) models.Sections {
+	blocks := split(text)
+
+	choruses := make(map[string]int)
+	for _, b := range blocks {
+		if b.typ == "" || b.typ == models.SectionChorus {
+			choruses[normalize(b.lines)]++
+		}
+	}
+
+	sections := make(models.Sections, 0, len(blocks))
+	ordinals := make(map[string]int)
+	last := make(map[string]string)
+
+	for _, b := range blocks {
+		body := strings.Join(b.lines, "\n")
+
+		if b.typ == "" {
+			b.typ = models.SectionVerse
+			if choruses[normalize(b.lines)] > 1 {
+				b.typ = models.SectionChorus
+			}
+		}
+
+		if body == "" {
+			body = last[b.typ]
+		}
+		if body == "" {
+			continue
+		}
+		last[b.typ] = body
+
+		ordinals[b.typ]++
+		if b.ordinal == 0 {
+			b.ordinal = ordinals[b.typ]
+		}
+
+		sections = append(sections, models.Section{
+			Position: len(sections) + 1,
+			Type:     b.typ,
+			Ordinal:  b.ordinal,
+			Label:    b.label,
+			Text:     body,
+		})
+	}
+
+	return sections
+}
+
+func split(text string) []block {
+	var (
+		blocks []block
+		cur    *block
+	)
+
+	flush := func() {
+		if cur != nil && (len(cur.lines) != 0 || cur.label != "") {
+			blocks = append(blocks, *cur)
+		}
+		cur = nil
+	}
+
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		line = strings.TrimSpace(line)
+
+		if line == "" {
+			if cur != nil && len(cur.lines) != 0 {
+				flush()
+			}
+			continue
+		}
+
+		if typ, ordinal, ok := parseMarker(line); ok {
+			flush()
+			cur = &block{typ: typ, label: strings.Trim(line, "[]:"), ordinal: ordinal}
+			continue
+		}
+
+		if cur == nil {
+			cur = &block{}
+		}
+		cur.lines = append(cur.lines, line)
+	}
+	flush()
+
+	return blocks
+}
+
+func parseMarker(line string) (string, int, bool) {
+	if m := bracketMarker.FindStringSubmatch(line); m != nil {
+		name, _, _ := strings.Cut(m[1], ":")
+		name = markerCount.ReplaceAllString(strings.TrimSpace(name), "")
+
+		ordinal := 0
+		if n := markerNum.FindStringSubmatch(name); n != nil {
+			ordinal, _ = strconv.Atoi(n[1])
+		}
+
+		typ := sectionType(name)
+		if typ == "" {
+			typ = models.SectionOther
+		}
+
+		return typ, ordinal, true
+	}
+
+	if m := colonMarker.FindStringSubmatch(line); m != nil {
+		typ := exactSectionType(m[1])
+		if typ == "" {
+			return "", 0, false
+		}
+
+		ordinal, _ := strconv.Atoi(m[2])
+		return typ, ordinal, true
+	}
+
+	return "", 0, false
+}
+
+func sectionType(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, k := range sectionKeywords {
+		if strings.HasPrefix(name, k.prefix) {
+			return k.typ
+		}
+	}
+
+	return ""
+}
+
+// exactSectionType accepts only a bare section name, so "Bridge:" is a marker and "Bridges over water:" is not.
+func exactSectionType(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, k := range sectionKeywords {
+		if name == k.prefix {
+			return k.typ
+		}
+	}
+
+	return ""
+}
+
+// normalize makes blocks that differ only in case, punctuation or spacing compare equal.
+func normalize(lines []string) string {
+	var b strings.Builder
+	for _, line := range lines {
+		for _, r := range strings.ToLower(line) {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				b.WriteRune(r)
+			}
+		}
+		b.WriteByte('\n')
+	}
+
+	return b.String()
+}
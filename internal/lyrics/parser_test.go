@@ -0,0 +1,108 @@
+package lyrics
+
+import (
+	"reflect"
+	"songs-library/internal/models"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want models.Sections
+	}{
+		{
+			name: "empty",
+			text: "",
+			want: models.Sections{},
+		},
+		{
+			name: "repeated unmarked block is a chorus",
+			text: "a\nb\n\nc\nd\n\na\nb",
+			want: models.Sections{
+				{Position: 1, Type: models.SectionChorus, Ordinal: 1, Text: "a\nb"},
+				{Position: 2, Type: models.SectionVerse, Ordinal: 1, Text: "c\nd"},
+				{Position: 3, Type: models.SectionChorus, Ordinal: 2, Text: "a\nb"},
+			},
+		},
+		{
+			name: "unmarked repeat of a marked chorus",
+			text: "[Chorus]\nhey\n\nverse line\n\nhey",
+			want: models.Sections{
+				{Position: 1, Type: models.SectionChorus, Ordinal: 1, Label: "Chorus", Text: "hey"},
+				{Position: 2, Type: models.SectionVerse, Ordinal: 1, Text: "verse line"},
+				{Position: 3, Type: models.SectionChorus, Ordinal: 2, Text: "hey"},
+			},
+		},
+		{
+			name: "bracket marker with number and artist",
+			text: "[Verse 2: Artist]\nline one\nline two",
+			want: models.Sections{
+				{Position: 1, Type: models.SectionVerse, Ordinal: 2, Label: "Verse 2: Artist", Text: "line one\nline two"},
+			},
+		},
+		{
+			name: "bare marker repeats the previous section",
+			text: "[Chorus]\nla la\n\n[Verse]\nx\n\n[Chorus]",
+			want: models.Sections{
+				{Position: 1, Type: models.SectionChorus, Ordinal: 1, Label: "Chorus", Text: "la la"},
+				{Position: 2, Type: models.SectionVerse, Ordinal: 1, Label: "Verse", Text: "x"},
+				{Position: 3, Type: models.SectionChorus, Ordinal: 2, Label: "Chorus", Text: "la la"},
+			},
+		},
+		{
+			name: "bare marker without a previous section is dropped",
+			text: "[Bridge]\n\n[Verse]\nx",
+			want: models.Sections{
+				{Position: 1, Type: models.SectionVerse, Ordinal: 1, Label: "Verse", Text: "x"},
+			},
+		},
+		{
+			name: "russian marker with repeat count",
+			text: "[Припев x2]\nраз\nдва",
+			want: models.Sections{
+				{Position: 1, Type: models.SectionChorus, Ordinal: 1, Label: "Припев x2", Text: "раз\nдва"},
+			},
+		},
+		{
+			name: "colon markers",
+			text: "Bridge:\nover the water\n\nVerse 2:\nx",
+			want: models.Sections{
+				{Position: 1, Type: models.SectionBridge, Ordinal: 1, Label: "Bridge", Text: "over the water"},
+				{Position: 2, Type: models.SectionVerse, Ordinal: 2, Label: "Verse 2", Text: "x"},
+			},
+		},
+		{
+			name: "lyric line ending with a colon is not a marker",
+			text: "Bridges over water:\nline",
+			want: models.Sections{
+				{Position: 1, Type: models.SectionVerse, Ordinal: 1, Text: "Bridges over water:\nline"},
+			},
+		},
+		{
+			name: "unknown bracket marker",
+			text: "[Solo]\nguitar",
+			want: models.Sections{
+				{Position: 1, Type: models.SectionOther, Ordinal: 1, Label: "Solo", Text: "guitar"},
+			},
+		},
+		{
+			name: "crlf line endings and padding",
+			text: "  a\r\nb  \r\n\r\n\r\nc",
+			want: models.Sections{
+				{Position: 1, Type: models.SectionVerse, Ordinal: 1, Text: "a\nb"},
+				{Position: 2, Type: models.SectionVerse, Ordinal: 2, Text: "c"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Parse(tt.text)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse(%q) = %+v, want %+v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
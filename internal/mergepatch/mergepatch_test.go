@@ -0,0 +1,80 @@
+package mergepatch
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestApply(t *testing.T) {
+	// The examples of RFC 7396, appendix A, whose patches are objects.
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		want  string
+	}{
+		{name: "replace member", doc: `{"a":"b"}`, patch: `{"a":"c"}`, want: `{"a":"c"}`},
+		{name: "add member", doc: `{"a":"b"}`, patch: `{"b":"c"}`, want: `{"a":"b","b":"c"}`},
+		{name: "remove member", doc: `{"a":"b"}`, patch: `{"a":null}`, want: `{}`},
+		{name: "remove one of members", doc: `{"a":"b","b":"c"}`, patch: `{"a":null}`, want: `{"b":"c"}`},
+		{name: "array replaced by value", doc: `{"a":["b"]}`, patch: `{"a":"c"}`, want: `{"a":"c"}`},
+		{name: "value replaced by array", doc: `{"a":"c"}`, patch: `{"a":["b"]}`, want: `{"a":["b"]}`},
+		{name: "nested merge", doc: `{"a":{"b":"c"}}`, patch: `{"a":{"b":"d","c":null}}`, want: `{"a":{"b":"d"}}`},
+		{name: "arrays are not merged", doc: `{"a":[{"b":"c"}]}`, patch: `{"a":[1]}`, want: `{"a":[1]}`},
+		{name: "null in the document is kept", doc: `{"e":null}`, patch: `{"a":1}`, want: `{"a":1,"e":null}`},
+		{name: "value replaced by object", doc: `{"a":"foo"}`, patch: `{"a":{"bb":{"ccc":null}}}`, want: `{"a":{"bb":{}}}`},
+		{name: "empty patch", doc: `{"a":"b"}`, patch: `{}`, want: `{"a":"b"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Apply([]byte(tt.doc), []byte(tt.patch))
+			if err != nil {
+				t.Fatalf("Apply(%s, %s) error = %v", tt.doc, tt.patch, err)
+			}
+
+			var gotValue, wantValue any
+			if err = json.Unmarshal(got, &gotValue); err != nil {
+				t.Fatalf("Apply(%s, %s) = %s, not JSON: %v", tt.doc, tt.patch, got, err)
+			}
+			if err = json.Unmarshal([]byte(tt.want), &wantValue); err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(gotValue, wantValue) {
+				t.Errorf("Apply(%s, %s) = %s, want %s", tt.doc, tt.patch, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyErrors(t *testing.T) {
+	tests := []struct {
+		name         string
+		doc          string
+		patch        string
+		invalidPatch bool
+	}{
+		{name: "array patch", doc: `{"a":"b"}`, patch: `["c"]`, invalidPatch: true},
+		{name: "null patch", doc: `{"a":"b"}`, patch: `null`, invalidPatch: true},
+		{name: "string patch", doc: `{"a":"b"}`, patch: `"c"`, invalidPatch: true},
+		{name: "malformed patch", doc: `{"a":"b"}`, patch: `{"a":`},
+		{name: "malformed document", doc: `{"a":`, patch: `{"a":"c"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Apply([]byte(tt.doc), []byte(tt.patch))
+			if err == nil {
+				t.Fatalf("Apply(%s, %s) error = nil, want an error", tt.doc, tt.patch)
+			}
+
+			if got := errors.Is(err, ErrInvalidPatch); got != tt.invalidPatch {
+				t.Errorf("Apply(%s, %s) error = %v, is ErrInvalidPatch = %v, want %v",
+					tt.doc, tt.patch, err, got, tt.invalidPatch)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+// Package migrate applies the SQL migrations written for goose: files named <version>_<name>.sql with
+// "-- +goose Up" and "-- +goose Down" sections. The applied versions are kept in goose's own table,
+// so a database migrated with goose carries on as is.
+package migrate
+
+import (
+	"bufio"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// lockKey is the advisory lock that keeps instances started together from migrating at once.
+const lockKey = 7316029450201
+
+var (
+	ErrSchemaBehind     = errors.New("database schema is behind the code")
+	ErrNoMigrations     = errors.New("no migrations applied")
+	ErrUnknownVersion   = errors.New("no migration with this version")
+	ErrInvalidMigration = errors.New("invalid migration")
+)
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+	// NoTx is set by "-- +goose NO TRANSACTION", for statements that can't run in a transaction.
+	NoTx bool
+}
+
+// Status is a migration and whether it is applied to the database.
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt *time.Time
+}
+
+type Migrator struct {
+	log        *slog.Logger
+	db         *sql.DB
+	migrations []Migration
+}
+
+// New reads the migrations from the root of fsys.
+func New(log *slog.Logger, db *sql.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		log:        log.With(slog.String("component", "migrate")),
+		db:         db,
+		migrations: migrations,
+	}, nil
+}
+
+// Load reads and parses the migrations from the root of fsys, in version order.
+func Load(fsys fs.FS) ([]Migration, error) {
+	names, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make([]Migration, 0, len(names))
+	for _, name := range names {
+		version, rest, ok := strings.Cut(strings.TrimSuffix(name, ".sql"), "_")
+		v, err := strconv.ParseInt(version, 10, 64)
+		if !ok || err != nil || v <= 0 {
+			return nil, fmt.Errorf("%w: %s: name must be <version>_<name>.sql", ErrInvalidMigration, name)
+		}
+
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+
+		m, err := parse(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrInvalidMigration, name, err)
+		}
+		m.Version, m.Name = v, rest
+
+		migrations = append(migrations, m)
+	}
+
+	slices.SortFunc(migrations, func(a, b Migration) int {
+		return cmpInt64(a.Version, b.Version)
+	})
+
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version == migrations[i-1].Version {
+			return nil, fmt.Errorf("%w: version %d is used twice", ErrInvalidMigration, migrations[i].Version)
+		}
+	}
+
+	return migrations, nil
+}
+
+// parse splits a migration into its sections. The statements of a section are run together, so the
+// StatementBegin and StatementEnd annotations need no handling.
+func parse(data string) (Migration, error) {
+	const (
+		none = iota
+		up
+		down
+	)
+
+	var (
+		m        Migration
+		section  = none
+		sections [3]strings.Builder
+		seenUp   bool
+	)
+
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		annotation, ok := strings.CutPrefix(strings.TrimSpace(line), "-- +goose")
+		if !ok {
+			sections[section].WriteString(line + "\n")
+			continue
+		}
+
+		switch strings.TrimSpace(annotation) {
+		case "Up":
+			section, seenUp = up, true
+		case "Down":
+			section = down
+		case "NO TRANSACTION":
+			m.NoTx = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Migration{}, err
+	}
+
+	if !seenUp {
+		return Migration{}, errors.New(`no "-- +goose Up" section`)
+	}
+
+	m.Up = sections[up].String()
+	m.Down = sections[down].String()
+
+	return m, nil
+}
+
+func cmpInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,316 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+const versionTableExistsQuery = `SELECT to_regclass('goose_db_version') IS NOT NULL`
+
+const createVersionTableQuery = `
+CREATE TABLE goose_db_version (
+    id serial PRIMARY KEY,
+    version_id bigint NOT NULL,
+    is_applied boolean NOT NULL,
+    tstamp timestamp DEFAULT now()
+);
+INSERT INTO goose_db_version (version_id, is_applied) VALUES (0, true);`
+
+// appliedQuery lists the state of each version. Rolling a migration back adds a row with is_applied false,
+// so only the last row of a version counts.
+const appliedQuery = `
+SELECT DISTINCT ON (version_id) version_id, is_applied, tstamp
+FROM goose_db_version
+WHERE version_id > 0
+ORDER BY version_id, id DESC`
+
+const recordQuery = `INSERT INTO goose_db_version (version_id, is_applied) VALUES ($1, $2)`
+
+// execer runs the statements of a migration, on a transaction or straight on the connection.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// Latest is the version of the newest migration: the schema the code is written for.
+func (m *Migrator) Latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Status lists the migrations with whether each is applied.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx, m.db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		at, ok := applied[mig.Version]
+		status := Status{Migration: mig, Applied: ok}
+		if ok {
+			status.AppliedAt = &at
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// Version returns the newest applied version, 0 if none is.
+func (m *Migrator) Version(ctx context.Context) (int64, error) {
+	applied, err := m.applied(ctx, m.db)
+	if err != nil {
+		return 0, err
+	}
+
+	var version int64
+	for v := range applied {
+		version = max(version, v)
+	}
+
+	return version, nil
+}
+
+// CheckSchema returns ErrSchemaBehind while a migration of the code is not applied. Migrations the code
+// doesn't know of, from a newer release, are accepted: they are written to keep working with the code before them.
+func (m *Migrator) CheckSchema(ctx context.Context) error {
+	applied, err := m.applied(ctx, m.db)
+	if err != nil {
+		return err
+	}
+
+	var pending int
+	for _, mig := range m.migrations {
+		if _, ok := applied[mig.Version]; !ok {
+			pending++
+		}
+	}
+
+	if pending > 0 {
+		return fmt.Errorf("%w: %d migrations up to version %d are not applied", ErrSchemaBehind, pending, m.Latest())
+	}
+
+	return nil
+}
+
+// Up applies every migration not applied yet, oldest first.
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.To(ctx, m.Latest())
+}
+
+// Down rolls back the newest applied migration.
+func (m *Migrator) Down(ctx context.Context) error {
+	return m.locked(ctx, func(conn *sql.Conn, applied map[int64]time.Time) error {
+		mig, ok := m.newestApplied(applied)
+		if !ok {
+			return ErrNoMigrations
+		}
+
+		return m.run(ctx, conn, mig, false)
+	})
+}
+
+// Redo rolls back the newest applied migration and applies it again.
+func (m *Migrator) Redo(ctx context.Context) error {
+	return m.locked(ctx, func(conn *sql.Conn, applied map[int64]time.Time) error {
+		mig, ok := m.newestApplied(applied)
+		if !ok {
+			return ErrNoMigrations
+		}
+
+		if err := m.run(ctx, conn, mig, false); err != nil {
+			return err
+		}
+
+		return m.run(ctx, conn, mig, true)
+	})
+}
+
+// To applies the migrations up to the version and rolls back the ones after it, newest first.
+// Version 0 rolls back every migration.
+func (m *Migrator) To(ctx context.Context, version int64) error {
+	if version != 0 && !m.known(version) {
+		return fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+	}
+
+	return m.locked(ctx, func(conn *sql.Conn, applied map[int64]time.Time) error {
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			mig := m.migrations[i]
+			if _, ok := applied[mig.Version]; ok && mig.Version > version {
+				if err := m.run(ctx, conn, mig, false); err != nil {
+					return err
+				}
+			}
+		}
+
+		for _, mig := range m.migrations {
+			if _, ok := applied[mig.Version]; !ok && mig.Version <= version {
+				if err := m.run(ctx, conn, mig, true); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+}
+
+// locked runs fn holding the migration lock, on a connection where the version table exists.
+func (m *Migrator) locked(ctx context.Context, fn func(conn *sql.Conn, applied map[int64]time.Time) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err = conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockKey); err != nil {
+		return fmt.Errorf("cannot take the migration lock: %w", err)
+	}
+	defer func() {
+		_, _ = conn.ExecContext(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock($1)", lockKey)
+	}()
+
+	var exists bool
+	if err = conn.QueryRowContext(ctx, versionTableExistsQuery).Scan(&exists); err != nil {
+		return err
+	}
+
+	if !exists {
+		if _, err = conn.ExecContext(ctx, createVersionTableQuery); err != nil {
+			return fmt.Errorf("cannot create the version table: %w", err)
+		}
+	}
+
+	// The versions are read under the lock: another instance may have just migrated.
+	applied, err := m.applied(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	return fn(conn, applied)
+}
+
+// run applies the migration, or rolls it back, and records it in the same transaction.
+func (m *Migrator) run(ctx context.Context, conn *sql.Conn, mig Migration, up bool) error {
+	query, direction := mig.Up, "up"
+	if !up {
+		query, direction = mig.Down, "down"
+	}
+
+	t1 := time.Now()
+
+	var err error
+	if mig.NoTx {
+		err = apply(ctx, conn, query, mig.Version, up)
+	} else {
+		err = inTx(ctx, conn, func(tx *sql.Tx) error {
+			return apply(ctx, tx, query, mig.Version, up)
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("migration %d_%s %s: %w", mig.Version, mig.Name, direction, err)
+	}
+
+	m.log.Info("migration applied",
+		slog.Int64("version", mig.Version),
+		slog.String("name", mig.Name),
+		slog.String("direction", direction),
+		slog.String("duration", time.Since(t1).String()),
+	)
+
+	return nil
+}
+
+func apply(ctx context.Context, db execer, query string, version int64, up bool) error {
+	// lib/pq runs a query without arguments as a simple query, which may hold several statements.
+	if _, err := db.ExecContext(ctx, query); err != nil {
+		return err
+	}
+
+	_, err := db.ExecContext(ctx, recordQuery, version, up)
+
+	return err
+}
+
+func inTx(ctx context.Context, conn *sql.Conn, fn func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+type querier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// applied returns when each applied version was applied. Without the version table nothing is.
+func (m *Migrator) applied(ctx context.Context, db querier) (map[int64]time.Time, error) {
+	var exists bool
+	if err := db.QueryRowContext(ctx, versionTableExistsQuery).Scan(&exists); err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int64]time.Time)
+	if !exists {
+		return applied, nil
+	}
+
+	rows, err := db.QueryContext(ctx, appliedQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			version   int64
+			isApplied bool
+			at        sql.NullTime
+		)
+		if err = rows.Scan(&version, &isApplied, &at); err != nil {
+			return nil, err
+		}
+
+		if isApplied {
+			applied[version] = at.Time
+		}
+	}
+
+	return applied, rows.Err()
+}
+
+func (m *Migrator) newestApplied(applied map[int64]time.Time) (Migration, bool) {
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		if _, ok := applied[m.migrations[i].Version]; ok {
+			return m.migrations[i], true
+		}
+	}
+
+	return Migration{}, false
+}
+
+func (m *Migrator) known(version int64) bool {
+	for _, mig := range m.migrations {
+		if mig.Version == version {
+			return true
+		}
+	}
+
+	return false
+}
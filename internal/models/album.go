@@ -0,0 +1,197 @@
+package models
+
+import (
+	"errors"
+	"songs-library/internal/releasedate"
+	"strings"
+)
+
+const (
+	AlbumTypeLP          = "lp"
+	AlbumTypeEP          = "ep"
+	AlbumTypeSingle      = "single"
+	AlbumTypeCompilation = "compilation"
+	AlbumTypeLive        = "live"
+)
+
+var (
+	ErrInvalidAlbumID     = errors.New("invalid album_id parameter")
+	ErrTitleIsRequired    = errors.New("title is required")
+	ErrInvalidAlbumType   = errors.New("type must be one of: lp, ep, single, compilation, live")
+	ErrInvalidTrackNumber = errors.New("disc_number and track_number must be positive")
+	ErrDuplicateTrack     = errors.New("each song and each disc_number, track_number pair may appear once per album")
+)
+
+type Album struct {
+	ID          int    `json:"id"`
+	Title       string `json:"title"`
+	ArtistID    int    `json:"artist_id"`
+	Group       string `json:"group"`
+	ReleaseDate string `json:"release_date"`
+	CoverLink   string `json:"cover_link"`
+	Type        string `json:"type" enums:"lp,ep,single,compilation,live"`
+	TracksCount int    `json:"tracks_count"`
+}
+
+type Albums []Album
+
+// Track is a song on an album.
+type Track struct {
+	DiscNumber  int  `json:"disc_number"`
+	TrackNumber int  `json:"track_number"`
+	Song        Song `json:"song"`
+}
+
+// AlbumWithTracks is an album with its tracklist in disc and track order.
+type AlbumWithTracks struct {
+	Album
+	Tracks []Track `json:"tracks"`
+}
+
+// AlbumTrack places a song on an album. Disc 1 is assumed; a track without a number follows
+// the previous track of its disc.
+type AlbumTrack struct {
+	SongID      int `json:"song_id"`
+	DiscNumber  int `json:"disc_number"`
+	TrackNumber int `json:"track_number"`
+}
+
+type CreateAlbum struct {
+	Title       string       `json:"title"`
+	Group       string       `json:"group"`
+	ReleaseDate string       `json:"release_date"`
+	CoverLink   string       `json:"cover_link"`
+	Type        string       `json:"type" enums:"lp,ep,single,compilation,live"`
+	Tracks      []AlbumTrack `json:"tracks"`
+}
+
+func (c *CreateAlbum) Validate() error {
+	return validateAlbum(&c.Title, c.Group, &c.ReleaseDate, &c.Type, c.Tracks)
+}
+
+// UpdateAlbum replaces the album, tracklist included.
+type UpdateAlbum struct {
+	ID          int          `json:"id"`
+	Title       string       `json:"title"`
+	Group       string       `json:"group"`
+	ReleaseDate string       `json:"release_date"`
+	CoverLink   string       `json:"cover_link"`
+	Type        string       `json:"type" enums:"lp,ep,single,compilation,live"`
+	Tracks      []AlbumTrack `json:"tracks"`
+}
+
+func (u *UpdateAlbum) Validate() error {
+	if u.ID <= 0 {
+		return ErrInvalidAlbumID
+	}
+
+	return validateAlbum(&u.Title, u.Group, &u.ReleaseDate, &u.Type, u.Tracks)
+}
+
+type AlbumsFilter struct {
+	Title    string `json:"title"`
+	Group    string `json:"group"`
+	ArtistID int    `json:"artist_id"`
+	Type     string `json:"type" enums:"lp,ep,single,compilation,live"`
+	Page     int    `json:"page"`
+	Limit    int    `json:"limit"`
+}
+
+func (f *AlbumsFilter) Validate() error {
+	if f.Type != "" && !IsAlbumType(f.Type) {
+		return ErrInvalidAlbumType
+	}
+
+	return nil
+}
+
+// validateAlbum trims the title, normalizes the release date, defaults the type to LP and numbers the tracks.
+func validateAlbum(title *string, group string, releaseDate, albumType *string, tracks []AlbumTrack) error {
+	*title = strings.TrimSpace(*title)
+	if *title == "" {
+		return ErrTitleIsRequired
+	}
+
+	if CleanArtistName(group) == "" {
+		return ErrGroupIsRequired
+	}
+
+	date, err := releasedate.Normalize(*releaseDate)
+	if err != nil {
+		return ErrInvalidReleaseDate
+	}
+	*releaseDate = date
+
+	if *albumType == "" {
+		*albumType = AlbumTypeLP
+	}
+
+	if !IsAlbumType(*albumType) {
+		return ErrInvalidAlbumType
+	}
+
+	return NumberTracks(tracks)
+}
+
+// NumberTracks fills in the disc and track numbers left out and checks that no song or position repeats.
+func NumberTracks(tracks []AlbumTrack) error {
+	type position struct{ disc, track int }
+
+	last := make(map[int]int)
+	songs := make(map[int]struct{}, len(tracks))
+	positions := make(map[position]struct{}, len(tracks))
+
+	for i := range tracks {
+		t := &tracks[i]
+
+		if t.SongID <= 0 {
+			return ErrInvalidSongID
+		}
+
+		if t.DiscNumber == 0 {
+			t.DiscNumber = 1
+		}
+
+		if t.TrackNumber == 0 {
+			t.TrackNumber = last[t.DiscNumber] + 1
+		}
+
+		if t.DiscNumber < 0 || t.TrackNumber < 0 {
+			return ErrInvalidTrackNumber
+		}
+
+		last[t.DiscNumber] = t.TrackNumber
+
+		p := position{t.DiscNumber, t.TrackNumber}
+		if _, ok := positions[p]; ok {
+			return ErrDuplicateTrack
+		}
+		if _, ok := songs[t.SongID]; ok {
+			return ErrDuplicateTrack
+		}
+
+		positions[p] = struct{}{}
+		songs[t.SongID] = struct{}{}
+	}
+
+	return nil
+}
+
+func IsAlbumType(t string) bool {
+	switch t {
+	case AlbumTypeLP, AlbumTypeEP, AlbumTypeSingle, AlbumTypeCompilation, AlbumTypeLive:
+		return true
+	default:
+		return false
+	}
+}
+
+// SongAlbum is the album of a song as an info API or an import file gives it.
+type SongAlbum struct {
+	Title       string `json:"title"`
+	ReleaseDate string `json:"releaseDate"`
+	CoverLink   string `json:"coverLink"`
+	Type        string `json:"type"`
+	DiscNumber  int    `json:"discNumber"`
+	TrackNumber int    `json:"trackNumber"`
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"errors"
+	"songs-library/pkg/principal"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidAPIKeyID = errors.New("invalid key_id parameter")
+	ErrInvalidRole     = errors.New("role must be one of: reader, editor, admin")
+	ErrExpiresInPast   = errors.New("expires_at must be in the future")
+)
+
+// APIKey describes a key without the key itself, which is shown only when issued or rotated.
+type APIKey struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	Role       string     `json:"role" enums:"reader,editor,admin"`
+	Prefix     string     `json:"prefix"`
+	CreatedBy  string     `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	RotatedAt  *time.Time `json:"rotated_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+type APIKeys []APIKey
+
+// IssuedAPIKey is a new key. Key is not stored and can't be shown again.
+type IssuedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+// IssueAPIKey names a key and gives it a role. A key without expires_at doesn't expire.
+type IssueAPIKey struct {
+	Name      string     `json:"name"`
+	Role      string     `json:"role" enums:"reader,editor,admin"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+func (i *IssueAPIKey) Validate() error {
+	i.Name = strings.TrimSpace(i.Name)
+	if i.Name == "" {
+		return ErrNameIsRequired
+	}
+
+	if !principal.Role(i.Role).Valid() {
+		return ErrInvalidRole
+	}
+
+	if i.ExpiresAt != nil && !i.ExpiresAt.After(time.Now()) {
+		return ErrExpiresInPast
+	}
+
+	return nil
+}
+
+// APIKeyPrincipalName is the name a key's requests are recorded under.
+func APIKeyPrincipalName(name string) string {
+	return "apikey:" + name
+}
@@ -0,0 +1,93 @@
+package models
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	ErrInvalidArtistID     = errors.New("invalid artist_id parameter")
+	ErrNameIsRequired      = errors.New("name is required")
+	ErrSourceIDsIsRequired = errors.New("source_ids is required")
+	ErrMergeIntoItself     = errors.New("artist cannot be merged into itself")
+)
+
+type Artist struct {
+	ID         int    `json:"id"`
+	Name       string `json:"name"`
+	SongsCount int    `json:"songs_count"`
+}
+
+type Artists []Artist
+
+type CreateArtist struct {
+	Name string `json:"name"`
+}
+
+func (c *CreateArtist) Validate() error {
+	if CleanArtistName(c.Name) == "" {
+		return ErrNameIsRequired
+	}
+
+	return nil
+}
+
+type UpdateArtist struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func (u *UpdateArtist) Validate() error {
+	if u.ID <= 0 {
+		return ErrInvalidArtistID
+	}
+
+	if CleanArtistName(u.Name) == "" {
+		return ErrNameIsRequired
+	}
+
+	return nil
+}
+
+type MergeArtists struct {
+	TargetID  int   `json:"-"`
+	SourceIDs []int `json:"source_ids"`
+}
+
+func (m *MergeArtists) Validate() error {
+	if m.TargetID <= 0 {
+		return ErrInvalidArtistID
+	}
+
+	if len(m.SourceIDs) == 0 {
+		return ErrSourceIDsIsRequired
+	}
+
+	for _, id := range m.SourceIDs {
+		if id <= 0 {
+			return ErrInvalidArtistID
+		}
+
+		if id == m.TargetID {
+			return ErrMergeIntoItself
+		}
+	}
+
+	return nil
+}
+
+type ArtistsFilter struct {
+	Name  string `json:"name"`
+	Page  int    `json:"page"`
+	Limit int    `json:"limit"`
+}
+
+// CleanArtistName trims the name and collapses inner whitespace, keeping the original case for display.
+func CleanArtistName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// NormalizeArtistName returns the key artists are deduplicated by, so "Muse", "muse" and "MUSE " are the same artist.
+func NormalizeArtistName(name string) string {
+	return strings.ToLower(CleanArtistName(name))
+}
@@ -0,0 +1,107 @@
+package models
+
+import (
+	"errors"
+)
+
+// What CreateSong does when the artist already has a song with the same normalized title.
+const (
+	OnDuplicateAllow  = "allow"
+	OnDuplicateReject = "reject"
+	OnDuplicateReturn = "return"
+)
+
+const (
+	DefaultDuplicateThreshold = 0.6
+	DefaultDuplicateClusters  = 50
+	MaxDuplicateClusters      = 500
+)
+
+var (
+	ErrInvalidOnDuplicate     = errors.New("on_duplicate must be one of: allow, reject, return")
+	ErrInvalidThreshold       = errors.New("threshold must be greater than 0 and at most 1")
+	ErrInvalidDuplicatesLimit = errors.New("limit must be between 1 and 500")
+	ErrMergeSongIntoItself    = errors.New("song cannot be merged into itself")
+)
+
+// DuplicateSongError is returned by CreateSong when the song already exists and duplicates are rejected.
+type DuplicateSongError struct {
+	SongID int
+	Song   *Song
+}
+
+func (e *DuplicateSongError) Error() string {
+	return "song already exists"
+}
+
+// DuplicatePair is two songs alike in title and group. Score is the mean of the two similarities.
+type DuplicatePair struct {
+	SongID          int     `json:"song_id"`
+	DuplicateID     int     `json:"duplicate_id"`
+	TitleSimilarity float64 `json:"title_similarity"`
+	GroupSimilarity float64 `json:"group_similarity"`
+	Score           float64 `json:"score"`
+}
+
+// DuplicateCluster is a set of songs linked by duplicate pairs. Score is the best score of its pairs.
+type DuplicateCluster struct {
+	Score float64         `json:"score"`
+	Songs Songs           `json:"songs"`
+	Pairs []DuplicatePair `json:"pairs"`
+}
+
+type DuplicateClusters []DuplicateCluster
+
+type DuplicatesFilter struct {
+	Threshold float64 `json:"threshold" example:"0.6"`
+	Limit     int     `json:"limit"`
+}
+
+func (f *DuplicatesFilter) Validate() error {
+	if f.Threshold == 0 {
+		f.Threshold = DefaultDuplicateThreshold
+	}
+
+	if f.Threshold < 0 || f.Threshold > 1 {
+		return ErrInvalidThreshold
+	}
+
+	if f.Limit == 0 {
+		f.Limit = DefaultDuplicateClusters
+	}
+
+	if f.Limit < 0 || f.Limit > MaxDuplicateClusters {
+		return ErrInvalidDuplicatesLimit
+	}
+
+	return nil
+}
+
+// MergeSongs keeps the target song and deletes the sources. Fields the target lacks are taken from the
+// sources, the release date from the song that knows it most precisely.
+type MergeSongs struct {
+	TargetID  int   `json:"-"`
+	SourceIDs []int `json:"source_ids"`
+}
+
+func (m *MergeSongs) Validate() error {
+	if m.TargetID <= 0 {
+		return ErrInvalidSongID
+	}
+
+	if len(m.SourceIDs) == 0 {
+		return ErrSourceIDsIsRequired
+	}
+
+	for _, id := range m.SourceIDs {
+		if id <= 0 {
+			return ErrInvalidSongID
+		}
+
+		if id == m.TargetID {
+			return ErrMergeSongIntoItself
+		}
+	}
+
+	return nil
+}
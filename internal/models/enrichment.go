@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+const (
+	EnrichmentPending = "pending"
+	EnrichmentOK      = "ok"
+	EnrichmentFailed  = "failed"
+)
+
+// EnrichmentJob is a claimed request to fetch the details of a song from the info API.
+type EnrichmentJob struct {
+	ID       int
+	SongID   int
+	Song     string
+	Group    string
+	Attempts int
+}
+
+type Enrichment struct {
+	SongID    int        `json:"song_id"`
+	Status    string     `json:"status" enums:"pending,ok,failed"`
+	Attempts  int        `json:"attempts"`
+	LastError string     `json:"last_error,omitempty"`
+	NextRunAt *time.Time `json:"next_run_at,omitempty"`
+}
+
+type RequeueEnrichment struct {
+	IDs []int `json:"ids"`
+}
+
+func (r *RequeueEnrichment) Validate() error {
+	for _, id := range r.IDs {
+		if id <= 0 {
+			return ErrInvalidSongID
+		}
+	}
+
+	return nil
+}
+
+type Requeued struct {
+	Count int `json:"count"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"errors"
+	"slices"
+)
+
+var ErrInvalidSongsExportFormat = errors.New("format must be one of: csv, ndjson, json")
+
+const (
+	ExportFormatCSV    = ImportFormatCSV
+	ExportFormatNDJSON = ImportFormatNDJSON
+	ExportFormatJSON   = ImportFormatJSON
+)
+
+// ExportSongs selects the songs to export with the filter. Pagination fields of the filter are ignored.
+type ExportSongs struct {
+	Filter  SongsFilter
+	Format  string
+	Include []string
+}
+
+func (e *ExportSongs) Validate() error {
+	switch e.Format {
+	case ExportFormatCSV, ExportFormatNDJSON, ExportFormatJSON:
+	default:
+		return ErrInvalidSongsExportFormat
+	}
+
+	for _, include := range e.Include {
+		if include != IncludeText {
+			return ErrInvalidInclude
+		}
+	}
+
+	return e.Filter.Validate()
+}
+
+func (e *ExportSongs) IncludesText() bool {
+	return slices.Contains(e.Include, IncludeText)
+}
@@ -0,0 +1,114 @@
+package models
+
+import (
+	"errors"
+	"songs-library/internal/releasedate"
+)
+
+var (
+	ErrInvalidImportFormat = errors.New("format must be one of: csv, json, ndjson")
+	ErrInvalidBatchSize    = errors.New("batch_size must be between 1 and 1000")
+)
+
+const (
+	ImportFormatCSV    = "csv"
+	ImportFormatJSON   = "json"
+	ImportFormatNDJSON = "ndjson"
+)
+
+const (
+	ImportCreated   = "created"
+	ImportDuplicate = "duplicate"
+	ImportFailed    = "failed"
+)
+
+const (
+	DefaultImportBatchSize = 100
+	MaxImportBatchSize     = 1000
+)
+
+// ImportSong is a row of an import file. Only song and group are required. With an album the song is
+// added to the group's album of that title, which is created if there is none.
+type ImportSong struct {
+	Row         int    `json:"-"`
+	Song        string `json:"song"`
+	Group       string `json:"group"`
+	ReleaseDate string `json:"release_date"`
+	Text        string `json:"text"`
+	Link        string `json:"link"`
+	Album       string `json:"album"`
+	DiscNumber  int    `json:"disc_number"`
+	TrackNumber int    `json:"track_number"`
+}
+
+// Validate applies the CreateSong rules, and checks the release date if there is one.
+func (s *ImportSong) Validate() error {
+	create := CreateSong{Song: s.Song, Group: s.Group}
+	if err := create.Validate(); err != nil {
+		return err
+	}
+
+	if _, err := releasedate.Normalize(s.ReleaseDate); err != nil {
+		return ErrInvalidReleaseDate
+	}
+
+	if s.DiscNumber < 0 || s.TrackNumber < 0 {
+		return ErrInvalidTrackNumber
+	}
+
+	return nil
+}
+
+// HasDetails tells whether the row has everything enrichment would fill in.
+func (s *ImportSong) HasDetails() bool {
+	return s.ReleaseDate != "" && s.Text != "" && s.Link != ""
+}
+
+type ImportSongs struct {
+	Format    string
+	Enrich    bool
+	BatchSize int
+}
+
+func (i *ImportSongs) Validate() error {
+	switch i.Format {
+	case ImportFormatCSV, ImportFormatJSON, ImportFormatNDJSON:
+	default:
+		return ErrInvalidImportFormat
+	}
+
+	if i.BatchSize < 1 || i.BatchSize > MaxImportBatchSize {
+		return ErrInvalidBatchSize
+	}
+
+	return nil
+}
+
+// ImportRow is the outcome of a row, numbered from 1 without the CSV header. For a duplicate the id is
+// the song already in the library.
+type ImportRow struct {
+	Row    int    `json:"row"`
+	Status string `json:"status" enums:"created,duplicate,failed"`
+	SongID int    `json:"song_id,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+type ImportReport struct {
+	Created    int         `json:"created"`
+	Duplicates int         `json:"duplicates"`
+	Failed     int         `json:"failed"`
+	Rows       []ImportRow `json:"rows"`
+}
+
+func (r *ImportReport) Add(row ImportRow) {
+	switch row.Status {
+	case ImportCreated:
+		r.Created++
+	case ImportDuplicate:
+		r.Duplicates++
+	case ImportFailed:
+		r.Failed++
+	}
+
+	r.Rows = append(r.Rows, row)
+}
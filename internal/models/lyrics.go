@@ -0,0 +1,45 @@
+package models
+
+import "errors"
+
+var (
+	ErrInvalidPaginateBy  = errors.New("by must be one of: verse, section, line")
+	ErrInvalidSectionType = errors.New("section must be one of: verse, chorus, pre-chorus, bridge, intro, outro, hook, other")
+)
+
+const (
+	SectionVerse     = "verse"
+	SectionChorus    = "chorus"
+	SectionPreChorus = "pre-chorus"
+	SectionBridge    = "bridge"
+	SectionIntro     = "intro"
+	SectionOutro     = "outro"
+	SectionHook      = "hook"
+	SectionOther     = "other"
+)
+
+const (
+	PaginateByVerse   = "verse"
+	PaginateBySection = "section"
+	PaginateByLine    = "line"
+)
+
+// Section is a typed part of the lyrics. Ordinal counts sections of the same type, starting at 1.
+type Section struct {
+	Position int    `json:"position"`
+	Type     string `json:"type" enums:"verse,chorus,pre-chorus,bridge,intro,outro,hook,other"`
+	Ordinal  int    `json:"ordinal"`
+	Label    string `json:"label,omitempty"`
+	Text     string `json:"text"`
+}
+
+type Sections []Section
+
+func IsSectionType(t string) bool {
+	switch t {
+	case SectionVerse, SectionChorus, SectionPreChorus, SectionBridge, SectionIntro, SectionOutro, SectionHook, SectionOther:
+		return true
+	}
+
+	return false
+}
@@ -1,37 +1,100 @@
 package models
 
-import "errors"
+import (
+	"errors"
+	"slices"
+	"songs-library/internal/consts"
+	"songs-library/internal/releasedate"
+)
 
 var (
-	ErrInvalidSongID   = errors.New("invalid song_id parameter")
-	ErrSongIsRequired  = errors.New("song is required")
-	ErrGroupIsRequired = errors.New("group is required")
+	ErrInvalidSongID      = errors.New("invalid song_id parameter")
+	ErrSongIsRequired     = errors.New("song is required")
+	ErrGroupIsRequired    = errors.New("group is required")
+	ErrInvalidLyricsMode  = errors.New("lyrics_mode must be one of: plain, phrase, websearch")
+	ErrInvalidPatch       = errors.New("invalid merge patch")
+	ErrInvalidFields      = errors.New("fields must be among: id, song, artist_id, group, release_date, link, enrichment_status, version")
+	ErrInvalidInclude     = errors.New("include must be one of: text")
+	ErrInvalidReleaseDate = errors.New("release_date must be a date like 2006-07-16, 16.07.2006, 2006-07 or 2006")
+	ErrInvalidDecade      = errors.New("decade must be a year divisible by 10, e.g. 1990")
+	ErrInvalidYear        = errors.New("year must be positive")
 )
 
+const IncludeText = "text"
+
+// songFields are the JSON names of the Song fields a sparse fieldset can select.
+var songFields = []string{"id", "song", "artist_id", "group", "release_date", "link", "enrichment_status", "version"}
+
 type Song struct {
-	ID          int    `json:"id"`
-	Song        string `json:"song"`
-	Group       string `json:"group"`
-	ReleaseDate string `json:"release_date"`
-	Text        string `json:"-"`
-	Link        string `json:"link"`
+	ID               int     `json:"id"`
+	Song             string  `json:"song"`
+	ArtistID         int     `json:"artist_id"`
+	Group            string  `json:"group"`
+	ReleaseDate      string  `json:"release_date"`
+	Text             string  `json:"-"`
+	Link             string  `json:"link"`
+	EnrichmentStatus string  `json:"enrichment_status" enums:"pending,ok,failed"`
+	Rank             float64 `json:"rank,omitempty"`
+	Snippet          string  `json:"snippet,omitempty"`
+	Version          int     `json:"version"`
 }
 
 type Songs []Song
 
+// SongWithText is a song with the lyrics embedded.
+type SongWithText struct {
+	Song
+	Text string `json:"text"`
+}
+
+type GetSong struct {
+	ID      int
+	Fields  []string
+	Include []string
+}
+
+func (g *GetSong) Validate() error {
+	if g.ID <= 0 {
+		return ErrInvalidSongID
+	}
+
+	for _, field := range g.Fields {
+		if !slices.Contains(songFields, field) {
+			return ErrInvalidFields
+		}
+	}
+
+	for _, include := range g.Include {
+		if include != IncludeText {
+			return ErrInvalidInclude
+		}
+	}
+
+	return nil
+}
+
+func (g *GetSong) IncludesText() bool {
+	return slices.Contains(g.Include, IncludeText)
+}
+
 type SongDetail struct {
-	ReleaseDate string `json:"releaseDate"`
-	Text        string `json:"text"`
-	Link        string `json:"link"`
+	ReleaseDate string     `json:"releaseDate"`
+	Text        string     `json:"text"`
+	Link        string     `json:"link"`
+	Album       *SongAlbum `json:"album,omitempty"`
 }
 
 type UpdateSong struct {
 	ID          int    `json:"id"`
 	Song        string `json:"song"`
+	ArtistID    int    `json:"artist_id"`
 	Group       string `json:"group"`
 	ReleaseDate string `json:"release_date"`
 	Text        string `json:"text"`
 	Link        string `json:"link"`
+	Version     int    `json:"version"`
+	// IfMatch lists the versions the update may apply to, any version if empty.
+	IfMatch []int `json:"-"`
 }
 
 func (s *UpdateSong) Validate() error {
@@ -43,7 +106,7 @@ func (s *UpdateSong) Validate() error {
 		return ErrSongIsRequired
 	}
 
-	if s.Group == "" {
+	if CleanArtistName(s.Group) == "" {
 		return ErrGroupIsRequired
 	}
 
@@ -51,12 +114,61 @@ func (s *UpdateSong) Validate() error {
 		return errors.New("release_date is required")
 	}
 
+	if _, err := releasedate.Parse(s.ReleaseDate); err != nil {
+		return ErrInvalidReleaseDate
+	}
+
+	return nil
+}
+
+// PatchSong is a JSON Merge Patch (RFC 7396) of the song's SongFields.
+type PatchSong struct {
+	ID      int
+	Patch   []byte
+	IfMatch []int
+}
+
+func (p *PatchSong) Validate() error {
+	if p.ID <= 0 {
+		return ErrInvalidSongID
+	}
+
+	if len(p.Patch) == 0 {
+		return ErrInvalidPatch
+	}
+
+	return nil
+}
+
+// SongFields are the fields of a song a merge patch can change.
+type SongFields struct {
+	Song        string `json:"song,omitempty"`
+	Group       string `json:"group,omitempty"`
+	ReleaseDate string `json:"release_date,omitempty"`
+	Text        string `json:"text,omitempty"`
+	Link        string `json:"link,omitempty"`
+}
+
+func (f *SongFields) Validate() error {
+	if f.Song == "" {
+		return ErrSongIsRequired
+	}
+
+	if CleanArtistName(f.Group) == "" {
+		return ErrGroupIsRequired
+	}
+
+	if _, err := releasedate.Normalize(f.ReleaseDate); err != nil {
+		return ErrInvalidReleaseDate
+	}
+
 	return nil
 }
 
 type CreateSong struct {
-	Song  string `json:"song"`
-	Group string `json:"group"`
+	Song        string `json:"song"`
+	Group       string `json:"group"`
+	OnDuplicate string `json:"on_duplicate" enums:"allow,reject,return"`
 }
 
 func (c *CreateSong) Validate() error {
@@ -64,32 +176,152 @@ func (c *CreateSong) Validate() error {
 		return ErrSongIsRequired
 	}
 
-	if c.Group == "" {
+	if CleanArtistName(c.Group) == "" {
 		return ErrGroupIsRequired
 	}
 
+	switch c.OnDuplicate {
+	case "":
+		c.OnDuplicate = OnDuplicateAllow
+	case OnDuplicateAllow, OnDuplicateReject, OnDuplicateReturn:
+	default:
+		return ErrInvalidOnDuplicate
+	}
+
 	return nil
 }
 
+// SongsFilter selects songs. Release dates known only to the month or year are compared by their first day;
+// ReleaseDate matches the whole period it names, e.g. "2006-07" is any day of July 2006. A song matches
+// TagsAny with one of the tags, TagsAll with all of them and TagsNone with none; GenreIDs match their
+// subgenres too.
 type SongsFilter struct {
-	IDs         []int  `json:"ids"`
-	Song        string `json:"song"`
-	Group       string `json:"group"`
-	ReleaseDate string `json:"release_date"`
-	Link        string `json:"link"`
-	Page        int    `json:"page"`
-	Limit       int    `json:"limit"`
+	IDs          []int    `json:"ids"`
+	Song         string   `json:"song"`
+	Group        string   `json:"group"`
+	ReleaseDate  string   `json:"release_date"`
+	ReleasedFrom string   `json:"released_from"`
+	ReleasedTo   string   `json:"released_to"`
+	Decade       int      `json:"decade" example:"1990"`
+	Year         int      `json:"year"`
+	Link         string   `json:"link"`
+	AlbumID      int      `json:"album_id"`
+	TagsAny      []string `json:"tags_any"`
+	TagsAll      []string `json:"tags_all"`
+	TagsNone     []string `json:"tags_none"`
+	GenreIDs     []int    `json:"genre_ids"`
+	Lyrics       string   `json:"lyrics"`
+	LyricsMode   string   `json:"lyrics_mode" enums:"plain,phrase,websearch"`
+	Sort         string   `json:"sort" example:"release_date:desc,song"`
+	Cursor       string   `json:"cursor"`
+	WithTotal    bool     `json:"with_total"`
+	Page         int      `json:"page"`
+	Limit        int      `json:"limit"`
+}
+
+// SortKeys returns the sort of the list: by relevance for a lyrics search, by id otherwise.
+// The id breaks ties in either case and is not listed.
+func (f *SongsFilter) SortKeys() []SortKey {
+	keys, _ := ParseSort(f.Sort)
+	if len(keys) == 0 && f.Lyrics != "" {
+		keys = []SortKey{{Field: SortRelevance, Desc: true}}
+	}
+
+	return keys
+}
+
+// DecodeCursor returns the cursor of the filter, nil if there is none. Cursors are bound to the sort they were
+// made with.
+func (f *SongsFilter) DecodeCursor() (*Cursor, error) {
+	if f.Cursor == "" {
+		return nil, nil
+	}
+
+	cursor, err := DecodeCursor(f.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := f.SortKeys()
+	if cursor.Sort != FormatSort(keys) || len(cursor.Values) != len(keys)+1 {
+		return nil, ErrInvalidCursor
+	}
+
+	// The values are cast back in the query; one that doesn't read as its column would fail it there.
+	for i, key := range keys {
+		if !validSortValue(key.Field, cursor.Values[i]) {
+			return nil, ErrInvalidCursor
+		}
+	}
+
+	if !validSortValue(sortID, cursor.Values[len(keys)]) {
+		return nil, ErrInvalidCursor
+	}
+
+	return cursor, nil
+}
+
+func (f *SongsFilter) Validate() error {
+	switch f.LyricsMode {
+	case "", consts.LyricsModePlain, consts.LyricsModePhrase, consts.LyricsModeWebSearch:
+	default:
+		return ErrInvalidLyricsMode
+	}
+
+	if f.AlbumID < 0 {
+		return ErrInvalidAlbumID
+	}
+
+	for _, id := range f.GenreIDs {
+		if id <= 0 {
+			return ErrInvalidGenreID
+		}
+	}
+
+	for _, date := range []string{f.ReleaseDate, f.ReleasedFrom, f.ReleasedTo} {
+		if _, err := releasedate.Normalize(date); err != nil {
+			return ErrInvalidReleaseDate
+		}
+	}
+
+	if f.Decade < 0 || f.Decade%10 != 0 {
+		return ErrInvalidDecade
+	}
+
+	if f.Year < 0 {
+		return ErrInvalidYear
+	}
+
+	keys, err := ParseSort(f.Sort)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		if key.Field == SortRelevance && f.Lyrics == "" {
+			return ErrRelevanceNoLyrics
+		}
+	}
+
+	if _, err = f.DecodeCursor(); err != nil {
+		return err
+	}
+
+	return nil
 }
 
 type Text struct {
-	SongID int    `json:"song_id"`
-	Text   string `json:"text"`
+	SongID   int      `json:"song_id"`
+	Text     string   `json:"text"`
+	Sections Sections `json:"sections,omitempty"`
 }
 
 type GetText struct {
-	SongID  int `json:"song_id"`
-	Page    int `json:"page"`
-	PerPage int `json:"per_page"`
+	SongID  int    `json:"song_id"`
+	Page    int    `json:"page"`
+	PerPage int    `json:"per_page"`
+	By      string `json:"by"`
+	Section string `json:"section"`
 }
 
 func (s *GetText) Validate() error {
@@ -97,5 +329,15 @@ func (s *GetText) Validate() error {
 		return ErrInvalidSongID
 	}
 
+	switch s.By {
+	case "", PaginateByVerse, PaginateBySection, PaginateByLine:
+	default:
+		return ErrInvalidPaginateBy
+	}
+
+	if s.Section != "" && !IsSectionType(s.Section) {
+		return ErrInvalidSectionType
+	}
+
 	return nil
 }
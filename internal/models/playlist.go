@@ -0,0 +1,152 @@
+package models
+
+import (
+	"errors"
+	"strings"
+)
+
+// What happens to the playlist entries of a deleted song.
+const (
+	// PlaylistOnSongDeleteKeep keeps the entries as unavailable, with the song and group names they had.
+	PlaylistOnSongDeleteKeep = "keep"
+	// PlaylistOnSongDeleteCascade deletes the entries with the song.
+	PlaylistOnSongDeleteCascade = "cascade"
+)
+
+const (
+	PlaylistFormatM3U  = "m3u"
+	PlaylistFormatXSPF = "xspf"
+)
+
+var (
+	ErrInvalidPlaylistID     = errors.New("invalid playlist_id parameter")
+	ErrInvalidEntryID        = errors.New("invalid entry_id parameter")
+	ErrInvalidPosition       = errors.New("position must be positive")
+	ErrInvalidPlaylistFormat = errors.New("format must be one of: m3u, xspf")
+)
+
+type Playlist struct {
+	ID           int    `json:"id"`
+	Name         string `json:"name"`
+	Description  string `json:"description"`
+	EntriesCount int    `json:"entries_count"`
+}
+
+type Playlists []Playlist
+
+// PlaylistEntry is a song in a playlist. Position is the place of the entry, counted from 1.
+// An entry whose song was deleted is not available; its song then has no id, only the song and group names.
+type PlaylistEntry struct {
+	ID        int  `json:"id"`
+	Position  int  `json:"position"`
+	Available bool `json:"available"`
+	Song      Song `json:"song"`
+}
+
+// PlaylistWithEntries is a playlist with its entries in order.
+type PlaylistWithEntries struct {
+	Playlist
+	Entries []PlaylistEntry `json:"entries"`
+}
+
+type CreatePlaylist struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+func (c *CreatePlaylist) Validate() error {
+	c.Name = strings.TrimSpace(c.Name)
+	if c.Name == "" {
+		return ErrNameIsRequired
+	}
+
+	c.Description = strings.TrimSpace(c.Description)
+
+	return nil
+}
+
+// UpdatePlaylist renames the playlist and replaces its description. The entries are kept.
+type UpdatePlaylist struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+func (u *UpdatePlaylist) Validate() error {
+	if u.ID <= 0 {
+		return ErrInvalidPlaylistID
+	}
+
+	u.Name = strings.TrimSpace(u.Name)
+	if u.Name == "" {
+		return ErrNameIsRequired
+	}
+
+	u.Description = strings.TrimSpace(u.Description)
+
+	return nil
+}
+
+type PlaylistsFilter struct {
+	Name  string `json:"name"`
+	Page  int    `json:"page"`
+	Limit int    `json:"limit"`
+}
+
+// AddPlaylistEntries adds the songs, in order, at the position. Without a position, or past the end,
+// they are added to the end.
+type AddPlaylistEntries struct {
+	SongIDs  []int `json:"song_ids"`
+	Position int   `json:"position"`
+}
+
+func (a *AddPlaylistEntries) Validate() error {
+	if len(a.SongIDs) == 0 {
+		return ErrSongIDsIsRequired
+	}
+
+	for _, id := range a.SongIDs {
+		if id <= 0 {
+			return ErrInvalidSongID
+		}
+	}
+
+	if a.Position < 0 {
+		return ErrInvalidPosition
+	}
+
+	return nil
+}
+
+// MovePlaylistEntry moves the entry to the position. Past the end, the entry becomes the last one.
+type MovePlaylistEntry struct {
+	Position int `json:"position"`
+}
+
+func (m *MovePlaylistEntry) Validate() error {
+	if m.Position <= 0 {
+		return ErrInvalidPosition
+	}
+
+	return nil
+}
+
+// DuplicatePlaylist names the copy of a playlist; by default it is the name of the playlist with " (copy)".
+type DuplicatePlaylist struct {
+	Name string `json:"name"`
+}
+
+func (d *DuplicatePlaylist) Validate() error {
+	d.Name = strings.TrimSpace(d.Name)
+
+	return nil
+}
+
+func ValidatePlaylistFormat(format string) error {
+	switch format {
+	case PlaylistFormatM3U, PlaylistFormatXSPF:
+		return nil
+	default:
+		return ErrInvalidPlaylistFormat
+	}
+}
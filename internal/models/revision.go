@@ -0,0 +1,97 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrInvalidRevision = errors.New("invalid revision parameter")
+
+const (
+	RevisionCreate  = "create"
+	RevisionUpdate  = "update"
+	RevisionDelete  = "delete"
+	RevisionRestore = "restore"
+	RevisionMerge   = "merge"
+)
+
+const (
+	DiffEqual  = " "
+	DiffInsert = "+"
+	DiffDelete = "-"
+)
+
+// SongSnapshot is the state of a song at a revision.
+type SongSnapshot struct {
+	Song        string `json:"song"`
+	ArtistID    int    `json:"artist_id"`
+	Group       string `json:"group"`
+	ReleaseDate string `json:"release_date"`
+	Text        string `json:"text,omitempty"`
+	Link        string `json:"link"`
+}
+
+type Revision struct {
+	SongID    int          `json:"song_id"`
+	Revision  int          `json:"revision"`
+	Action    string       `json:"action" enums:"create,update,delete,restore,merge"`
+	Author    string       `json:"author"`
+	CreatedAt time.Time    `json:"created_at"`
+	Snapshot  SongSnapshot `json:"snapshot"`
+}
+
+type Revisions []Revision
+
+type DiffLine struct {
+	Op   string `json:"op" enums:" ,+,-"`
+	Text string `json:"text"`
+}
+
+type FieldChange struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+type RevisionDiff struct {
+	SongID int           `json:"song_id"`
+	From   int           `json:"from"`
+	To     int           `json:"to"`
+	Fields []FieldChange `json:"fields"`
+	Text   []DiffLine    `json:"text"`
+}
+
+type GetRevision struct {
+	SongID   int
+	Revision int
+}
+
+func (g *GetRevision) Validate() error {
+	if g.SongID <= 0 {
+		return ErrInvalidSongID
+	}
+
+	if g.Revision <= 0 {
+		return ErrInvalidRevision
+	}
+
+	return nil
+}
+
+type DiffRevisions struct {
+	SongID int
+	From   int
+	To     int
+}
+
+func (d *DiffRevisions) Validate() error {
+	if d.SongID <= 0 {
+		return ErrInvalidSongID
+	}
+
+	if d.From <= 0 || d.To <= 0 {
+		return ErrInvalidRevision
+	}
+
+	return nil
+}
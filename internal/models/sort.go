@@ -0,0 +1,160 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	ErrInvalidSort       = errors.New("sort must list song, group, release_date, created_at or relevance, each optionally followed by :asc or :desc")
+	ErrRelevanceNoLyrics = errors.New("sort by relevance requires lyrics")
+	ErrInvalidCursor     = errors.New("invalid cursor")
+)
+
+const (
+	SortSong        = "song"
+	SortGroup       = "group"
+	SortReleaseDate = "release_date"
+	SortCreatedAt   = "created_at"
+	SortRelevance   = "relevance"
+
+	SortAsc  = "asc"
+	SortDesc = "desc"
+
+	// sortID is the song id, the last key of every order.
+	sortID = "id"
+)
+
+// timestampLayouts are the ways Postgres prints a timestamptz, by the offset of the session time zone.
+var timestampLayouts = []string{
+	"2006-01-02 15:04:05.999999-07",
+	"2006-01-02 15:04:05.999999-07:00",
+	"2006-01-02 15:04:05.999999-07:00:00",
+}
+
+type SortKey struct {
+	Field string
+	Desc  bool
+}
+
+// ParseSort reads a comma separated list of keys like "release_date:desc,song". Keys are ascending by default.
+func ParseSort(s string) ([]SortKey, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+
+	var keys []SortKey
+	for _, item := range strings.Split(s, ",") {
+		field, dir, _ := strings.Cut(strings.TrimSpace(item), ":")
+
+		switch field {
+		case SortSong, SortGroup, SortReleaseDate, SortCreatedAt, SortRelevance:
+		default:
+			return nil, ErrInvalidSort
+		}
+
+		switch dir {
+		case "", SortAsc, SortDesc:
+		default:
+			return nil, ErrInvalidSort
+		}
+
+		keys = append(keys, SortKey{Field: field, Desc: dir == SortDesc})
+	}
+
+	return keys, nil
+}
+
+// FormatSort is the inverse of ParseSort.
+func FormatSort(keys []SortKey) string {
+	items := make([]string, 0, len(keys))
+	for _, key := range keys {
+		dir := SortAsc
+		if key.Desc {
+			dir = SortDesc
+		}
+
+		items = append(items, key.Field+":"+dir)
+	}
+
+	return strings.Join(items, ",")
+}
+
+// Cursor is a position in an ordered list of songs: the sort key values of the row it points at.
+// Prev cursors select the rows before it, the others the rows after.
+type Cursor struct {
+	Sort   string   `json:"s"`
+	Prev   bool     `json:"p,omitempty"`
+	Values []string `json:"v"`
+}
+
+// EncodeCursor makes an opaque token of the cursor.
+func EncodeCursor(c *Cursor) string {
+	data, _ := json.Marshal(c)
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeCursor(token string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c Cursor
+	if err = json.Unmarshal(data, &c); err != nil || len(c.Values) == 0 {
+		return nil, ErrInvalidCursor
+	}
+
+	return &c, nil
+}
+
+// validSortValue tells whether v reads as a value of the sort field the way Postgres prints it.
+func validSortValue(field, v string) bool {
+	switch field {
+	case SortSong, SortGroup:
+		return utf8.ValidString(v) && !strings.ContainsRune(v, 0)
+	case SortReleaseDate:
+		// Unknown dates sort as -infinity.
+		if v == "-infinity" {
+			return true
+		}
+
+		_, err := time.Parse(time.DateOnly, v)
+		return err == nil
+	case SortCreatedAt:
+		for _, layout := range timestampLayouts {
+			if _, err := time.Parse(layout, v); err == nil {
+				return true
+			}
+		}
+
+		return false
+	case SortRelevance:
+		// Go reads hexadecimal floats, Postgres doesn't.
+		if strings.ContainsAny(v, "xX") {
+			return false
+		}
+
+		_, err := strconv.ParseFloat(v, 32)
+		return err == nil
+	case sortID:
+		_, err := strconv.ParseInt(v, 10, 32)
+		return err == nil
+	default:
+		return false
+	}
+}
+
+// SongsPage is a page of songs with the cursors of its neighbours, empty at either end of the list.
+type SongsPage struct {
+	Songs      Songs
+	NextCursor string
+	PrevCursor string
+	Total      *int
+}
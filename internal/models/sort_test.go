@@ -0,0 +1,154 @@
+package models
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseSort(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []SortKey
+		wantErr error
+	}{
+		{in: "", want: nil},
+		{in: "  ", want: nil},
+		{in: "song", want: []SortKey{{Field: SortSong}}},
+		{in: "release_date:desc,song", want: []SortKey{{Field: SortReleaseDate, Desc: true}, {Field: SortSong}}},
+		{in: " group:asc , created_at:desc ", want: []SortKey{{Field: SortGroup}, {Field: SortCreatedAt, Desc: true}}},
+		{in: "relevance:desc", want: []SortKey{{Field: SortRelevance, Desc: true}}},
+		{in: "title", wantErr: ErrInvalidSort},
+		{in: "song:up", wantErr: ErrInvalidSort},
+		{in: "song,", wantErr: ErrInvalidSort},
+		{in: "Song", wantErr: ErrInvalidSort},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseSort(tt.in)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseSort(%q) error = %v, want %v", tt.in, err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseSort(%q) = %+v, want %+v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatSort(t *testing.T) {
+	tests := []struct {
+		keys []SortKey
+		want string
+	}{
+		{keys: nil, want: ""},
+		{keys: []SortKey{{Field: SortSong}}, want: "song:asc"},
+		{keys: []SortKey{{Field: SortReleaseDate, Desc: true}, {Field: SortGroup}}, want: "release_date:desc,group:asc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			got := FormatSort(tt.keys)
+			if got != tt.want {
+				t.Errorf("FormatSort(%+v) = %q, want %q", tt.keys, got, tt.want)
+			}
+
+			// The formatted sort reads back as the same keys.
+			keys, err := ParseSort(got)
+			if err != nil || !reflect.DeepEqual(keys, tt.keys) {
+				t.Errorf("ParseSort(%q) = %+v, %v, want %+v", got, keys, err, tt.keys)
+			}
+		})
+	}
+}
+
+func TestDecodeCursor(t *testing.T) {
+	valid := EncodeCursor(&Cursor{Sort: "song:asc", Prev: true, Values: []string{"Song", "42"}})
+
+	tests := []struct {
+		name  string
+		token string
+		want  *Cursor
+	}{
+		{name: "round trip", token: valid, want: &Cursor{Sort: "song:asc", Prev: true, Values: []string{"Song", "42"}}},
+		{name: "not base64", token: "!!!"},
+		{name: "padded base64", token: valid + "="},
+		{name: "not json", token: "bm90IGpzb24"},
+		{name: "no values", token: EncodeCursor(&Cursor{Sort: "song:asc"})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeCursor(tt.token)
+			if tt.want == nil {
+				if !errors.Is(err, ErrInvalidCursor) {
+					t.Errorf("DecodeCursor(%q) = %+v, %v, want ErrInvalidCursor", tt.token, got, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("DecodeCursor(%q) error = %v", tt.token, err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DecodeCursor(%q) = %+v, want %+v", tt.token, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSongsFilterDecodeCursor(t *testing.T) {
+	cursor := func(sort string, values ...string) string {
+		return EncodeCursor(&Cursor{Sort: sort, Values: values})
+	}
+
+	tests := []struct {
+		name    string
+		filter  SongsFilter
+		wantErr bool
+	}{
+		{name: "no cursor", filter: SongsFilter{}},
+		{name: "id only", filter: SongsFilter{Cursor: cursor("", "7")}},
+		{name: "song", filter: SongsFilter{Sort: "song", Cursor: cursor("song:asc", "Yesterday", "7")}},
+		{name: "group", filter: SongsFilter{Sort: "group:desc", Cursor: cursor("group:desc", "Кино", "7")}},
+		{name: "release date", filter: SongsFilter{Sort: "release_date", Cursor: cursor("release_date:asc", "1965-08-06", "7")}},
+		{name: "unknown release date", filter: SongsFilter{Sort: "release_date", Cursor: cursor("release_date:asc", "-infinity", "7")}},
+		{name: "created at", filter: SongsFilter{Sort: "created_at", Cursor: cursor("created_at:asc", "2024-05-01 12:34:56.123456+00", "7")}},
+		{name: "created at with minutes offset", filter: SongsFilter{Sort: "created_at", Cursor: cursor("created_at:asc", "2024-05-01 12:34:56+05:30", "7")}},
+		{name: "relevance by default with lyrics", filter: SongsFilter{Lyrics: "love", Cursor: cursor("relevance:desc", "0.0607927", "7")}},
+		{name: "other sort", filter: SongsFilter{Sort: "song", Cursor: cursor("group:asc", "Yesterday", "7")}, wantErr: true},
+		{name: "missing value", filter: SongsFilter{Sort: "song", Cursor: cursor("song:asc", "7")}, wantErr: true},
+		{name: "extra value", filter: SongsFilter{Cursor: cursor("", "7", "8")}, wantErr: true},
+		{name: "id not a number", filter: SongsFilter{Cursor: cursor("", "seven")}, wantErr: true},
+		{name: "id out of range", filter: SongsFilter{Cursor: cursor("", "4294967296")}, wantErr: true},
+		{name: "song with nul", filter: SongsFilter{Sort: "song", Cursor: cursor("song:asc", "a\x00b", "7")}, wantErr: true},
+		{name: "bad release date", filter: SongsFilter{Sort: "release_date", Cursor: cursor("release_date:asc", "1965-13-01", "7")}, wantErr: true},
+		{name: "bad created at", filter: SongsFilter{Sort: "created_at", Cursor: cursor("created_at:asc", "yesterday", "7")}, wantErr: true},
+		{name: "hex relevance", filter: SongsFilter{Lyrics: "love", Cursor: cursor("relevance:desc", "0x1p-2", "7")}, wantErr: true},
+		{name: "relevance out of range", filter: SongsFilter{Lyrics: "love", Cursor: cursor("relevance:desc", "1e40", "7")}, wantErr: true},
+		{name: "not a cursor", filter: SongsFilter{Cursor: "garbage"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.filter.DecodeCursor()
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidCursor) {
+					t.Errorf("DecodeCursor() = %+v, %v, want ErrInvalidCursor", got, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("DecodeCursor() error = %v", err)
+			}
+
+			if (got == nil) != (tt.filter.Cursor == "") {
+				t.Errorf("DecodeCursor() = %+v for cursor %q", got, tt.filter.Cursor)
+			}
+		})
+	}
+}
@@ -0,0 +1,13 @@
+package models
+
+// LibraryStats is the size of the library and of the enrichment backlog.
+type LibraryStats struct {
+	Songs     int
+	Artists   int
+	Albums    int
+	Playlists int
+	// Queued jobs wait for a worker, running ones are held by one, and failed ones wait to be requeued.
+	EnrichmentQueued  int
+	EnrichmentRunning int
+	EnrichmentFailed  int
+}
@@ -0,0 +1,59 @@
+package models
+
+import "errors"
+
+var (
+	ErrInvalidOffset       = errors.New("at must be a non-negative number of seconds")
+	ErrInvalidExportFormat = errors.New("format must be one of: lrc, vtt")
+)
+
+const (
+	ExportFormatLRC = "lrc"
+	ExportFormatVTT = "vtt"
+)
+
+type SyncedLine struct {
+	TimeMs int    `json:"time_ms"`
+	Text   string `json:"text"`
+}
+
+// SyncedLyrics are time-synced lyrics in LRC terms: metadata tags, a global offset and timestamped lines sorted by time.
+type SyncedLyrics struct {
+	SongID   int               `json:"song_id"`
+	Tags     map[string]string `json:"tags,omitempty"`
+	OffsetMs int               `json:"offset_ms"`
+	Lines    []SyncedLine      `json:"lines"`
+}
+
+// ActiveLine is the line shown at a playback position, nil before the first line starts.
+type ActiveLine struct {
+	SongID int         `json:"song_id"`
+	AtMs   int         `json:"at_ms"`
+	Index  int         `json:"index"`
+	Line   *SyncedLine `json:"line"`
+	Next   *SyncedLine `json:"next,omitempty"`
+}
+
+type GetActiveLine struct {
+	SongID int
+	AtMs   int
+}
+
+type ExportSyncedLyrics struct {
+	SongID int
+	Format string
+}
+
+func (e *ExportSyncedLyrics) Validate() error {
+	if e.SongID <= 0 {
+		return ErrInvalidSongID
+	}
+
+	switch e.Format {
+	case ExportFormatLRC, ExportFormatVTT:
+	default:
+		return ErrInvalidExportFormat
+	}
+
+	return nil
+}
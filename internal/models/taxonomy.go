@@ -0,0 +1,178 @@
+package models
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	ErrInvalidGenreID      = errors.New("invalid genre_id parameter")
+	ErrInvalidTagID        = errors.New("invalid tag_id parameter")
+	ErrSongIDsIsRequired   = errors.New("song_ids is required")
+	ErrTagsOrGenresMissing = errors.New("tags or genre_ids is required")
+	ErrInvalidTagName      = errors.New("tags must not be empty")
+)
+
+// Genre is a node of the genre tree. Path names the genre with its ancestors, e.g. "Rock > Alternative Rock".
+// SongsCount counts the songs given this genre, not its subgenres.
+type Genre struct {
+	ID         int    `json:"id"`
+	Name       string `json:"name"`
+	ParentID   *int   `json:"parent_id"`
+	Path       string `json:"path"`
+	SongsCount int    `json:"songs_count"`
+}
+
+type Genres []Genre
+
+type CreateGenre struct {
+	Name     string `json:"name"`
+	ParentID *int   `json:"parent_id"`
+}
+
+func (c *CreateGenre) Validate() error {
+	c.Name = CleanArtistName(c.Name)
+	if c.Name == "" {
+		return ErrNameIsRequired
+	}
+
+	if c.ParentID != nil && *c.ParentID <= 0 {
+		return ErrInvalidGenreID
+	}
+
+	return nil
+}
+
+// UpdateGenre renames the genre and moves it under the parent, or to the root without one.
+type UpdateGenre struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	ParentID *int   `json:"parent_id"`
+}
+
+func (u *UpdateGenre) Validate() error {
+	if u.ID <= 0 {
+		return ErrInvalidGenreID
+	}
+
+	create := CreateGenre{Name: u.Name, ParentID: u.ParentID}
+	if err := create.Validate(); err != nil {
+		return err
+	}
+
+	u.Name = create.Name
+
+	return nil
+}
+
+type Tag struct {
+	ID         int    `json:"id"`
+	Name       string `json:"name"`
+	SongsCount int    `json:"songs_count"`
+}
+
+type Tags []Tag
+
+type CreateTag struct {
+	Name string `json:"name"`
+}
+
+func (c *CreateTag) Validate() error {
+	if CleanArtistName(c.Name) == "" {
+		return ErrNameIsRequired
+	}
+
+	return nil
+}
+
+type UpdateTag struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func (u *UpdateTag) Validate() error {
+	if u.ID <= 0 {
+		return ErrInvalidTagID
+	}
+
+	if CleanArtistName(u.Name) == "" {
+		return ErrNameIsRequired
+	}
+
+	return nil
+}
+
+type TagsFilter struct {
+	Name  string `json:"name"`
+	Page  int    `json:"page"`
+	Limit int    `json:"limit"`
+}
+
+// TagSongs adds tags and genres to songs, or removes them. Tags are matched by name the way artists are,
+// and the ones not seen before are created when tagging.
+type TagSongs struct {
+	SongIDs  []int    `json:"song_ids"`
+	Tags     []string `json:"tags"`
+	GenreIDs []int    `json:"genre_ids"`
+}
+
+func (t *TagSongs) Validate() error {
+	if len(t.SongIDs) == 0 {
+		return ErrSongIDsIsRequired
+	}
+
+	for _, id := range t.SongIDs {
+		if id <= 0 {
+			return ErrInvalidSongID
+		}
+	}
+
+	if len(t.Tags) == 0 && len(t.GenreIDs) == 0 {
+		return ErrTagsOrGenresMissing
+	}
+
+	for _, tag := range t.Tags {
+		if CleanArtistName(tag) == "" {
+			return ErrInvalidTagName
+		}
+	}
+
+	for _, id := range t.GenreIDs {
+		if id <= 0 {
+			return ErrInvalidGenreID
+		}
+	}
+
+	return nil
+}
+
+// Tagged counts the song tags and genres added or removed.
+type Tagged struct {
+	Tags   int `json:"tags"`
+	Genres int `json:"genres"`
+}
+
+// SongTags is the classification of a song.
+type SongTags struct {
+	SongID int    `json:"song_id"`
+	Genres Genres `json:"genres"`
+	Tags   Tags   `json:"tags"`
+}
+
+// NormalizeTagNames returns the keys of the tag names, without repeats.
+func NormalizeTagNames(names []string) []string {
+	keys := make([]string, 0, len(names))
+	seen := make(map[string]struct{}, len(names))
+
+	for _, name := range names {
+		key := strings.ToLower(CleanArtistName(name))
+		if _, ok := seen[key]; ok || key == "" {
+			continue
+		}
+
+		seen[key] = struct{}{}
+		keys = append(keys, key)
+	}
+
+	return keys
+}
@@ -0,0 +1,10 @@
+package internal
+
+import (
+	"context"
+	"songs-library/internal/models"
+)
+
+type SongDetailProvider interface {
+	GetSongDetail(ctx context.Context, song, group string) (*models.SongDetail, error)
+}
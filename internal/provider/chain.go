@@ -0,0 +1,92 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"songs-library/internal"
+	"songs-library/internal/models"
+	"songs-library/pkg/logger/sl"
+)
+
+// Chain asks the providers in order. Each provider only fills in the fields the earlier ones left empty,
+// and the chain stops as soon as every field is set.
+type Chain struct {
+	log       *slog.Logger
+	names     []string
+	providers []internal.SongDetailProvider
+}
+
+func NewChain(log *slog.Logger, names []string, providers ...internal.SongDetailProvider) *Chain {
+	return &Chain{
+		log:       log.With(slog.String("component", "provider/chain")),
+		names:     names,
+		providers: providers,
+	}
+}
+
+// GetSongDetail returns the combined details, or ErrNotFound if no provider knows the song. When a provider
+// failed and the details aren't complete, it returns the joined provider errors, so the job is retried,
+// together with whatever the others answered, for the last attempt to keep.
+func (c *Chain) GetSongDetail(ctx context.Context, song, group string) (*models.SongDetail, error) {
+	var (
+		detail   models.SongDetail
+		answered bool
+		errs     []error
+	)
+
+	for i, p := range c.providers {
+		d, err := p.GetSongDetail(ctx, song, group)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			c.log.Warn("song detail provider failed", slog.String("provider", c.names[i]), sl.Err(err))
+			errs = append(errs, fmt.Errorf("%s: %w", c.names[i], err))
+			continue
+		}
+
+		answered = true
+		fillEmpty(&detail, d)
+
+		if complete(&detail) {
+			break
+		}
+	}
+
+	if len(errs) != 0 && !complete(&detail) {
+		if answered {
+			return &detail, errors.Join(errs...)
+		}
+		return nil, errors.Join(errs...)
+	}
+
+	if answered {
+		return &detail, nil
+	}
+
+	return nil, ErrNotFound
+}
+
+func fillEmpty(dst, src *models.SongDetail) {
+	if dst.ReleaseDate == "" {
+		dst.ReleaseDate = src.ReleaseDate
+	}
+
+	if dst.Text == "" {
+		dst.Text = src.Text
+	}
+
+	if dst.Link == "" {
+		dst.Link = src.Link
+	}
+
+	if dst.Album == nil {
+		dst.Album = src.Album
+	}
+}
+
+func complete(d *models.SongDetail) bool {
+	return d.ReleaseDate != "" && d.Text != "" && d.Link != "" && d.Album != nil
+}
@@ -0,0 +1,100 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"reflect"
+	"songs-library/internal"
+	"songs-library/internal/models"
+	"testing"
+)
+
+type providerFunc func() (*models.SongDetail, error)
+
+func (f providerFunc) GetSongDetail(context.Context, string, string) (*models.SongDetail, error) {
+	return f()
+}
+
+func answer(d models.SongDetail) providerFunc {
+	return func() (*models.SongDetail, error) { return &d, nil }
+}
+
+func fail(err error) providerFunc {
+	return func() (*models.SongDetail, error) { return nil, err }
+}
+
+func TestChainGetSongDetail(t *testing.T) {
+	album := &models.SongAlbum{Title: "Help!", ReleaseDate: "1965-08-06", TrackNumber: 13}
+	errDown := errors.New("provider is down")
+
+	tests := []struct {
+		name      string
+		providers []providerFunc
+		want      *models.SongDetail
+		wantErr   error
+	}{
+		{
+			name:      "album from a later provider",
+			providers: []providerFunc{answer(models.SongDetail{ReleaseDate: "1965-08-06", Text: "text", Link: "link"}), answer(models.SongDetail{Album: album})},
+			want:      &models.SongDetail{ReleaseDate: "1965-08-06", Text: "text", Link: "link", Album: album},
+		},
+		{
+			name:      "earlier album is kept",
+			providers: []providerFunc{answer(models.SongDetail{Album: album}), answer(models.SongDetail{Text: "text", Album: &models.SongAlbum{Title: "Other"}})},
+			want:      &models.SongDetail{Text: "text", Album: album},
+		},
+		{
+			name: "stops once complete",
+			providers: []providerFunc{
+				answer(models.SongDetail{ReleaseDate: "1965", Text: "text", Link: "link", Album: album}),
+				fail(errDown),
+			},
+			want: &models.SongDetail{ReleaseDate: "1965", Text: "text", Link: "link", Album: album},
+		},
+		{
+			name:      "fields filled from providers in order",
+			providers: []providerFunc{fail(ErrNotFound), answer(models.SongDetail{Text: "first"}), answer(models.SongDetail{Text: "second", Link: "link"})},
+			want:      &models.SongDetail{Text: "first", Link: "link"},
+		},
+		{
+			name:      "no provider knows the song",
+			providers: []providerFunc{fail(ErrNotFound), fail(ErrNotFound)},
+			wantErr:   ErrNotFound,
+		},
+		{
+			name:      "failure with nothing found",
+			providers: []providerFunc{fail(errDown), fail(ErrNotFound)},
+			wantErr:   errDown,
+		},
+		{
+			name:      "failure keeps partial details",
+			providers: []providerFunc{answer(models.SongDetail{Text: "text"}), fail(errDown)},
+			want:      &models.SongDetail{Text: "text"},
+			wantErr:   errDown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			names := make([]string, len(tt.providers))
+			providers := make([]internal.SongDetailProvider, len(tt.providers))
+			for i, p := range tt.providers {
+				names[i] = "test"
+				providers[i] = p
+			}
+
+			chain := NewChain(slog.New(slog.NewTextHandler(io.Discard, nil)), names, providers...)
+
+			got, err := chain.GetSongDetail(context.Background(), "Yesterday", "The Beatles")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetSongDetail() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetSongDetail() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
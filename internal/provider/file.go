@@ -0,0 +1,141 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"songs-library/internal/models"
+	"strings"
+)
+
+// FileProvider reads song details from disk. The path is either a JSON catalogue file
+// or a directory laid out as <group>/<song>.txt with lyrics and an optional <group>/<song>.json
+// in the info API shape. Group and song names are matched the same way artists are deduplicated.
+type FileProvider struct {
+	dir       string
+	catalogue map[string]models.SongDetail
+}
+
+type catalogueEntry struct {
+	Group string `json:"group"`
+	Song  string `json:"song"`
+	models.SongDetail
+}
+
+func NewFileProvider(path string) (*FileProvider, error) {
+	if path == "" {
+		return nil, errors.New("lyrics path is not set")
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.IsDir() {
+		return &FileProvider{dir: path}, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []catalogueEntry
+	if err = json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal catalogue: %w", err)
+	}
+
+	catalogue := make(map[string]models.SongDetail, len(entries))
+	for _, e := range entries {
+		catalogue[catalogueKey(e.Song, e.Group)] = e.SongDetail
+	}
+
+	return &FileProvider{catalogue: catalogue}, nil
+}
+
+func (p *FileProvider) GetSongDetail(ctx context.Context, song, group string) (*models.SongDetail, error) {
+	if p.catalogue != nil {
+		detail, ok := p.catalogue[catalogueKey(song, group)]
+		if !ok {
+			return nil, ErrNotFound
+		}
+
+		return &detail, nil
+	}
+
+	groupDir, err := findEntry(p.dir, group, func(e os.DirEntry) bool { return e.IsDir() })
+	if err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Join(p.dir, groupDir)
+	var (
+		detail models.SongDetail
+		found  bool
+	)
+
+	name, err := findEntry(dir, song+".json", isFile)
+	if err == nil {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		if err = json.Unmarshal(data, &detail); err != nil {
+			return nil, fmt.Errorf("cannot unmarshal %s: %w", name, err)
+		}
+		found = true
+	} else if !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+
+	name, err = findEntry(dir, song+".txt", isFile)
+	if err == nil {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		if detail.Text == "" {
+			detail.Text = strings.TrimSpace(strings.ReplaceAll(string(data), "\r\n", "\n"))
+		}
+		found = true
+	} else if !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+
+	if !found {
+		return nil, ErrNotFound
+	}
+
+	return &detail, nil
+}
+
+// findEntry returns the name of the entry in dir that matches name regardless of case and extra whitespace.
+func findEntry(dir, name string, accept func(os.DirEntry) bool) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+
+	want := models.NormalizeArtistName(name)
+	for _, e := range entries {
+		if accept(e) && models.NormalizeArtistName(e.Name()) == want {
+			return e.Name(), nil
+		}
+	}
+
+	return "", ErrNotFound
+}
+
+func isFile(e os.DirEntry) bool {
+	return e.Type().IsRegular()
+}
+
+func catalogueKey(song, group string) string {
+	return models.NormalizeArtistName(group) + "\x00" + models.NormalizeArtistName(song)
+}
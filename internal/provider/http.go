@@ -0,0 +1,155 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"songs-library/internal/models"
+	"songs-library/pkg/tracing"
+	"time"
+)
+
+// Outcomes of the requests to the songs info API, as counted in the metrics.
+const (
+	outcomeOK              = "ok"
+	outcomeNotFound        = "not_found"
+	outcomeTimeout         = "timeout"
+	outcomeUnavailable     = "unavailable"
+	outcomeBadStatus       = "bad_status"
+	outcomeInvalidResponse = "invalid_response"
+)
+
+// HTTPProvider requests song details from the songs info API.
+type HTTPProvider struct {
+	client          *http.Client
+	songsInfoAPIURL string
+	requests        *prometheus.CounterVec
+	duration        *prometheus.HistogramVec
+}
+
+// NewHTTPProvider creates the provider; with a registry, its requests are counted and timed by outcome.
+func NewHTTPProvider(songsInfoAPIURL string, timeout time.Duration, reg prometheus.Registerer) *HTTPProvider {
+	factory := promauto.With(reg)
+
+	return &HTTPProvider{
+		client:          &http.Client{Timeout: timeout},
+		songsInfoAPIURL: songsInfoAPIURL,
+		requests: factory.NewCounterVec(prometheus.CounterOpts{
+			Name: "songs_info_api_requests_total",
+			Help: "Requests to the songs info API, by outcome.",
+		}, []string{"outcome"}),
+		duration: factory.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "songs_info_api_request_duration_seconds",
+			Help:    "Time of the requests to the songs info API, by outcome.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"outcome"}),
+	}
+}
+
+func (p *HTTPProvider) GetSongDetail(ctx context.Context, song, group string) (*models.SongDetail, error) {
+	ctx, span := tracing.Start(ctx, "GET /info", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+
+	t1 := time.Now()
+
+	songDetail, outcome, err := p.getSongDetail(ctx, song, group)
+
+	p.requests.WithLabelValues(outcome).Inc()
+	p.duration.WithLabelValues(outcome).Observe(time.Since(t1).Seconds())
+
+	span.SetAttributes(
+		attribute.String("server.address", p.songsInfoAPIURL),
+		attribute.String("outcome", outcome),
+	)
+	if outcome != outcomeOK && outcome != outcomeNotFound {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	return songDetail, err
+}
+
+func (p *HTTPProvider) getSongDetail(ctx context.Context, song, group string) (*models.SongDetail, string, error) {
+	params := url.Values{}
+	params.Add("group", group)
+	params.Add("song", song)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.songsInfoAPIURL+"/info"+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, outcomeUnavailable, fmt.Errorf("cannot create request: %w", err)
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, transportOutcome(err), fmt.Errorf("cannot get song detail: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, outcomeNotFound, ErrNotFound
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, outcomeBadStatus, fmt.Errorf("request failed: %v", resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, transportOutcome(err), fmt.Errorf("cannot get response body: %w", err)
+	}
+
+	var songDetail models.SongDetail
+	if err = json.Unmarshal(body, &songDetail); err != nil {
+		return nil, outcomeInvalidResponse, fmt.Errorf("cannot unmarshal song detail: %w", err)
+	}
+
+	return &songDetail, outcomeOK, nil
+}
+
+// transportOutcome tells a request that ran out of time from one that failed otherwise.
+func transportOutcome(err error) string {
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) && netErr.Timeout() {
+		return outcomeTimeout
+	}
+
+	return outcomeUnavailable
+}
+
+// CheckSongsInfoAPI returns a check that the songs info API answers. Any response but a server error will do:
+// the API has no health endpoint of its own.
+func CheckSongsInfoAPI(songsInfoAPIURL string) func(ctx context.Context) error {
+	client := &http.Client{}
+
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, songsInfoAPIURL, nil)
+		if err != nil {
+			return fmt.Errorf("cannot create request: %w", err)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return fmt.Errorf("songs info api is unreachable: %w", err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode >= http.StatusInternalServerError {
+			return fmt.Errorf("songs info api responded %v", resp.Status)
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,18 @@
+package provider
+
+import (
+	"context"
+	"songs-library/internal/models"
+)
+
+// NoopProvider never finds any details. It turns enrichment off while keeping songs creatable:
+// the jobs fail at once with ErrNotFound instead of completing without data.
+type NoopProvider struct{}
+
+func NewNoopProvider() *NoopProvider {
+	return &NoopProvider{}
+}
+
+func (p *NoopProvider) GetSongDetail(context.Context, string, string) (*models.SongDetail, error) {
+	return nil, ErrNotFound
+}
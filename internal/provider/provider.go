@@ -0,0 +1,58 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"github.com/prometheus/client_golang/prometheus"
+	"log/slog"
+	"songs-library/internal"
+	"time"
+)
+
+const (
+	HTTP = "http"
+	File = "file"
+	Noop = "noop"
+)
+
+// ErrNotFound is returned by a provider that has no data for the song. Unlike other errors it is not worth retrying.
+var ErrNotFound = errors.New("song detail not found")
+
+type Options struct {
+	SongsInfoAPIURL string
+	RequestTimeout  time.Duration
+	LyricsPath      string
+	// Metrics, if set, gets the timings and outcomes of the songs info API requests.
+	Metrics prometheus.Registerer
+}
+
+// Build creates the providers by name and combines them into a fallback chain in the given order.
+func Build(log *slog.Logger, names []string, opts Options) (internal.SongDetailProvider, error) {
+	providers := make([]internal.SongDetailProvider, 0, len(names))
+
+	for _, name := range names {
+		switch name {
+		case HTTP:
+			if opts.SongsInfoAPIURL == "" {
+				return nil, fmt.Errorf("provider %q: songs info api url is not set", name)
+			}
+			providers = append(providers, NewHTTPProvider(opts.SongsInfoAPIURL, opts.RequestTimeout, opts.Metrics))
+		case File:
+			p, err := NewFileProvider(opts.LyricsPath)
+			if err != nil {
+				return nil, fmt.Errorf("provider %q: %w", name, err)
+			}
+			providers = append(providers, p)
+		case Noop:
+			providers = append(providers, NewNoopProvider())
+		default:
+			return nil, fmt.Errorf("unknown song detail provider %q", name)
+		}
+	}
+
+	if len(providers) == 1 {
+		return providers[0], nil
+	}
+
+	return NewChain(log, names, providers...), nil
+}
@@ -0,0 +1,101 @@
+// Package releasedate parses song release dates, which are often known only to the month or the year.
+package releasedate
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+const (
+	PrecisionDay   = "day"
+	PrecisionMonth = "month"
+	PrecisionYear  = "year"
+)
+
+var ErrInvalid = errors.New("unknown release date format")
+
+// Date is the first day of the period a release date is known to.
+type Date struct {
+	Time      time.Time
+	Precision string
+}
+
+type layout struct {
+	layout    string
+	precision string
+}
+
+// layouts are the formats seen from users and song info APIs, e.g. "16.07.2006". Single digit days and months
+// are accepted by the same layouts.
+var layouts = []layout{
+	{"2006-01-02", PrecisionDay},
+	{"2.1.2006", PrecisionDay},
+	{"2/1/2006", PrecisionDay},
+	{"January 2, 2006", PrecisionDay},
+	{"Jan 2, 2006", PrecisionDay},
+	{"2 January 2006", PrecisionDay},
+	{"2 Jan 2006", PrecisionDay},
+	{time.RFC3339, PrecisionDay},
+	{"2006-01", PrecisionMonth},
+	{"1.2006", PrecisionMonth},
+	{"1/2006", PrecisionMonth},
+	{"January 2006", PrecisionMonth},
+	{"Jan 2006", PrecisionMonth},
+	{"2006", PrecisionYear},
+}
+
+func Parse(s string) (Date, error) {
+	s = strings.TrimSpace(s)
+
+	for _, l := range layouts {
+		t, err := time.Parse(l.layout, s)
+		if err != nil {
+			continue
+		}
+
+		y, m, d := t.Date()
+
+		return Date{Time: time.Date(y, m, d, 0, 0, 0, 0, time.UTC), Precision: l.precision}, nil
+	}
+
+	return Date{}, ErrInvalid
+}
+
+// String formats the date as ISO 8601 to its precision: "2006-07-16", "2006-07" or "2006".
+func (d Date) String() string {
+	switch d.Precision {
+	case PrecisionYear:
+		return d.Time.Format("2006")
+	case PrecisionMonth:
+		return d.Time.Format("2006-01")
+	default:
+		return d.Time.Format("2006-01-02")
+	}
+}
+
+// End returns the last day of the period.
+func (d Date) End() time.Time {
+	switch d.Precision {
+	case PrecisionYear:
+		return d.Time.AddDate(1, 0, -1)
+	case PrecisionMonth:
+		return d.Time.AddDate(0, 1, -1)
+	default:
+		return d.Time
+	}
+}
+
+// Normalize returns s in the String format, or an empty string for an empty s.
+func Normalize(s string) (string, error) {
+	if strings.TrimSpace(s) == "" {
+		return "", nil
+	}
+
+	d, err := Parse(s)
+	if err != nil {
+		return "", err
+	}
+
+	return d.String(), nil
+}
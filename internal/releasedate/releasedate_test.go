@@ -0,0 +1,67 @@
+package releasedate
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "", want: ""},
+		{in: "   ", want: ""},
+		{in: "2006-07-16", want: "2006-07-16"},
+		{in: " 2006-07-16 ", want: "2006-07-16"},
+		{in: "16.07.2006", want: "2006-07-16"},
+		{in: "6.7.2006", want: "2006-07-06"},
+		{in: "16/07/2006", want: "2006-07-16"},
+		{in: "July 16, 2006", want: "2006-07-16"},
+		{in: "Jul 16, 2006", want: "2006-07-16"},
+		{in: "16 July 2006", want: "2006-07-16"},
+		{in: "16 Jul 2006", want: "2006-07-16"},
+		{in: "2006-07-16T10:00:00Z", want: "2006-07-16"},
+		{in: "2006-07-16T23:30:00-05:00", want: "2006-07-16"},
+		{in: "2006-07", want: "2006-07"},
+		{in: "7.2006", want: "2006-07"},
+		{in: "07/2006", want: "2006-07"},
+		{in: "July 2006", want: "2006-07"},
+		{in: "Jul 2006", want: "2006-07"},
+		{in: "2006", want: "2006"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := Normalize(tt.in)
+			if err != nil {
+				t.Fatalf("Normalize(%q) error = %v", tt.in, err)
+			}
+
+			if got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeInvalid(t *testing.T) {
+	tests := []string{
+		"yesterday",
+		"06",
+		"2006-13",
+		"2006-02-30",
+		"32.01.2006",
+		"16-07-2006",
+		"2006/07/16",
+	}
+
+	for _, in := range tests {
+		t.Run(in, func(t *testing.T) {
+			got, err := Normalize(in)
+			if !errors.Is(err, ErrInvalid) {
+				t.Errorf("Normalize(%q) = %q, %v, want ErrInvalid", in, got, err)
+			}
+		})
+	}
+}
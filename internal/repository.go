@@ -1,13 +1,87 @@
 package internal
 
 import (
+	"context"
+	"database/sql"
 	"songs-library/internal/models"
+	"time"
 )
 
 type Repository interface {
-	CreateSong(*models.Song) (int, error)
-	UpdateSong(song *models.UpdateSong) error
-	DeleteSong(int) error
-	ListSongs(*models.SongsFilter) (models.Songs, error)
-	GetTextBySongID(int) (string, error)
+	// WithTx runs fn in a transaction, with every method of the repository fn gets running in it.
+	WithTx(ctx context.Context, opts *sql.TxOptions, fn func(repo Repository) error) error
+
+	CreateSong(ctx context.Context, song *models.Song, unique bool) (int, error)
+	UpdateSong(ctx context.Context, song *models.UpdateSong) error
+	DeleteSong(context.Context, int) error
+	ListSongs(context.Context, *models.SongsFilter) (*models.SongsPage, error)
+	GetSong(context.Context, int) (*models.Song, error)
+	ImportSongs(ctx context.Context, songs []models.ImportSong, enrich bool) ([]models.ImportRow, error)
+	ExportSongs(ctx context.Context, filter *models.SongsFilter, withText bool, fn func(*models.SongWithText) error) error
+	GetTextBySongID(context.Context, int) (string, error)
+	ListSections(ctx context.Context, songID int) (models.Sections, error)
+	SaveSections(ctx context.Context, songID int, text string, sections models.Sections) error
+	SaveSyncedLyrics(ctx context.Context, lyrics *models.SyncedLyrics, text string) error
+	GetSyncedLyrics(ctx context.Context, songID int) (*models.SyncedLyrics, error)
+	ListRevisions(ctx context.Context, songID int) (models.Revisions, error)
+	GetRevision(ctx context.Context, songID, revision int) (*models.Revision, error)
+	RestoreRevision(ctx context.Context, songID, revision int) (*models.Revision, error)
+	ListDuplicateClusters(context.Context, *models.DuplicatesFilter) (models.DuplicateClusters, error)
+	MergeSongs(ctx context.Context, targetID int, sourceIDs []int) error
+
+	ResolveArtist(ctx context.Context, name string) (int, error)
+	CreateArtist(context.Context, *models.Artist) (int, error)
+	GetArtist(context.Context, int) (*models.Artist, error)
+	ListArtists(context.Context, *models.ArtistsFilter) (models.Artists, error)
+	UpdateArtist(context.Context, *models.UpdateArtist) error
+	MergeArtists(ctx context.Context, targetID int, sourceIDs []int) error
+
+	CreateAlbum(context.Context, *models.CreateAlbum) (int, error)
+	GetAlbum(context.Context, int) (*models.AlbumWithTracks, error)
+	ListAlbums(context.Context, *models.AlbumsFilter) (models.Albums, error)
+	UpdateAlbum(context.Context, *models.UpdateAlbum) error
+	DeleteAlbum(context.Context, int) error
+
+	CreateGenre(context.Context, *models.CreateGenre) (int, error)
+	GetGenre(context.Context, int) (*models.Genre, error)
+	ListGenres(context.Context) (models.Genres, error)
+	UpdateGenre(context.Context, *models.UpdateGenre) error
+	DeleteGenre(context.Context, int) error
+	ListSongGenres(ctx context.Context, songID int) (models.Genres, error)
+
+	CreateTag(context.Context, *models.Tag) (int, error)
+	GetTag(context.Context, int) (*models.Tag, error)
+	ListTags(context.Context, *models.TagsFilter) (models.Tags, error)
+	UpdateTag(context.Context, *models.UpdateTag) error
+	DeleteTag(context.Context, int) error
+	ListSongTags(ctx context.Context, songID int) (models.Tags, error)
+	TagSongs(context.Context, *models.TagSongs) (*models.Tagged, error)
+	UntagSongs(context.Context, *models.TagSongs) (*models.Tagged, error)
+
+	CreatePlaylist(context.Context, *models.CreatePlaylist) (int, error)
+	GetPlaylist(context.Context, int) (*models.PlaylistWithEntries, error)
+	ListPlaylists(context.Context, *models.PlaylistsFilter) (models.Playlists, error)
+	UpdatePlaylist(context.Context, *models.UpdatePlaylist) error
+	DeletePlaylist(context.Context, int) error
+	DuplicatePlaylist(ctx context.Context, id int, name string) (int, error)
+	AddPlaylistEntries(ctx context.Context, playlistID int, in *models.AddPlaylistEntries) error
+	MovePlaylistEntry(ctx context.Context, playlistID, entryID, position int) error
+	RemovePlaylistEntry(ctx context.Context, playlistID, entryID int) error
+
+	CreateAPIKey(ctx context.Context, in *models.IssueAPIKey, prefix string, hash []byte, createdBy string) (*models.APIKey, error)
+	ListAPIKeys(context.Context) (models.APIKeys, error)
+	RotateAPIKey(ctx context.Context, id int, prefix string, hash []byte) (*models.APIKey, error)
+	RevokeAPIKey(context.Context, int) error
+	FindAPIKey(ctx context.Context, hash []byte) (*models.APIKey, error)
+
+	EnqueueEnrichment(ctx context.Context, songID int) error
+	ClaimEnrichmentJob(ctx context.Context, lease time.Duration) (*models.EnrichmentJob, error)
+	CompleteEnrichment(context.Context, *models.EnrichmentJob, *models.SongDetail) error
+	RetryEnrichmentJob(ctx context.Context, job *models.EnrichmentJob, runAt time.Time, lastErr string) error
+	ReleaseEnrichmentJob(ctx context.Context, job *models.EnrichmentJob) error
+	FailEnrichment(ctx context.Context, job *models.EnrichmentJob, lastErr string) error
+	GetEnrichment(ctx context.Context, songID int) (*models.Enrichment, error)
+	RequeueEnrichment(ctx context.Context, songIDs []int) (int, error)
+
+	LibraryStats(context.Context) (*models.LibraryStats, error)
 }
@@ -0,0 +1,369 @@
+package respository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"songs-library/internal/consts"
+	"songs-library/internal/converter"
+	"songs-library/internal/models"
+	"songs-library/pkg/tracing"
+	"time"
+)
+
+var ErrAlbumNotFound = errors.New("album not found")
+
+const foreignKeyViolationCode = "23503"
+
+// albumLockClass is the first key of the advisory locks that serialize album lookups per artist.
+const albumLockClass = 2
+
+func (r *Repository) CreateAlbum(ctx context.Context, album *models.CreateAlbum) (int, error) {
+	const op = "repository.CreateAlbum"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	releaseDate, precision, err := releaseDateValues(album.ReleaseDate)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var id int
+	err = r.inTx(ctx, func(tx *sqlx.Tx) error {
+		artistID, err := resolveArtist(ctx, tx, album.Group)
+		if err != nil {
+			return err
+		}
+
+		err = squirrel.Insert(consts.AlbumsTableName).
+			PlaceholderFormat(squirrel.Dollar).
+			Columns(
+				consts.TitleColumn,
+				consts.ArtistIDColumn,
+				consts.ReleaseDateColumn,
+				consts.ReleaseDatePrecisionColumn,
+				consts.CoverLinkColumn,
+				consts.TypeColumn,
+			).
+			Values(album.Title, artistID, releaseDate, precision, album.CoverLink, album.Type).
+			Suffix("RETURNING " + consts.IDColumn).
+			RunWith(tx).QueryRowContext(ctx).Scan(&id)
+		if err != nil {
+			return err
+		}
+
+		return insertTracks(ctx, tx, id, album.Tracks)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+func (r *Repository) GetAlbum(ctx context.Context, id int) (*models.AlbumWithTracks, error) {
+	const op = "repository.GetAlbum"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	var album models.AlbumWithTracks
+	err := selectAlbums().
+		Where(squirrel.Eq{consts.AlbumsIDColumn: id}).
+		RunWith(r.conn()).QueryRowContext(ctx).
+		Scan(albumDest(&album.Album)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAlbumNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := selectSongs().
+		Columns(consts.DiscNumberColumn, consts.TrackNumberColumn).
+		Join(consts.AlbumTracksTableName+" ON "+consts.AlbumTracksSongID+" = "+consts.SongsIDColumn).
+		Where(squirrel.Eq{consts.AlbumTracksAlbumID: id}).
+		OrderBy(consts.DiscNumberColumn, consts.TrackNumberColumn).
+		RunWith(r.conn()).QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	album.Tracks = make([]models.Track, 0, album.TracksCount)
+	for rows.Next() {
+		var track models.Track
+		if err = rows.Scan(append(songDest(&track.Song), &track.DiscNumber, &track.TrackNumber)...); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		album.Tracks = append(album.Tracks, track)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &album, nil
+}
+
+func (r *Repository) ListAlbums(ctx context.Context, filter *models.AlbumsFilter) (models.Albums, error) {
+	const op = "repository.ListAlbums"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	q := selectAlbums().
+		OrderBy(consts.AlbumsIDColumn + " ASC")
+
+	q = converter.AlbumsFilterToSqlFilters(q, filter)
+
+	rows, err := q.RunWith(r.conn()).QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	albums := make(models.Albums, 0, filter.Limit)
+	for rows.Next() {
+		var album models.Album
+		if err = rows.Scan(albumDest(&album)...); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		albums = append(albums, album)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return albums, nil
+}
+
+// UpdateAlbum replaces the album and its tracklist.
+func (r *Repository) UpdateAlbum(ctx context.Context, album *models.UpdateAlbum) error {
+	const op = "repository.UpdateAlbum"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	releaseDate, precision, err := releaseDateValues(album.ReleaseDate)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = r.inTx(ctx, func(tx *sqlx.Tx) error {
+		artistID, err := resolveArtist(ctx, tx, album.Group)
+		if err != nil {
+			return err
+		}
+
+		res, err := squirrel.Update(consts.AlbumsTableName).
+			PlaceholderFormat(squirrel.Dollar).
+			Set(consts.TitleColumn, album.Title).
+			Set(consts.ArtistIDColumn, artistID).
+			Set(consts.ReleaseDateColumn, releaseDate).
+			Set(consts.ReleaseDatePrecisionColumn, precision).
+			Set(consts.CoverLinkColumn, album.CoverLink).
+			Set(consts.TypeColumn, album.Type).
+			Where(squirrel.Eq{consts.IDColumn: album.ID}).
+			RunWith(tx).ExecContext(ctx)
+		if err != nil {
+			return err
+		}
+
+		rowsAffected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rowsAffected == 0 {
+			return ErrAlbumNotFound
+		}
+
+		_, err = squirrel.Delete(consts.AlbumTracksTableName).
+			PlaceholderFormat(squirrel.Dollar).
+			Where(squirrel.Eq{consts.AlbumIDColumn: album.ID}).
+			RunWith(tx).ExecContext(ctx)
+		if err != nil {
+			return err
+		}
+
+		return insertTracks(ctx, tx, album.ID, album.Tracks)
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// DeleteAlbum deletes the album and its tracklist. The songs stay in the library.
+func (r *Repository) DeleteAlbum(ctx context.Context, id int) error {
+	const op = "repository.DeleteAlbum"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	res, err := squirrel.Delete(consts.AlbumsTableName).
+		PlaceholderFormat(squirrel.Dollar).
+		Where(squirrel.Eq{consts.IDColumn: id}).
+		RunWith(r.conn()).ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrAlbumNotFound
+	}
+
+	return nil
+}
+
+// insertTracks adds numbered tracks to the album. A song that doesn't exist is ErrSongNotFound.
+func insertTracks(ctx context.Context, tx *sqlx.Tx, albumID int, tracks []models.AlbumTrack) error {
+	if len(tracks) == 0 {
+		return nil
+	}
+
+	q := squirrel.Insert(consts.AlbumTracksTableName).
+		PlaceholderFormat(squirrel.Dollar).
+		Columns(consts.AlbumIDColumn, consts.SongIDColumn, consts.DiscNumberColumn, consts.TrackNumberColumn)
+
+	for _, t := range tracks {
+		q = q.Values(albumID, t.SongID, t.DiscNumber, t.TrackNumber)
+	}
+
+	_, err := q.RunWith(tx).ExecContext(ctx)
+	if isForeignKeyViolation(err) {
+		return ErrSongNotFound
+	}
+
+	return err
+}
+
+// attachAlbum puts the song on the artist's album of the same normalized title, creating the album if there
+// is none. A song already on the album, or a position already taken, is left as is. A track without a number
+// goes after the last track of its disc.
+func attachAlbum(ctx context.Context, tx *sqlx.Tx, artistID, songID int, album *models.SongAlbum) error {
+	albumID, err := resolveAlbum(ctx, tx, artistID, album)
+	if err != nil {
+		return err
+	}
+
+	disc := max(album.DiscNumber, 1)
+
+	track := any(album.TrackNumber)
+	if album.TrackNumber <= 0 {
+		track = squirrel.Expr(
+			"(SELECT coalesce(max("+consts.TrackNumberColumn+"), 0) + 1 FROM "+consts.AlbumTracksTableName+
+				" WHERE "+consts.AlbumIDColumn+" = ? AND "+consts.DiscNumberColumn+" = ?)",
+			albumID, disc,
+		)
+	}
+
+	_, err = squirrel.Insert(consts.AlbumTracksTableName).
+		PlaceholderFormat(squirrel.Dollar).
+		Columns(consts.AlbumIDColumn, consts.SongIDColumn, consts.DiscNumberColumn, consts.TrackNumberColumn).
+		Values(albumID, songID, disc, track).
+		Suffix("ON CONFLICT DO NOTHING").
+		RunWith(tx).ExecContext(ctx)
+
+	return err
+}
+
+// resolveAlbum returns the id of the artist's album with the same normalized title, creating it from album
+// if there is none. Details the album lacks, such as an unknown release date, are not filled in later.
+func resolveAlbum(ctx context.Context, tx *sqlx.Tx, artistID int, album *models.SongAlbum) (int, error) {
+	_, err := tx.ExecContext(ctx, advisoryXactLockQuery, albumLockClass, artistID)
+	if err != nil {
+		return 0, err
+	}
+
+	var id int
+	err = squirrel.Select(consts.IDColumn).
+		PlaceholderFormat(squirrel.Dollar).
+		From(consts.AlbumsTableName).
+		Where(squirrel.Eq{consts.ArtistIDColumn: artistID}).
+		Where("normalize_title("+consts.TitleColumn+") = normalize_title(?)", album.Title).
+		OrderBy(consts.IDColumn).
+		Limit(1).
+		RunWith(tx).QueryRowContext(ctx).Scan(&id)
+	if !errors.Is(err, sql.ErrNoRows) {
+		return id, err
+	}
+
+	// Song info comes from outside, so a date or type this library doesn't know is dropped, not an error.
+	releaseDate, precision, err := releaseDateValues(album.ReleaseDate)
+	if err != nil {
+		releaseDate, precision, _ = releaseDateValues("")
+	}
+
+	albumType := album.Type
+	if !models.IsAlbumType(albumType) {
+		albumType = models.AlbumTypeLP
+	}
+
+	err = squirrel.Insert(consts.AlbumsTableName).
+		PlaceholderFormat(squirrel.Dollar).
+		Columns(
+			consts.TitleColumn,
+			consts.ArtistIDColumn,
+			consts.ReleaseDateColumn,
+			consts.ReleaseDatePrecisionColumn,
+			consts.CoverLinkColumn,
+			consts.TypeColumn,
+		).
+		Values(album.Title, artistID, releaseDate, precision, album.CoverLink, albumType).
+		Suffix("RETURNING " + consts.IDColumn).
+		RunWith(tx).QueryRowContext(ctx).Scan(&id)
+
+	return id, err
+}
+
+func selectAlbums() squirrel.SelectBuilder {
+	return squirrel.
+		Select(
+			consts.AlbumsIDColumn,
+			consts.AlbumsTitleColumn,
+			consts.AlbumsArtistIDColumn,
+			consts.ArtistsNameColumn,
+			consts.AlbumReleaseDateExpr,
+			consts.CoverLinkColumn,
+			consts.AlbumsTypeColumn,
+			"count("+consts.AlbumTracksSongID+") AS "+consts.TracksCountColumn,
+		).
+		PlaceholderFormat(squirrel.Dollar).
+		From(consts.AlbumsTableName).
+		Join(consts.ArtistsTableName+" ON "+consts.ArtistsIDColumn+" = "+consts.AlbumsArtistIDColumn).
+		LeftJoin(consts.AlbumTracksTableName+" ON "+consts.AlbumTracksAlbumID+" = "+consts.AlbumsIDColumn).
+		GroupBy(consts.AlbumsIDColumn, consts.ArtistsIDColumn)
+}
+
+// albumDest lists the scan destinations of the selectAlbums columns.
+func albumDest(album *models.Album) []any {
+	return []any{
+		&album.ID,
+		&album.Title,
+		&album.ArtistID,
+		&album.Group,
+		&album.ReleaseDate,
+		&album.CoverLink,
+		&album.Type,
+		&album.TracksCount,
+	}
+}
+
+func isForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == foreignKeyViolationCode
+}
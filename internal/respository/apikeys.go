@@ -0,0 +1,198 @@
+package respository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"songs-library/internal/consts"
+	"songs-library/internal/models"
+	"songs-library/pkg/tracing"
+	"strings"
+	"time"
+)
+
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+var apiKeyColumns = []string{
+	consts.IDColumn,
+	consts.NameColumn,
+	consts.RoleColumn,
+	consts.PrefixColumn,
+	consts.CreatedByColumn,
+	consts.CreatedAtColumn,
+	consts.RotatedAtColumn,
+	consts.ExpiresAtColumn,
+	consts.LastUsedAtColumn,
+	consts.RevokedAtColumn,
+}
+
+// CreateAPIKey stores a key by its prefix and hash.
+func (r *Repository) CreateAPIKey(
+	ctx context.Context,
+	in *models.IssueAPIKey,
+	prefix string,
+	hash []byte,
+	createdBy string,
+) (*models.APIKey, error) {
+	const op = "repository.CreateAPIKey"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	var key models.APIKey
+	err := squirrel.Insert(consts.APIKeysTableName).
+		PlaceholderFormat(squirrel.Dollar).
+		Columns(
+			consts.NameColumn,
+			consts.RoleColumn,
+			consts.PrefixColumn,
+			consts.KeyHashColumn,
+			consts.CreatedByColumn,
+			consts.ExpiresAtColumn,
+		).
+		Values(in.Name, in.Role, prefix, hash, createdBy, in.ExpiresAt).
+		Suffix("RETURNING " + strings.Join(apiKeyColumns, ", ")).
+		RunWith(r.conn()).QueryRowContext(ctx).Scan(apiKeyDest(&key)...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &key, nil
+}
+
+// ListAPIKeys returns all keys, revoked and expired ones included, newest first.
+func (r *Repository) ListAPIKeys(ctx context.Context) (models.APIKeys, error) {
+	const op = "repository.ListAPIKeys"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	rows, err := squirrel.Select(apiKeyColumns...).
+		PlaceholderFormat(squirrel.Dollar).
+		From(consts.APIKeysTableName).
+		OrderBy(consts.IDColumn + " DESC").
+		RunWith(r.conn()).QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	keys := make(models.APIKeys, 0)
+	for rows.Next() {
+		var key models.APIKey
+		if err = rows.Scan(apiKeyDest(&key)...); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		keys = append(keys, key)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return keys, nil
+}
+
+// RotateAPIKey replaces the key of a key that isn't revoked. The old key stops working at once.
+func (r *Repository) RotateAPIKey(ctx context.Context, id int, prefix string, hash []byte) (*models.APIKey, error) {
+	const op = "repository.RotateAPIKey"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	var key models.APIKey
+	err := squirrel.Update(consts.APIKeysTableName).
+		PlaceholderFormat(squirrel.Dollar).
+		Set(consts.PrefixColumn, prefix).
+		Set(consts.KeyHashColumn, hash).
+		Set(consts.RotatedAtColumn, squirrel.Expr("now()")).
+		Where(squirrel.Eq{consts.IDColumn: id, consts.RevokedAtColumn: nil}).
+		Suffix("RETURNING " + strings.Join(apiKeyColumns, ", ")).
+		RunWith(r.conn()).QueryRowContext(ctx).Scan(apiKeyDest(&key)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &key, nil
+}
+
+// RevokeAPIKey stops a key from working. The key is kept to show who made the changes recorded under it.
+func (r *Repository) RevokeAPIKey(ctx context.Context, id int) error {
+	const op = "repository.RevokeAPIKey"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	q := squirrel.Update(consts.APIKeysTableName).
+		PlaceholderFormat(squirrel.Dollar).
+		Set(consts.RevokedAtColumn, squirrel.Expr("now()")).
+		Where(squirrel.Eq{consts.IDColumn: id, consts.RevokedAtColumn: nil})
+
+	if err := execOne(ctx, q.RunWith(r.conn()), ErrAPIKeyNotFound); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// FindAPIKey returns the key with the hash if it is neither revoked nor expired, and marks it used.
+func (r *Repository) FindAPIKey(ctx context.Context, hash []byte) (*models.APIKey, error) {
+	const op = "repository.FindAPIKey"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	var key models.APIKey
+	err := squirrel.Select(apiKeyColumns...).
+		PlaceholderFormat(squirrel.Dollar).
+		From(consts.APIKeysTableName).
+		Where(squirrel.Eq{consts.KeyHashColumn: hash, consts.RevokedAtColumn: nil}).
+		Where(squirrel.Or{
+			squirrel.Eq{consts.ExpiresAtColumn: nil},
+			squirrel.Expr(consts.ExpiresAtColumn + " > now()"),
+		}).
+		RunWith(r.conn()).QueryRowContext(ctx).Scan(apiKeyDest(&key)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// last_used_at is only as precise as the touch interval, so that not every request writes.
+	_, err = squirrel.Update(consts.APIKeysTableName).
+		PlaceholderFormat(squirrel.Dollar).
+		Set(consts.LastUsedAtColumn, squirrel.Expr("now()")).
+		Where(squirrel.Eq{consts.IDColumn: key.ID}).
+		Where(squirrel.Or{
+			squirrel.Eq{consts.LastUsedAtColumn: nil},
+			squirrel.Expr(consts.LastUsedAtColumn + " < now() - interval '" + consts.APIKeyTouchInterval + "'"),
+		}).
+		RunWith(r.conn()).ExecContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &key, nil
+}
+
+// apiKeyDest lists the scan destinations of apiKeyColumns.
+func apiKeyDest(key *models.APIKey) []any {
+	return []any{
+		&key.ID,
+		&key.Name,
+		&key.Role,
+		&key.Prefix,
+		&key.CreatedBy,
+		&key.CreatedAt,
+		&key.RotatedAt,
+		&key.ExpiresAt,
+		&key.LastUsedAt,
+		&key.RevokedAt,
+	}
+}
@@ -0,0 +1,258 @@
+package respository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"songs-library/internal/consts"
+	"songs-library/internal/converter"
+	"songs-library/internal/models"
+	"songs-library/pkg/tracing"
+	"time"
+)
+
+var (
+	ErrArtistNotFound      = errors.New("artist not found")
+	ErrArtistAlreadyExists = errors.New("artist already exists")
+)
+
+const uniqueViolationCode = "23505"
+
+// ResolveArtist returns the id of the artist with the same normalized name, creating the artist if there is none.
+func (r *Repository) ResolveArtist(ctx context.Context, name string) (int, error) {
+	const op = "repository.ResolveArtist"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	id, err := resolveArtist(ctx, r.conn(), name)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+func resolveArtist(ctx context.Context, runner squirrel.BaseRunner, name string) (int, error) {
+	q := squirrel.Insert(consts.ArtistsTableName).
+		PlaceholderFormat(squirrel.Dollar).
+		Columns(consts.NameColumn, consts.NormalizedNameColumn).
+		Values(models.CleanArtistName(name), models.NormalizeArtistName(name)).
+		Suffix("ON CONFLICT (" + consts.NormalizedNameColumn + ") DO UPDATE SET " +
+			consts.NormalizedNameColumn + " = EXCLUDED." + consts.NormalizedNameColumn + " RETURNING id")
+
+	var id int
+	err := q.RunWith(runner).QueryRowContext(ctx).Scan(&id)
+
+	return id, err
+}
+
+func (r *Repository) CreateArtist(ctx context.Context, artist *models.Artist) (int, error) {
+	const op = "repository.CreateArtist"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	q := squirrel.Insert(consts.ArtistsTableName).
+		PlaceholderFormat(squirrel.Dollar).
+		Columns(consts.NameColumn, consts.NormalizedNameColumn).
+		Values(models.CleanArtistName(artist.Name), models.NormalizeArtistName(artist.Name)).
+		Suffix("RETURNING id")
+
+	var id int
+	err := q.RunWith(r.conn()).QueryRowContext(ctx).Scan(&id)
+	if isUniqueViolation(err) {
+		return 0, ErrArtistAlreadyExists
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+func (r *Repository) GetArtist(ctx context.Context, id int) (*models.Artist, error) {
+	const op = "repository.GetArtist"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	q := selectArtists().
+		Where(squirrel.Eq{consts.ArtistsIDColumn: id})
+
+	var artist models.Artist
+	err := q.RunWith(r.conn()).QueryRowContext(ctx).Scan(&artist.ID, &artist.Name, &artist.SongsCount)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrArtistNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &artist, nil
+}
+
+func (r *Repository) ListArtists(ctx context.Context, filter *models.ArtistsFilter) (models.Artists, error) {
+	const op = "repository.ListArtists"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	q := selectArtists().
+		OrderBy(consts.ArtistsIDColumn + " ASC")
+
+	q = converter.ArtistsFilterToSqlFilters(q, filter)
+
+	rows, err := q.RunWith(r.conn()).QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close()
+
+	artists := make([]models.Artist, 0, filter.Limit)
+
+	for rows.Next() {
+		var artist models.Artist
+		if err = rows.Scan(&artist.ID, &artist.Name, &artist.SongsCount); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		artists = append(artists, artist)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return artists, nil
+}
+
+func (r *Repository) UpdateArtist(ctx context.Context, artist *models.UpdateArtist) error {
+	const op = "repository.UpdateArtist"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	q := squirrel.Update(consts.ArtistsTableName).
+		PlaceholderFormat(squirrel.Dollar).
+		Set(consts.NameColumn, models.CleanArtistName(artist.Name)).
+		Set(consts.NormalizedNameColumn, models.NormalizeArtistName(artist.Name)).
+		Where(squirrel.Eq{consts.IDColumn: artist.ID})
+
+	res, err := q.RunWith(r.conn()).ExecContext(ctx)
+	if isUniqueViolation(err) {
+		return ErrArtistAlreadyExists
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrArtistNotFound
+	}
+
+	return nil
+}
+
+// MergeArtists moves the songs and albums of the source artists to the target artist and deletes the sources.
+func (r *Repository) MergeArtists(ctx context.Context, targetID int, sourceIDs []int) error {
+	const op = "repository.MergeArtists"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	err := r.inTx(ctx, func(tx *sqlx.Tx) error {
+		var id int
+		err := squirrel.Select(consts.IDColumn).
+			PlaceholderFormat(squirrel.Dollar).
+			From(consts.ArtistsTableName).
+			Where(squirrel.Eq{consts.IDColumn: targetID}).
+			Suffix("FOR UPDATE").
+			RunWith(tx).QueryRowContext(ctx).Scan(&id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrArtistNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = squirrel.Update(consts.SongsTableName).
+			PlaceholderFormat(squirrel.Dollar).
+			Set(consts.ArtistIDColumn, targetID).
+			Where(squirrel.Eq{consts.ArtistIDColumn: sourceIDs}).
+			RunWith(tx).ExecContext(ctx)
+		if err != nil {
+			return err
+		}
+
+		_, err = squirrel.Update(consts.AlbumsTableName).
+			PlaceholderFormat(squirrel.Dollar).
+			Set(consts.ArtistIDColumn, targetID).
+			Where(squirrel.Eq{consts.ArtistIDColumn: sourceIDs}).
+			RunWith(tx).ExecContext(ctx)
+		if err != nil {
+			return err
+		}
+
+		res, err := squirrel.Delete(consts.ArtistsTableName).
+			PlaceholderFormat(squirrel.Dollar).
+			Where(squirrel.Eq{consts.IDColumn: sourceIDs}).
+			RunWith(tx).ExecContext(ctx)
+		if err != nil {
+			return err
+		}
+
+		rowsAffected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rowsAffected != int64(countUnique(sourceIDs)) {
+			return ErrArtistNotFound
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func selectArtists() squirrel.SelectBuilder {
+	return squirrel.
+		Select(
+			consts.ArtistsIDColumn,
+			consts.ArtistsNameColumn,
+			"count("+consts.SongsIDColumn+") AS "+consts.SongsCountColumn,
+		).
+		PlaceholderFormat(squirrel.Dollar).
+		From(consts.ArtistsTableName).
+		LeftJoin(consts.SongsTableName + " ON " + consts.SongsArtistIDColumn + " = " + consts.ArtistsIDColumn).
+		GroupBy(consts.ArtistsIDColumn)
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == uniqueViolationCode
+}
+
+func countUnique(ids []int) int {
+	seen := make(map[int]struct{}, len(ids))
+	for _, id := range ids {
+		seen[id] = struct{}{}
+	}
+
+	return len(seen)
+}
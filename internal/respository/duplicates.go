@@ -0,0 +1,428 @@
+package respository
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"slices"
+	"songs-library/internal/consts"
+	"songs-library/internal/models"
+	"songs-library/internal/releasedate"
+	"songs-library/pkg/tracing"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// duplicateLockClass is the first key of the advisory locks that serialize duplicate checks per artist.
+const duplicateLockClass = 1
+
+const (
+	advisoryXactLockQuery = "SELECT pg_advisory_xact_lock($1, $2)"
+	setSimilarityQuery    = "SELECT set_config('pg_trgm.similarity_threshold', $1, true)"
+	duplicatePairsQuery   = `
+SELECT a.id, b.id, s.title, s.grp, (s.title + s.grp) / 2 AS score
+FROM songs a
+JOIN songs b ON b.normalized_song % a.normalized_song AND b.id > a.id
+JOIN artists aa ON aa.id = a.artist_id
+JOIN artists ba ON ba.id = b.artist_id
+CROSS JOIN LATERAL (
+    SELECT similarity(a.normalized_song, b.normalized_song) AS title,
+           CASE WHEN a.artist_id = b.artist_id THEN 1
+                ELSE similarity(aa.normalized_name, ba.normalized_name) END AS grp
+) s
+WHERE a.normalized_song <> '' AND (s.title + s.grp) / 2 >= $1
+ORDER BY score DESC, a.id, b.id
+LIMIT $2`
+)
+
+// precisionRank orders release date precisions from the least to the most precise.
+var precisionRank = map[string]int{
+	releasedate.PrecisionYear:  1,
+	releasedate.PrecisionMonth: 2,
+	releasedate.PrecisionDay:   3,
+}
+
+// ListDuplicateClusters groups songs alike in title and group. A pair is a candidate when both the title
+// similarity and the mean of the title and group similarities reach the threshold; songs linked by
+// candidate pairs, directly or through others, form a cluster. The best clusters come first.
+func (r *Repository) ListDuplicateClusters(ctx context.Context, filter *models.DuplicatesFilter) (models.DuplicateClusters, error) {
+	const op = "repository.ListDuplicateClusters"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	// Repeatable read keeps the songs of the clusters as they were when the pairs were found.
+	tx, done, err := r.readTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer done()
+
+	threshold := strconv.FormatFloat(filter.Threshold, 'f', -1, 64)
+	if _, err = tx.ExecContext(ctx, setSimilarityQuery, threshold); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := tx.QueryContext(ctx, duplicatePairsQuery, filter.Threshold, consts.MaxDuplicatePairs)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var pairs []models.DuplicatePair
+	for rows.Next() {
+		var p models.DuplicatePair
+		err = rows.Scan(&p.SongID, &p.DuplicateID, &p.TitleSimilarity, &p.GroupSimilarity, &p.Score)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		pairs = append(pairs, p)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	clusters := clusterPairs(pairs)
+	if len(clusters) > filter.Limit {
+		clusters = clusters[:filter.Limit]
+	}
+
+	var ids []int
+	for _, c := range clusters {
+		for _, s := range c.Songs {
+			ids = append(ids, s.ID)
+		}
+	}
+
+	songs, err := getSongs(ctx, tx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for i := range clusters {
+		for j := range clusters[i].Songs {
+			clusters[i].Songs[j] = songs[clusters[i].Songs[j].ID]
+		}
+	}
+
+	return clusters, nil
+}
+
+// MergeSongs keeps the target song and deletes the sources. The target keeps its link and text unless they are
+// empty, when it takes the link of the first source that has one and the longest source text with its synced
+// lyrics, and it takes the most precise release date of all the songs, its own on a tie. The target also takes
+// the sources' places on albums it isn't on yet and their playlist entries.
+func (r *Repository) MergeSongs(ctx context.Context, targetID int, sourceIDs []int) error {
+	const op = "repository.MergeSongs"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	err := r.inTx(ctx, func(tx *sqlx.Tx) error {
+		_, err := tx.ExecContext(ctx, setRevisionActionQuery, models.RevisionMerge)
+		if err != nil {
+			return err
+		}
+
+		songs, err := lockMergedSongs(ctx, tx, append([]int{targetID}, sourceIDs...))
+		if err != nil {
+			return err
+		}
+
+		if len(songs) != countUnique(sourceIDs)+1 {
+			return ErrSongNotFound
+		}
+
+		merged := songs[targetID]
+		textFrom := targetID
+
+		for _, id := range sourceIDs {
+			src := songs[id]
+
+			if merged.link == "" {
+				merged.link = src.link
+			}
+
+			if merged.text == "" && utf8.RuneCountInString(src.text) > utf8.RuneCountInString(songs[textFrom].text) {
+				textFrom = id
+			}
+
+			if src.releaseDate.Valid &&
+				(!merged.releaseDate.Valid || precisionRank[src.precision] > precisionRank[merged.precision]) {
+				merged.releaseDate, merged.precision = src.releaseDate, src.precision
+			}
+		}
+
+		merged.text = songs[textFrom].text
+
+		var releaseDate any
+		if merged.releaseDate.Valid {
+			releaseDate = merged.releaseDate.String
+		}
+
+		_, err = squirrel.Update(consts.SongsTableName).
+			PlaceholderFormat(squirrel.Dollar).
+			Set(consts.ReleaseDateColumn, releaseDate).
+			Set(consts.ReleaseDatePrecisionColumn, merged.precision).
+			Set(consts.TextColumn, merged.text).
+			Set(consts.LinkColumn, merged.link).
+			Where(squirrel.Eq{consts.IDColumn: targetID}).
+			RunWith(tx).ExecContext(ctx)
+		if err != nil {
+			return err
+		}
+
+		if textFrom != targetID {
+			if err = copySyncedLyrics(ctx, tx, textFrom, targetID); err != nil {
+				return err
+			}
+		}
+
+		if err = moveTracks(ctx, tx, sourceIDs, targetID); err != nil {
+			return err
+		}
+
+		if err = movePlaylistEntries(ctx, tx, sourceIDs, targetID); err != nil {
+			return err
+		}
+
+		_, err = squirrel.Delete(consts.SongsTableName).
+			PlaceholderFormat(squirrel.Dollar).
+			Where(squirrel.Eq{consts.IDColumn: sourceIDs}).
+			RunWith(tx).ExecContext(ctx)
+
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// findDuplicate returns the id of the artist's oldest song with the same normalized title.
+func findDuplicate(ctx context.Context, runner squirrel.BaseRunner, artistID int, song string) (int, error) {
+	var id int
+	err := squirrel.Select(consts.IDColumn).
+		PlaceholderFormat(squirrel.Dollar).
+		From(consts.SongsTableName).
+		Where(squirrel.Eq{consts.ArtistIDColumn: artistID}).
+		Where(consts.NormalizedSongColumn+" = normalize_title(?)", song).
+		OrderBy(consts.IDColumn).
+		Limit(1).
+		RunWith(runner).QueryRowContext(ctx).Scan(&id)
+
+	return id, err
+}
+
+// lockArtistSongs holds off other duplicate checks for the artist until the transaction ends, so two
+// requests can't both find no duplicate and insert the same song.
+func lockArtistSongs(ctx context.Context, tx *sqlx.Tx, artistID int) error {
+	_, err := tx.ExecContext(ctx, advisoryXactLockQuery, duplicateLockClass, artistID)
+	return err
+}
+
+type mergedSong struct {
+	releaseDate sql.NullString
+	precision   string
+	text        string
+	link        string
+}
+
+func lockMergedSongs(ctx context.Context, tx *sqlx.Tx, ids []int) (map[int]*mergedSong, error) {
+	rows, err := squirrel.
+		Select(
+			consts.IDColumn,
+			"to_char("+consts.ReleaseDateColumn+", 'YYYY-MM-DD')",
+			consts.ReleaseDatePrecisionColumn,
+			"coalesce("+consts.TextColumn+", '')",
+			"coalesce("+consts.LinkColumn+", '')",
+		).
+		PlaceholderFormat(squirrel.Dollar).
+		From(consts.SongsTableName).
+		Where(squirrel.Eq{consts.IDColumn: ids}).
+		OrderBy(consts.IDColumn).
+		Suffix("FOR UPDATE").
+		RunWith(tx).QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	songs := make(map[int]*mergedSong, len(ids))
+	for rows.Next() {
+		var (
+			id   int
+			song mergedSong
+		)
+
+		err = rows.Scan(&id, &song.releaseDate, &song.precision, &song.text, &song.link)
+		if err != nil {
+			return nil, err
+		}
+
+		songs[id] = &song
+	}
+
+	return songs, rows.Err()
+}
+
+// copySyncedLyrics gives the target the synced lyrics of the source, unless it has its own.
+func copySyncedLyrics(ctx context.Context, tx *sqlx.Tx, sourceID, targetID int) error {
+	res, err := tx.ExecContext(ctx, `
+INSERT INTO synced_lyrics (song_id, tags, offset_ms, updated_at)
+SELECT $1, tags, offset_ms, updated_at FROM synced_lyrics WHERE song_id = $2
+ON CONFLICT (song_id) DO NOTHING`, targetID, sourceID)
+	if err != nil {
+		return err
+	}
+
+	copied, err := res.RowsAffected()
+	if err != nil || copied == 0 {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+INSERT INTO synced_lyrics_lines (song_id, position, time_ms, text)
+SELECT $1, position, time_ms, text FROM synced_lyrics_lines WHERE song_id = $2`, targetID, sourceID)
+
+	return err
+}
+
+// moveTracks gives the target the album tracks of the sources. Where the target or another source already
+// is on the album, the track is dropped.
+func moveTracks(ctx context.Context, tx *sqlx.Tx, sourceIDs []int, targetID int) error {
+	rows, err := squirrel.Delete(consts.AlbumTracksTableName).
+		PlaceholderFormat(squirrel.Dollar).
+		Where(squirrel.Eq{consts.SongIDColumn: sourceIDs}).
+		Suffix("RETURNING " + consts.AlbumIDColumn + ", " + consts.DiscNumberColumn + ", " + consts.TrackNumberColumn).
+		RunWith(tx).QueryContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	type track struct{ albumID, disc, number int }
+
+	var tracks []track
+	for rows.Next() {
+		var t track
+		if err = rows.Scan(&t.albumID, &t.disc, &t.number); err != nil {
+			return err
+		}
+
+		tracks = append(tracks, t)
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	for _, t := range tracks {
+		_, err = squirrel.Insert(consts.AlbumTracksTableName).
+			PlaceholderFormat(squirrel.Dollar).
+			Columns(consts.AlbumIDColumn, consts.SongIDColumn, consts.DiscNumberColumn, consts.TrackNumberColumn).
+			Values(t.albumID, targetID, t.disc, t.number).
+			Suffix("ON CONFLICT DO NOTHING").
+			RunWith(tx).ExecContext(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getSongs returns the songs with the ids by id.
+func getSongs(ctx context.Context, runner squirrel.BaseRunner, ids []int) (map[int]models.Song, error) {
+	songs := make(map[int]models.Song, len(ids))
+	if len(ids) == 0 {
+		return songs, nil
+	}
+
+	rows, err := selectSongs().
+		Where(consts.SongsIDColumn+" = ANY(?)", pq.Array(ids)).
+		RunWith(runner).QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var song models.Song
+		if err = rows.Scan(songDest(&song)...); err != nil {
+			return nil, err
+		}
+
+		songs[song.ID] = song
+	}
+
+	return songs, rows.Err()
+}
+
+// clusterPairs joins the pairs that share a song into clusters, best score first. The songs of a cluster
+// hold only their ids.
+func clusterPairs(pairs []models.DuplicatePair) models.DuplicateClusters {
+	parent := make(map[int]int)
+
+	var find func(id int) int
+	find = func(id int) int {
+		p, ok := parent[id]
+		if !ok || p == id {
+			parent[id] = id
+			return id
+		}
+
+		root := find(p)
+		parent[id] = root
+
+		return root
+	}
+
+	for _, p := range pairs {
+		a, b := find(p.SongID), find(p.DuplicateID)
+		if a != b {
+			parent[max(a, b)] = min(a, b)
+		}
+	}
+
+	byRoot := make(map[int]*models.DuplicateCluster)
+	for _, p := range pairs {
+		root := find(p.SongID)
+
+		c, ok := byRoot[root]
+		if !ok {
+			c = &models.DuplicateCluster{}
+			byRoot[root] = c
+		}
+
+		c.Pairs = append(c.Pairs, p)
+		c.Score = max(c.Score, p.Score)
+	}
+
+	clusters := make(models.DuplicateClusters, 0, len(byRoot))
+	for _, c := range byRoot {
+		var ids []int
+		for _, p := range c.Pairs {
+			ids = append(ids, p.SongID, p.DuplicateID)
+		}
+
+		slices.Sort(ids)
+		for _, id := range slices.Compact(ids) {
+			c.Songs = append(c.Songs, models.Song{ID: id})
+		}
+
+		clusters = append(clusters, *c)
+	}
+
+	slices.SortFunc(clusters, func(a, b models.DuplicateCluster) int {
+		return cmp.Or(cmp.Compare(b.Score, a.Score), cmp.Compare(a.Songs[0].ID, b.Songs[0].ID))
+	})
+
+	return clusters
+}
@@ -0,0 +1,296 @@
+package respository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"songs-library/internal/consts"
+	"songs-library/internal/models"
+	"songs-library/pkg/tracing"
+	"strings"
+	"time"
+)
+
+var ErrNoEnrichmentJobs = errors.New("no enrichment jobs ready")
+
+// claimEnrichmentJobQuery locks the next due job, skipping jobs held by other workers
+// unless their lease has expired, and counts the attempt.
+const claimEnrichmentJobQuery = `
+UPDATE enrichment_jobs j
+SET attempts = j.attempts + 1, locked_at = now()
+FROM songs s
+JOIN artists a ON a.id = s.artist_id
+WHERE j.id = (
+    SELECT id FROM enrichment_jobs
+    WHERE failed_at IS NULL
+      AND run_at <= now()
+      AND (locked_at IS NULL OR locked_at < now() - make_interval(secs => $1))
+    ORDER BY run_at
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+AND s.id = j.song_id
+RETURNING j.id, j.song_id, s.song, a.name, j.attempts`
+
+// requeueEnrichmentQuery marks the songs as pending and resets their jobs.
+// With an empty id list every failed song is requeued.
+const requeueEnrichmentQuery = `
+WITH target AS (
+    UPDATE songs SET enrichment_status = $1
+    WHERE (cardinality($2::int[]) = 0 AND enrichment_status = $3) OR id = ANY($2::int[])
+    RETURNING id
+)
+INSERT INTO enrichment_jobs (song_id)
+SELECT id FROM target
+ON CONFLICT (song_id) DO UPDATE
+SET attempts = 0, run_at = now(), locked_at = NULL, failed_at = NULL, last_error = NULL`
+
+func (r *Repository) EnqueueEnrichment(ctx context.Context, songID int) error {
+	const op = "repository.EnqueueEnrichment"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	err := enqueueEnrichment(ctx, r.conn(), songID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func enqueueEnrichment(ctx context.Context, runner squirrel.BaseRunner, songID int) error {
+	_, err := squirrel.Insert(consts.EnrichmentJobsTableName).
+		PlaceholderFormat(squirrel.Dollar).
+		Columns(consts.SongIDColumn).
+		Values(songID).
+		Suffix("ON CONFLICT (" + consts.SongIDColumn + ") DO NOTHING").
+		RunWith(runner).ExecContext(ctx)
+
+	return err
+}
+
+// ClaimEnrichmentJob takes the next due job for lease. It returns ErrNoEnrichmentJobs when the queue is idle.
+func (r *Repository) ClaimEnrichmentJob(ctx context.Context, lease time.Duration) (*models.EnrichmentJob, error) {
+	const op = "repository.ClaimEnrichmentJob"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	var job models.EnrichmentJob
+	err := r.conn().QueryRowContext(ctx, claimEnrichmentJobQuery, lease.Seconds()).
+		Scan(&job.ID, &job.SongID, &job.Song, &job.Group, &job.Attempts)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNoEnrichmentJobs
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &job, nil
+}
+
+// CompleteEnrichment fills in the details the song is still missing, puts it on the album the info API
+// names and removes its job.
+func (r *Repository) CompleteEnrichment(ctx context.Context, job *models.EnrichmentJob, detail *models.SongDetail) error {
+	const op = "repository.CompleteEnrichment"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	releaseDate, precision, err := releaseDateValues(detail.ReleaseDate)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = r.inTx(ctx, func(tx *sqlx.Tx) error {
+		var artistID int
+		err := squirrel.Update(consts.SongsTableName).
+			PlaceholderFormat(squirrel.Dollar).
+			Set(consts.ReleaseDatePrecisionColumn, squirrel.Expr(
+				"CASE WHEN "+consts.ReleaseDateColumn+" IS NULL THEN ? ELSE "+consts.ReleaseDatePrecisionColumn+" END",
+				precision,
+			)).
+			Set(consts.ReleaseDateColumn, squirrel.Expr("coalesce("+consts.ReleaseDateColumn+", ?)", releaseDate)).
+			Set(consts.TextColumn, fillEmpty(consts.TextColumn, detail.Text)).
+			Set(consts.LinkColumn, fillEmpty(consts.LinkColumn, detail.Link)).
+			Set(consts.EnrichmentStatusColumn, models.EnrichmentOK).
+			Where(squirrel.Eq{consts.IDColumn: job.SongID}).
+			Suffix("RETURNING " + consts.ArtistIDColumn).
+			RunWith(tx).QueryRowContext(ctx).Scan(&artistID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		if err == nil && detail.Album != nil && strings.TrimSpace(detail.Album.Title) != "" {
+			if err = attachAlbum(ctx, tx, artistID, job.SongID, detail.Album); err != nil {
+				return err
+			}
+		}
+
+		_, err = squirrel.Delete(consts.EnrichmentJobsTableName).
+			PlaceholderFormat(squirrel.Dollar).
+			Where(squirrel.Eq{consts.IDColumn: job.ID}).
+			RunWith(tx).ExecContext(ctx)
+
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// RetryEnrichmentJob releases the job and schedules the next attempt.
+func (r *Repository) RetryEnrichmentJob(ctx context.Context, job *models.EnrichmentJob, runAt time.Time, lastErr string) error {
+	const op = "repository.RetryEnrichmentJob"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	_, err := squirrel.Update(consts.EnrichmentJobsTableName).
+		PlaceholderFormat(squirrel.Dollar).
+		Set(consts.LockedAtColumn, nil).
+		Set(consts.RunAtColumn, runAt).
+		Set(consts.LastErrorColumn, lastErr).
+		Where(squirrel.Eq{consts.IDColumn: job.ID}).
+		RunWith(r.conn()).ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ReleaseEnrichmentJob hands back a job the worker was stopped in the middle of, without counting the attempt.
+// A job whose lease expired and that another worker claimed meanwhile is left to that worker.
+func (r *Repository) ReleaseEnrichmentJob(ctx context.Context, job *models.EnrichmentJob) error {
+	const op = "repository.ReleaseEnrichmentJob"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	_, err := squirrel.Update(consts.EnrichmentJobsTableName).
+		PlaceholderFormat(squirrel.Dollar).
+		Set(consts.LockedAtColumn, nil).
+		Set(consts.AttemptsColumn, squirrel.Expr(consts.AttemptsColumn+" - 1")).
+		Where(squirrel.Eq{consts.IDColumn: job.ID, consts.AttemptsColumn: job.Attempts}).
+		RunWith(r.conn()).ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// FailEnrichment gives up on the job and marks the song as failed until it is requeued.
+func (r *Repository) FailEnrichment(ctx context.Context, job *models.EnrichmentJob, lastErr string) error {
+	const op = "repository.FailEnrichment"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	err := r.inTx(ctx, func(tx *sqlx.Tx) error {
+		_, err := squirrel.Update(consts.EnrichmentJobsTableName).
+			PlaceholderFormat(squirrel.Dollar).
+			Set(consts.LockedAtColumn, nil).
+			Set(consts.FailedAtColumn, squirrel.Expr("now()")).
+			Set(consts.LastErrorColumn, lastErr).
+			Where(squirrel.Eq{consts.IDColumn: job.ID}).
+			RunWith(tx).ExecContext(ctx)
+		if err != nil {
+			return err
+		}
+
+		_, err = squirrel.Update(consts.SongsTableName).
+			PlaceholderFormat(squirrel.Dollar).
+			Set(consts.EnrichmentStatusColumn, models.EnrichmentFailed).
+			Where(squirrel.Eq{consts.IDColumn: job.SongID}).
+			RunWith(tx).ExecContext(ctx)
+
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *Repository) GetEnrichment(ctx context.Context, songID int) (*models.Enrichment, error) {
+	const op = "repository.GetEnrichment"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	q := squirrel.
+		Select(
+			consts.SongsIDColumn,
+			consts.EnrichmentStatusColumn,
+			"coalesce(j."+consts.AttemptsColumn+", 0)",
+			"coalesce(j."+consts.LastErrorColumn+", '')",
+			"j."+consts.RunAtColumn,
+		).
+		PlaceholderFormat(squirrel.Dollar).
+		From(consts.SongsTableName).
+		LeftJoin(consts.EnrichmentJobsTableName + " j ON j." + consts.SongIDColumn + " = " + consts.SongsIDColumn).
+		Where(squirrel.Eq{consts.SongsIDColumn: songID})
+
+	var (
+		enrichment models.Enrichment
+		runAt      sql.NullTime
+	)
+	err := q.RunWith(r.conn()).QueryRowContext(ctx).Scan(
+		&enrichment.SongID,
+		&enrichment.Status,
+		&enrichment.Attempts,
+		&enrichment.LastError,
+		&runAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSongNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if runAt.Valid && enrichment.Status == models.EnrichmentPending {
+		enrichment.NextRunAt = &runAt.Time
+	}
+
+	return &enrichment, nil
+}
+
+// RequeueEnrichment schedules the songs for enrichment again, or every failed song when ids is empty.
+func (r *Repository) RequeueEnrichment(ctx context.Context, ids []int) (int, error) {
+	const op = "repository.RequeueEnrichment"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	if ids == nil {
+		ids = []int{}
+	}
+
+	res, err := r.conn().ExecContext(ctx, requeueEnrichmentQuery, models.EnrichmentPending, pq.Array(ids), models.EnrichmentFailed)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return int(rowsAffected), nil
+}
+
+// fillEmpty keeps a value that is already set on the song, e.g. by an edit made while enrichment was pending.
+func fillEmpty(column, value string) squirrel.Sqlizer {
+	return squirrel.Expr("coalesce(nullif("+column+", ''), ?)", value)
+}
@@ -0,0 +1,93 @@
+package respository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"songs-library/internal/consts"
+	"songs-library/internal/converter"
+	"songs-library/internal/models"
+	"songs-library/pkg/tracing"
+	"strconv"
+	"time"
+)
+
+// ExportSongs passes every song that matches the filter to fn, in the filter's sort. The songs are read
+// from a server-side cursor a batch at a time, so memory doesn't depend on how many there are.
+func (r *Repository) ExportSongs(ctx context.Context, filter *models.SongsFilter, withText bool, fn func(*models.SongWithText) error) error {
+	const op = "repository.ExportSongs"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	q := selectSongs()
+	if withText {
+		q = q.Column(consts.TextColumn)
+	}
+
+	q = converter.SongFilterToSqlFilters(q, filter)
+	q = converter.SongsOrder(q, filter, nil)
+
+	query, args, err := q.ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tx, done, err := r.readTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer done()
+
+	_, err = tx.ExecContext(ctx, "DECLARE "+consts.ExportCursorName+" NO SCROLL CURSOR FOR "+query, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	fetch := "FETCH " + strconv.Itoa(consts.ExportBatchSize) + " FROM " + consts.ExportCursorName
+	keys := make([]string, converter.SortKeysCount(filter))
+
+	for {
+		rows, err := tx.QueryContext(ctx, fetch)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		fetched := 0
+		for rows.Next() {
+			var song models.SongWithText
+
+			dest := songDest(&song.Song)
+			if withText {
+				dest = append(dest, &song.Text)
+			}
+			for i := range keys {
+				dest = append(dest, &keys[i])
+			}
+
+			if err = rows.Scan(dest...); err != nil {
+				rows.Close()
+				return fmt.Errorf("%s: %w", op, err)
+			}
+
+			if err = fn(&song); err != nil {
+				rows.Close()
+				return fmt.Errorf("%s: %w", op, err)
+			}
+
+			fetched++
+		}
+
+		if err = rows.Close(); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		if err = rows.Err(); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		if fetched < consts.ExportBatchSize {
+			return nil
+		}
+	}
+}
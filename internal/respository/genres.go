@@ -0,0 +1,297 @@
+package respository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"songs-library/internal/consts"
+	"songs-library/internal/models"
+	"songs-library/pkg/tracing"
+	"time"
+)
+
+var (
+	ErrGenreNotFound      = errors.New("genre not found")
+	ErrGenreAlreadyExists = errors.New("genre already exists")
+	ErrGenreCycle         = errors.New("genre cannot be moved under itself or its subgenres")
+	ErrGenreHasSubgenres  = errors.New("genre has subgenres")
+)
+
+// genreTreeLockClass is the first key of the advisory lock that serializes moves in the genre tree.
+const genreTreeLockClass = 3
+
+// genresQuery walks the genre tree from the roots, building the path of each genre and a sort key
+// that lists siblings by name under their parent.
+const genresQuery = `
+WITH RECURSIVE tree AS (
+    SELECT id, name, parent_id, name::text AS path, ARRAY[lower(name)] AS sort
+    FROM genres
+    WHERE parent_id IS NULL
+    UNION ALL
+    SELECT g.id, g.name, g.parent_id, tree.path || ' > ' || g.name, tree.sort || lower(g.name)
+    FROM genres g
+    JOIN tree ON g.parent_id = tree.id
+)
+SELECT tree.id, tree.name, tree.parent_id, tree.path,
+       (SELECT count(*) FROM song_genres WHERE song_genres.genre_id = tree.id) AS songs_count
+FROM tree`
+
+// genreDescendsQuery tells whether the second genre is the first one or one of its subgenres.
+const genreDescendsQuery = `
+WITH RECURSIVE subgenres AS (
+    SELECT id FROM genres WHERE id = $1
+    UNION
+    SELECT g.id FROM genres g JOIN subgenres ON g.parent_id = subgenres.id
+)
+SELECT EXISTS (SELECT 1 FROM subgenres WHERE id = $2)`
+
+func (r *Repository) CreateGenre(ctx context.Context, genre *models.CreateGenre) (int, error) {
+	const op = "repository.CreateGenre"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	var id int
+	err := squirrel.Insert(consts.GenresTableName).
+		PlaceholderFormat(squirrel.Dollar).
+		Columns(consts.NameColumn, consts.ParentIDColumn).
+		Values(genre.Name, genre.ParentID).
+		Suffix("RETURNING " + consts.IDColumn).
+		RunWith(r.conn()).QueryRowContext(ctx).Scan(&id)
+	if isUniqueViolation(err) {
+		return 0, ErrGenreAlreadyExists
+	}
+	if isForeignKeyViolation(err) {
+		return 0, ErrGenreNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+func (r *Repository) GetGenre(ctx context.Context, id int) (*models.Genre, error) {
+	const op = "repository.GetGenre"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	var genre models.Genre
+	err := r.conn().QueryRowContext(ctx, genresQuery+" WHERE tree.id = $1", id).
+		Scan(&genre.ID, &genre.Name, &genre.ParentID, &genre.Path, &genre.SongsCount)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrGenreNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &genre, nil
+}
+
+// ListGenres returns the whole genre tree, each genre after its parent.
+func (r *Repository) ListGenres(ctx context.Context) (models.Genres, error) {
+	const op = "repository.ListGenres"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	genres, err := queryGenres(ctx, r.conn(), genresQuery+" ORDER BY tree.sort")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return genres, nil
+}
+
+func (r *Repository) UpdateGenre(ctx context.Context, genre *models.UpdateGenre) error {
+	const op = "repository.UpdateGenre"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	err := r.inTx(ctx, func(tx *sqlx.Tx) error {
+		if genre.ParentID != nil {
+			// Two moves checked at once could each pass and together close a cycle.
+			_, err := tx.ExecContext(ctx, advisoryXactLockQuery, genreTreeLockClass, 0)
+			if err != nil {
+				return err
+			}
+
+			var cycle bool
+			err = tx.QueryRowContext(ctx, genreDescendsQuery, genre.ID, *genre.ParentID).Scan(&cycle)
+			if err != nil {
+				return err
+			}
+
+			if cycle {
+				return ErrGenreCycle
+			}
+		}
+
+		res, err := squirrel.Update(consts.GenresTableName).
+			PlaceholderFormat(squirrel.Dollar).
+			Set(consts.NameColumn, genre.Name).
+			Set(consts.ParentIDColumn, genre.ParentID).
+			Where(squirrel.Eq{consts.IDColumn: genre.ID}).
+			RunWith(tx).ExecContext(ctx)
+		if isUniqueViolation(err) {
+			return ErrGenreAlreadyExists
+		}
+		if isForeignKeyViolation(err) {
+			return ErrGenreNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		rowsAffected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rowsAffected == 0 {
+			return ErrGenreNotFound
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// DeleteGenre deletes a genre without subgenres. Its songs lose the genre.
+func (r *Repository) DeleteGenre(ctx context.Context, id int) error {
+	const op = "repository.DeleteGenre"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	res, err := squirrel.Delete(consts.GenresTableName).
+		PlaceholderFormat(squirrel.Dollar).
+		Where(squirrel.Eq{consts.IDColumn: id}).
+		RunWith(r.conn()).ExecContext(ctx)
+	if isForeignKeyViolation(err) {
+		return ErrGenreHasSubgenres
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrGenreNotFound
+	}
+
+	return nil
+}
+
+// addSongGenres gives the songs the genres. A genre that doesn't exist is ErrGenreNotFound.
+func addSongGenres(ctx context.Context, tx *sqlx.Tx, songIDs, genreIDs []int) (int, error) {
+	if len(genreIDs) == 0 {
+		return 0, nil
+	}
+
+	if err := checkExist(ctx, tx, consts.GenresTableName, genreIDs, ErrGenreNotFound); err != nil {
+		return 0, err
+	}
+
+	res, err := tx.ExecContext(ctx, `
+INSERT INTO song_genres (song_id, genre_id)
+SELECT s.song_id, g.genre_id FROM unnest($1::int[]) AS s(song_id) CROSS JOIN unnest($2::int[]) AS g(genre_id)
+ON CONFLICT DO NOTHING`, pq.Array(songIDs), pq.Array(genreIDs))
+	if err != nil {
+		return 0, err
+	}
+
+	added, err := res.RowsAffected()
+
+	return int(added), err
+}
+
+// removeSongGenres takes the genres from the songs. Subgenres are not removed with their parent.
+func removeSongGenres(ctx context.Context, tx *sqlx.Tx, songIDs, genreIDs []int) (int, error) {
+	if len(genreIDs) == 0 {
+		return 0, nil
+	}
+
+	res, err := squirrel.Delete(consts.SongGenresTableName).
+		PlaceholderFormat(squirrel.Dollar).
+		Where(squirrel.Eq{consts.SongIDColumn: songIDs}).
+		Where(squirrel.Eq{consts.GenreIDColumn: genreIDs}).
+		RunWith(tx).ExecContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	removed, err := res.RowsAffected()
+
+	return int(removed), err
+}
+
+// ListSongGenres returns the genres of the song with their paths.
+func (r *Repository) ListSongGenres(ctx context.Context, songID int) (models.Genres, error) {
+	const op = "repository.ListSongGenres"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	genres, err := queryGenres(ctx, r.conn(),
+		genresQuery+" WHERE tree.id IN (SELECT genre_id FROM song_genres WHERE song_id = $1) ORDER BY tree.sort", songID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return genres, nil
+}
+
+func queryGenres(ctx context.Context, db dbtx, query string, args ...any) (models.Genres, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	genres := make(models.Genres, 0)
+	for rows.Next() {
+		var genre models.Genre
+		if err = rows.Scan(&genre.ID, &genre.Name, &genre.ParentID, &genre.Path, &genre.SongsCount); err != nil {
+			return nil, err
+		}
+
+		genres = append(genres, genre)
+	}
+
+	return genres, rows.Err()
+}
+
+// checkExist returns notFound unless every id is in the table.
+func checkExist(ctx context.Context, tx *sqlx.Tx, table string, ids []int, notFound error) error {
+	var found int
+	err := squirrel.Select("count(*)").
+		PlaceholderFormat(squirrel.Dollar).
+		From(table).
+		Where(squirrel.Eq{consts.IDColumn: ids}).
+		RunWith(tx).QueryRowContext(ctx).Scan(&found)
+	if err != nil {
+		return err
+	}
+
+	if found != countUnique(ids) {
+		return notFound
+	}
+
+	return nil
+}
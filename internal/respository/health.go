@@ -0,0 +1,14 @@
+package respository
+
+import (
+	"context"
+	"songs-library/pkg/tracing"
+)
+
+func (r *Repository) Ping(ctx context.Context) error {
+	const op = "repository.Ping"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	return r.db.PingContext(ctx)
+}
@@ -0,0 +1,125 @@
+package respository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"songs-library/internal/consts"
+	"songs-library/internal/models"
+	"songs-library/pkg/tracing"
+	"time"
+)
+
+// ImportSongs inserts a batch of valid rows in one transaction. A row is a duplicate if the artist already
+// has a song with the same normalized title, including one earlier in the batch. Rows naming an album
+// are put on it, duplicates included. With enrich the rows
+// missing details are queued for enrichment, all others are stored as enriched.
+func (r *Repository) ImportSongs(ctx context.Context, songs []models.ImportSong, enrich bool) ([]models.ImportRow, error) {
+	const op = "repository.ImportSongs"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	rows := make([]models.ImportRow, 0, len(songs))
+
+	err := r.inTx(ctx, func(tx *sqlx.Tx) error {
+		rows = rows[:0]
+
+		for _, song := range songs {
+			row, err := importSong(ctx, tx, &song, enrich)
+			if err != nil {
+				return fmt.Errorf("row %d: %w", song.Row, err)
+			}
+
+			rows = append(rows, *row)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return rows, nil
+}
+
+func importSong(ctx context.Context, tx *sqlx.Tx, song *models.ImportSong, enrich bool) (*models.ImportRow, error) {
+	artistID, err := resolveArtist(ctx, tx, song.Group)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = lockArtistSongs(ctx, tx, artistID); err != nil {
+		return nil, err
+	}
+
+	id, err := findDuplicate(ctx, tx, artistID, song.Song)
+	if err == nil {
+		if err = importAlbum(ctx, tx, artistID, id, song); err != nil {
+			return nil, err
+		}
+
+		return &models.ImportRow{Row: song.Row, Status: models.ImportDuplicate, SongID: id}, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	releaseDate, precision, err := releaseDateValues(song.ReleaseDate)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := enrich && !song.HasDetails()
+
+	status := models.EnrichmentOK
+	if pending {
+		status = models.EnrichmentPending
+	}
+
+	err = squirrel.Insert(consts.SongsTableName).
+		PlaceholderFormat(squirrel.Dollar).
+		Columns(
+			consts.SongColumn,
+			consts.ArtistIDColumn,
+			consts.ReleaseDateColumn,
+			consts.ReleaseDatePrecisionColumn,
+			consts.TextColumn,
+			consts.LinkColumn,
+			consts.EnrichmentStatusColumn,
+		).
+		Values(song.Song, artistID, releaseDate, precision, song.Text, song.Link, status).
+		Suffix("RETURNING " + consts.IDColumn).
+		RunWith(tx).QueryRowContext(ctx).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+
+	if pending {
+		if err = enqueueEnrichment(ctx, tx, id); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = importAlbum(ctx, tx, artistID, id, song); err != nil {
+		return nil, err
+	}
+
+	return &models.ImportRow{Row: song.Row, Status: models.ImportCreated, SongID: id}, nil
+}
+
+// importAlbum puts the imported song, new or a duplicate, on the album the row names.
+func importAlbum(ctx context.Context, tx *sqlx.Tx, artistID, songID int, song *models.ImportSong) error {
+	if song.Album == "" {
+		return nil
+	}
+
+	return attachAlbum(ctx, tx, artistID, songID, &models.SongAlbum{
+		Title:       song.Album,
+		DiscNumber:  song.DiscNumber,
+		TrackNumber: song.TrackNumber,
+	})
+}
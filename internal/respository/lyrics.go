@@ -0,0 +1,114 @@
+package respository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"songs-library/internal/consts"
+	"songs-library/internal/models"
+	"songs-library/pkg/tracing"
+	"time"
+)
+
+func (r *Repository) ListSections(ctx context.Context, songID int) (models.Sections, error) {
+	const op = "repository.ListSections"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	q := squirrel.
+		Select(consts.PositionColumn, consts.TypeColumn, consts.OrdinalColumn, consts.LabelColumn, consts.TextColumn).
+		PlaceholderFormat(squirrel.Dollar).
+		From(consts.SongSectionsTableName).
+		Where(squirrel.Eq{consts.SongIDColumn: songID}).
+		OrderBy(consts.PositionColumn + " ASC")
+
+	rows, err := q.RunWith(r.conn()).QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close()
+
+	var sections models.Sections
+
+	for rows.Next() {
+		var section models.Section
+		if err = rows.Scan(
+			&section.Position,
+			&section.Type,
+			&section.Ordinal,
+			&section.Label,
+			&section.Text); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		sections = append(sections, section)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return sections, nil
+}
+
+// SaveSections stores the sections parsed from text. Nothing is stored if the song text
+// has changed since it was read, as the sections would be stale right away.
+func (r *Repository) SaveSections(ctx context.Context, songID int, text string, sections models.Sections) error {
+	const op = "repository.SaveSections"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	if len(sections) == 0 {
+		return nil
+	}
+
+	q := squirrel.Insert(consts.SongSectionsTableName).
+		PlaceholderFormat(squirrel.Dollar).
+		Columns(
+			consts.SongIDColumn,
+			consts.PositionColumn,
+			consts.TypeColumn,
+			consts.OrdinalColumn,
+			consts.LabelColumn,
+			consts.TextColumn,
+		).
+		Suffix("ON CONFLICT (" + consts.SongIDColumn + ", " + consts.PositionColumn + ") DO NOTHING")
+
+	for _, s := range sections {
+		q = q.Values(songID, s.Position, s.Type, s.Ordinal, s.Label, s.Text)
+	}
+
+	err := r.inTx(ctx, func(tx *sqlx.Tx) error {
+		var current sql.NullString
+		err := squirrel.Select(consts.TextColumn).
+			PlaceholderFormat(squirrel.Dollar).
+			From(consts.SongsTableName).
+			Where(squirrel.Eq{consts.IDColumn: songID}).
+			Suffix("FOR SHARE").
+			RunWith(tx).QueryRowContext(ctx).Scan(&current)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrSongNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		if current.String != text {
+			return nil
+		}
+
+		_, err = q.RunWith(tx).ExecContext(ctx)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,516 @@
+package respository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"songs-library/internal/consts"
+	"songs-library/internal/converter"
+	"songs-library/internal/models"
+	"songs-library/pkg/tracing"
+	"time"
+)
+
+var (
+	ErrPlaylistNotFound      = errors.New("playlist not found")
+	ErrPlaylistEntryNotFound = errors.New("playlist entry not found")
+)
+
+const (
+	// positionGap is the distance between the positions of entries added to the end of a playlist
+	// and of all entries once the positions are spread out again.
+	positionGap = 1024.0
+	// minPositionGap is the smallest distance kept between positions before they are spread out again.
+	minPositionGap = 1e-6
+)
+
+// playlistEntriesQuery selects the entries of a playlist in order. An entry whose song was deleted
+// has the names copied to it and no song id.
+const playlistEntriesQuery = `
+SELECT e.id,
+       row_number() OVER (ORDER BY e.position, e.id),
+       songs.id IS NOT NULL,
+       coalesce(songs.id, 0),
+       coalesce(songs.song, e.song),
+       coalesce(songs.artist_id, 0),
+       coalesce(artists.name, e.group_name),
+       ` + consts.ReleaseDateExpr + `,
+       coalesce(songs.link, ''),
+       coalesce(songs.enrichment_status, ''),
+       coalesce(songs.version, 0)
+FROM playlist_entries e
+LEFT JOIN songs ON songs.id = e.song_id
+LEFT JOIN artists ON artists.id = songs.artist_id
+WHERE e.playlist_id = $1
+ORDER BY e.position, e.id`
+
+// spreadPositionsQuery renumbers the positions of a playlist's entries positionGap apart, keeping their order.
+const spreadPositionsQuery = `
+UPDATE playlist_entries e SET position = o.n * $2::float8
+FROM (SELECT id, row_number() OVER (ORDER BY position, id) AS n FROM playlist_entries WHERE playlist_id = $1) o
+WHERE e.id = o.id`
+
+// duplicatePlaylistQuery copies a playlist, named $2 or after the original.
+const duplicatePlaylistQuery = `
+INSERT INTO playlists (name, description)
+SELECT coalesce(nullif($2, ''), name || ' (copy)'), description FROM playlists WHERE id = $1
+RETURNING id`
+
+// copyPlaylistEntriesQuery copies the entries of playlist $1 to playlist $2 in the same positions.
+const copyPlaylistEntriesQuery = `
+INSERT INTO playlist_entries (playlist_id, song_id, position, song, group_name)
+SELECT $2, song_id, position, song, group_name FROM playlist_entries WHERE playlist_id = $1`
+
+// keepPlaylistEntriesQuery makes the entries of a song unavailable, copying the song and group names to them.
+const keepPlaylistEntriesQuery = `
+UPDATE playlist_entries e SET song_id = NULL, song = songs.song, group_name = artists.name
+FROM songs JOIN artists ON artists.id = songs.artist_id
+WHERE songs.id = $1 AND e.song_id = songs.id`
+
+func (r *Repository) CreatePlaylist(ctx context.Context, playlist *models.CreatePlaylist) (int, error) {
+	const op = "repository.CreatePlaylist"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	var id int
+	err := squirrel.Insert(consts.PlaylistsTableName).
+		PlaceholderFormat(squirrel.Dollar).
+		Columns(consts.NameColumn, consts.DescriptionColumn).
+		Values(playlist.Name, playlist.Description).
+		Suffix("RETURNING " + consts.IDColumn).
+		RunWith(r.conn()).QueryRowContext(ctx).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+func (r *Repository) GetPlaylist(ctx context.Context, id int) (*models.PlaylistWithEntries, error) {
+	const op = "repository.GetPlaylist"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	var playlist models.PlaylistWithEntries
+	err := selectPlaylists().
+		Where(squirrel.Eq{consts.PlaylistsIDColumn: id}).
+		RunWith(r.conn()).QueryRowContext(ctx).
+		Scan(playlistDest(&playlist.Playlist)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPlaylistNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.conn().QueryContext(ctx, playlistEntriesQuery, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	playlist.Entries = make([]models.PlaylistEntry, 0, playlist.EntriesCount)
+	for rows.Next() {
+		var entry models.PlaylistEntry
+		if err = rows.Scan(append([]any{&entry.ID, &entry.Position, &entry.Available}, songDest(&entry.Song)...)...); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		playlist.Entries = append(playlist.Entries, entry)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &playlist, nil
+}
+
+func (r *Repository) ListPlaylists(ctx context.Context, filter *models.PlaylistsFilter) (models.Playlists, error) {
+	const op = "repository.ListPlaylists"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	q := selectPlaylists().
+		OrderBy(consts.PlaylistsIDColumn + " ASC")
+
+	q = converter.PlaylistsFilterToSqlFilters(q, filter)
+
+	rows, err := q.RunWith(r.conn()).QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	playlists := make(models.Playlists, 0, filter.Limit)
+	for rows.Next() {
+		var playlist models.Playlist
+		if err = rows.Scan(playlistDest(&playlist)...); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		playlists = append(playlists, playlist)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return playlists, nil
+}
+
+func (r *Repository) UpdatePlaylist(ctx context.Context, playlist *models.UpdatePlaylist) error {
+	const op = "repository.UpdatePlaylist"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	q := squirrel.Update(consts.PlaylistsTableName).
+		PlaceholderFormat(squirrel.Dollar).
+		Set(consts.NameColumn, playlist.Name).
+		Set(consts.DescriptionColumn, playlist.Description).
+		Set(consts.UpdatedAtColumn, squirrel.Expr("now()")).
+		Where(squirrel.Eq{consts.IDColumn: playlist.ID})
+
+	if err := execOne(ctx, q.RunWith(r.conn()), ErrPlaylistNotFound); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// DeletePlaylist deletes the playlist and its entries. The songs stay in the library.
+func (r *Repository) DeletePlaylist(ctx context.Context, id int) error {
+	const op = "repository.DeletePlaylist"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	q := squirrel.Delete(consts.PlaylistsTableName).
+		PlaceholderFormat(squirrel.Dollar).
+		Where(squirrel.Eq{consts.IDColumn: id})
+
+	if err := execOne(ctx, q.RunWith(r.conn()), ErrPlaylistNotFound); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// DuplicatePlaylist copies the playlist with its entries, unavailable ones included, and returns the id of the copy.
+func (r *Repository) DuplicatePlaylist(ctx context.Context, id int, name string) (int, error) {
+	const op = "repository.DuplicatePlaylist"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	var copyID int
+	err := r.inTx(ctx, func(tx *sqlx.Tx) error {
+		err := tx.QueryRowContext(ctx, duplicatePlaylistQuery, id, name).Scan(&copyID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrPlaylistNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, copyPlaylistEntriesQuery, id, copyID)
+
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return copyID, nil
+}
+
+// AddPlaylistEntries adds the songs in order at the position, or to the end without one.
+// A song that doesn't exist is ErrSongNotFound.
+func (r *Repository) AddPlaylistEntries(ctx context.Context, playlistID int, in *models.AddPlaylistEntries) error {
+	const op = "repository.AddPlaylistEntries"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	err := r.inTx(ctx, func(tx *sqlx.Tx) error {
+		if err := lockPlaylist(ctx, tx, playlistID); err != nil {
+			return err
+		}
+
+		if err := checkExist(ctx, tx, consts.SongsTableName, in.SongIDs, ErrSongNotFound); err != nil {
+			return err
+		}
+
+		positions, err := entryPositions(ctx, tx, playlistID, in.Position, len(in.SongIDs), 0)
+		if err != nil {
+			return err
+		}
+
+		q := squirrel.Insert(consts.PlaylistEntriesTableName).
+			PlaceholderFormat(squirrel.Dollar).
+			Columns(consts.PlaylistIDColumn, consts.SongIDColumn, consts.PositionColumn)
+
+		for i, songID := range in.SongIDs {
+			q = q.Values(playlistID, songID, positions[i])
+		}
+
+		_, err = q.RunWith(tx).ExecContext(ctx)
+
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// MovePlaylistEntry moves the entry to the position. Only the moved entry changes, unless the positions
+// around the new place are too close and the playlist is spread out again.
+func (r *Repository) MovePlaylistEntry(ctx context.Context, playlistID, entryID, position int) error {
+	const op = "repository.MovePlaylistEntry"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	err := r.inTx(ctx, func(tx *sqlx.Tx) error {
+		if err := lockPlaylist(ctx, tx, playlistID); err != nil {
+			return err
+		}
+
+		var id int
+		err := squirrel.Select(consts.IDColumn).
+			PlaceholderFormat(squirrel.Dollar).
+			From(consts.PlaylistEntriesTableName).
+			Where(squirrel.Eq{consts.IDColumn: entryID, consts.PlaylistIDColumn: playlistID}).
+			RunWith(tx).QueryRowContext(ctx).Scan(&id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrPlaylistEntryNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		positions, err := entryPositions(ctx, tx, playlistID, position, 1, entryID)
+		if err != nil {
+			return err
+		}
+
+		_, err = squirrel.Update(consts.PlaylistEntriesTableName).
+			PlaceholderFormat(squirrel.Dollar).
+			Set(consts.PositionColumn, positions[0]).
+			Where(squirrel.Eq{consts.IDColumn: entryID}).
+			RunWith(tx).ExecContext(ctx)
+
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *Repository) RemovePlaylistEntry(ctx context.Context, playlistID, entryID int) error {
+	const op = "repository.RemovePlaylistEntry"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	q := squirrel.Delete(consts.PlaylistEntriesTableName).
+		PlaceholderFormat(squirrel.Dollar).
+		Where(squirrel.Eq{consts.IDColumn: entryID, consts.PlaylistIDColumn: playlistID})
+
+	err := r.inTx(ctx, func(tx *sqlx.Tx) error {
+		if err := lockPlaylist(ctx, tx, playlistID); err != nil {
+			return err
+		}
+
+		return execOne(ctx, q.RunWith(tx), ErrPlaylistEntryNotFound)
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// lockPlaylist marks the playlist updated, locking it so concurrent changes of its entries take turns.
+func lockPlaylist(ctx context.Context, tx *sqlx.Tx, id int) error {
+	q := squirrel.Update(consts.PlaylistsTableName).
+		PlaceholderFormat(squirrel.Dollar).
+		Set(consts.UpdatedAtColumn, squirrel.Expr("now()")).
+		Where(squirrel.Eq{consts.IDColumn: id})
+
+	return execOne(ctx, q.RunWith(tx), ErrPlaylistNotFound)
+}
+
+// entryPositions returns n increasing positions for entries placed at the position, counted from 1,
+// between the entries now around it; past the end or at 0 the entries go to the end. The entry except
+// is left out, so that it can be moved. If the neighbours are too close for n entries between them,
+// the playlist is spread out first.
+func entryPositions(ctx context.Context, tx *sqlx.Tx, playlistID, position, n, except int) ([]float64, error) {
+	prev, next, err := neighbourPositions(ctx, tx, playlistID, position, except)
+	if err != nil {
+		return nil, err
+	}
+
+	if positions, ok := positionsBetween(prev, next, n); ok {
+		return positions, nil
+	}
+
+	if _, err = tx.ExecContext(ctx, spreadPositionsQuery, playlistID, positionGap); err != nil {
+		return nil, err
+	}
+
+	prev, next, err = neighbourPositions(ctx, tx, playlistID, position, except)
+	if err != nil {
+		return nil, err
+	}
+
+	positions, _ := positionsBetween(prev, next, n)
+
+	return positions, nil
+}
+
+// neighbourPositions returns the positions of the entries before and after the place, nil where there is none.
+func neighbourPositions(ctx context.Context, tx *sqlx.Tx, playlistID, position, except int) (prev, next *float64, err error) {
+	q := squirrel.Select(consts.PositionColumn).
+		PlaceholderFormat(squirrel.Dollar).
+		From(consts.PlaylistEntriesTableName).
+		Where(squirrel.Eq{consts.PlaylistIDColumn: playlistID}).
+		Where(squirrel.NotEq{consts.IDColumn: except})
+
+	if position > 0 {
+		rows, err := q.OrderBy(consts.PositionColumn, consts.IDColumn).
+			Offset(uint64(max(position-2, 0))).
+			Limit(2).
+			RunWith(tx).QueryContext(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer rows.Close()
+
+		var positions []float64
+		for rows.Next() {
+			var p float64
+			if err = rows.Scan(&p); err != nil {
+				return nil, nil, err
+			}
+
+			positions = append(positions, p)
+		}
+		if err = rows.Err(); err != nil {
+			return nil, nil, err
+		}
+
+		switch {
+		case position == 1 && len(positions) > 0:
+			return nil, &positions[0], nil
+		case position == 1:
+			return nil, nil, nil
+		case len(positions) == 2:
+			return &positions[0], &positions[1], nil
+		case len(positions) == 1:
+			return &positions[0], nil, nil
+		}
+	}
+
+	var last sql.NullFloat64
+	err = q.RemoveColumns().Columns("max(" + consts.PositionColumn + ")").
+		RunWith(tx).QueryRowContext(ctx).Scan(&last)
+	if err != nil || !last.Valid {
+		return nil, nil, err
+	}
+
+	return &last.Float64, nil, nil
+}
+
+// positionsBetween returns n increasing positions between prev and next, either of which may be missing.
+// It reports false if the positions would be too close together.
+func positionsBetween(prev, next *float64, n int) ([]float64, bool) {
+	positions := make([]float64, n)
+
+	switch {
+	case prev == nil && next == nil:
+		for i := range positions {
+			positions[i] = float64(i+1) * positionGap
+		}
+	case next == nil:
+		for i := range positions {
+			positions[i] = *prev + float64(i+1)*positionGap
+		}
+	case prev == nil:
+		for i := range positions {
+			positions[i] = *next - float64(n-i)*positionGap
+		}
+	default:
+		step := (*next - *prev) / float64(n+1)
+		if step < minPositionGap {
+			return nil, false
+		}
+
+		for i := range positions {
+			positions[i] = *prev + float64(i+1)*step
+		}
+	}
+
+	return positions, true
+}
+
+// detachPlaylistEntries deletes the playlist entries of a song about to be deleted, or keeps them
+// as unavailable, as the repository is configured.
+func (r *Repository) detachPlaylistEntries(ctx context.Context, tx *sqlx.Tx, songID int) error {
+	if r.opts.PlaylistOnSongDelete == models.PlaylistOnSongDeleteCascade {
+		_, err := squirrel.Delete(consts.PlaylistEntriesTableName).
+			PlaceholderFormat(squirrel.Dollar).
+			Where(squirrel.Eq{consts.SongIDColumn: songID}).
+			RunWith(tx).ExecContext(ctx)
+
+		return err
+	}
+
+	_, err := tx.ExecContext(ctx, keepPlaylistEntriesQuery, songID)
+
+	return err
+}
+
+// movePlaylistEntries points the playlist entries of the sources to the target, keeping their places.
+func movePlaylistEntries(ctx context.Context, tx *sqlx.Tx, sourceIDs []int, targetID int) error {
+	_, err := squirrel.Update(consts.PlaylistEntriesTableName).
+		PlaceholderFormat(squirrel.Dollar).
+		Set(consts.SongIDColumn, targetID).
+		Where(squirrel.Eq{consts.SongIDColumn: sourceIDs}).
+		RunWith(tx).ExecContext(ctx)
+
+	return err
+}
+
+func selectPlaylists() squirrel.SelectBuilder {
+	return squirrel.
+		Select(
+			consts.PlaylistsIDColumn,
+			consts.PlaylistsNameColumn,
+			consts.DescriptionColumn,
+			"count("+consts.PlaylistEntriesIDColumn+") AS "+consts.EntriesCountColumn,
+		).
+		PlaceholderFormat(squirrel.Dollar).
+		From(consts.PlaylistsTableName).
+		LeftJoin(consts.PlaylistEntriesTableName + " ON " + consts.PlaylistEntriesPlaylistID + " = " + consts.PlaylistsIDColumn).
+		GroupBy(consts.PlaylistsIDColumn)
+}
+
+// playlistDest lists the scan destinations of the selectPlaylists columns.
+func playlistDest(playlist *models.Playlist) []any {
+	return []any{
+		&playlist.ID,
+		&playlist.Name,
+		&playlist.Description,
+		&playlist.EntriesCount,
+	}
+}
@@ -0,0 +1,41 @@
+package respository
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPositionsBetween(t *testing.T) {
+	pos := func(p float64) *float64 { return &p }
+
+	tests := []struct {
+		name   string
+		prev   *float64
+		next   *float64
+		n      int
+		want   []float64
+		wantOK bool
+	}{
+		{name: "empty playlist", n: 3, want: []float64{1024, 2048, 3072}, wantOK: true},
+		{name: "after the last entry", prev: pos(2048), n: 2, want: []float64{3072, 4096}, wantOK: true},
+		{name: "before the first entry", next: pos(1024), n: 2, want: []float64{-1024, 0}, wantOK: true},
+		{name: "between two entries", prev: pos(1024), next: pos(2048), n: 3, want: []float64{1280, 1536, 1792}, wantOK: true},
+		{name: "one between two entries", prev: pos(0), next: pos(1), n: 1, want: []float64{0.5}, wantOK: true},
+		{name: "no positions", prev: pos(1), next: pos(2), n: 0, want: []float64{}, wantOK: true},
+		{name: "too close together", prev: pos(1), next: pos(1 + 3e-6), n: 3},
+		{name: "same position", prev: pos(1), next: pos(1), n: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := positionsBetween(tt.prev, tt.next, tt.n)
+			if ok != tt.wantOK {
+				t.Fatalf("positionsBetween() ok = %v, want %v", ok, tt.wantOK)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("positionsBetween() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package respository
+
+import (
+	"context"
+	"fmt"
+	"songs-library/pkg/ratelimit"
+	"songs-library/pkg/tracing"
+	"time"
+)
+
+// takeTokenQuery refills the bucket $1 for the time since it was last used, at $3 tokens a second up to $2,
+// and takes a token if there is a whole one. A new bucket starts full. The refill is computed from the
+// stored row in the same statement, so concurrent requests from several instances don't lose tokens.
+const takeTokenQuery = `
+INSERT INTO rate_limit_buckets AS b (key, tokens, allowed, updated_at)
+VALUES ($1, $2::float8 - 1, true, now())
+ON CONFLICT (key) DO UPDATE SET
+    allowed = least($2::float8, b.tokens + extract(epoch FROM now() - b.updated_at)::float8 * $3::float8) >= 1,
+    tokens = least($2::float8, b.tokens + extract(epoch FROM now() - b.updated_at)::float8 * $3::float8)
+        - CASE WHEN least($2::float8, b.tokens + extract(epoch FROM now() - b.updated_at)::float8 * $3::float8) >= 1
+            THEN 1 ELSE 0 END,
+    updated_at = now()
+RETURNING tokens, allowed`
+
+// RateLimitStore keeps the token buckets in Postgres, so the instances of the server share the limits.
+type RateLimitStore struct {
+	repo *Repository
+	// idle is how long a bucket stays after its last request; past the longest period it is full anyway.
+	idle time.Duration
+}
+
+// RateLimitStore returns a store for the limits; Sweep deletes the buckets unused for idle.
+func (r *Repository) RateLimitStore(idle time.Duration) *RateLimitStore {
+	return &RateLimitStore{repo: r, idle: idle}
+}
+
+func (s *RateLimitStore) Take(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Result, error) {
+	const op = "repository.RateLimitStore.Take"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	var (
+		tokens  float64
+		allowed bool
+	)
+	err := s.repo.db.QueryRowContext(ctx, takeTokenQuery, key, float64(limit.Requests), limit.Rate()).
+		Scan(&tokens, &allowed)
+	if err != nil {
+		return ratelimit.Result{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return ratelimit.NewResult(limit, tokens, allowed), nil
+}
+
+// Sweep deletes the buckets left idle and returns how many it deleted.
+func (s *RateLimitStore) Sweep(ctx context.Context) (int64, error) {
+	const op = "repository.RateLimitStore.Sweep"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	res, err := s.repo.db.ExecContext(ctx,
+		"DELETE FROM rate_limit_buckets WHERE updated_at < now() - $1 * interval '1 second'", s.idle.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return n, nil
+}
@@ -1,26 +1,46 @@
 package respository
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	"github.com/Masterminds/squirrel"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
 	"log/slog"
+	"slices"
 	"songs-library/internal/consts"
 	"songs-library/internal/converter"
 	"songs-library/internal/models"
+	"songs-library/internal/releasedate"
+	"songs-library/pkg/tracing"
+	"time"
 )
 
-var ErrSongNotFound = errors.New("song not found")
+var (
+	ErrSongNotFound        = errors.New("song not found")
+	ErrSongVersionMismatch = errors.New("song version mismatch")
+)
 
 type Repository struct {
-	log *slog.Logger
-	db  *sqlx.DB
+	log           *slog.Logger
+	db            *sqlx.DB
+	opts          Options
+	queryDuration *prometheus.HistogramVec
+	// uow is set on the copy of the repository WithTx hands over.
+	uow *unitOfWork
+}
+
+type Options struct {
+	// PlaylistOnSongDelete is models.PlaylistOnSongDeleteKeep or models.PlaylistOnSongDeleteCascade.
+	PlaylistOnSongDelete string
+	// Metrics, if set, gets the timings of the repository methods and the connection pool stats.
+	Metrics prometheus.Registerer
 }
 
-func NewRepository(conn string) (*Repository, error) {
+func NewRepository(conn string, opts Options) (*Repository, error) {
 	db, err := sqlx.Connect("postgres", conn)
 	if err != nil {
 		return nil, err
@@ -30,24 +50,66 @@ func NewRepository(conn string) (*Repository, error) {
 		return nil, err
 	}
 
-	return &Repository{db: db}, nil
+	r := &Repository{db: db, opts: opts}
+	r.registerMetrics(opts.Metrics)
+
+	return r, nil
 }
 
 func (r *Repository) Close() error {
 	return r.db.Close()
 }
 
-func (r *Repository) CreateSong(song *models.Song) (int, error) {
+// DB returns the connection pool of the repository, for the migrations to run on.
+func (r *Repository) DB() *sql.DB {
+	return r.db.DB
+}
+
+// CreateSong inserts the song. With unique set, a song the artist already has under the same normalized
+// title is not inserted again: a *models.DuplicateSongError with its id is returned instead.
+func (r *Repository) CreateSong(ctx context.Context, song *models.Song, unique bool) (int, error) {
 	const op = "repository.CreateSong"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	releaseDate, precision, err := releaseDateValues(song.ReleaseDate)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
 
 	q := squirrel.Insert(consts.SongsTableName).
 		PlaceholderFormat(squirrel.Dollar).
-		Columns(consts.SongColumn, consts.GroupColumn, consts.ReleaseDateColumn, consts.TextColumn, consts.LinkColumn).
-		Values(song.Song, song.Group, song.ReleaseDate, song.Text, song.Link).
+		Columns(
+			consts.SongColumn,
+			consts.ArtistIDColumn,
+			consts.ReleaseDateColumn,
+			consts.ReleaseDatePrecisionColumn,
+			consts.TextColumn,
+			consts.LinkColumn,
+			consts.EnrichmentStatusColumn,
+		).
+		Values(song.Song, song.ArtistID, releaseDate, precision, song.Text, song.Link, song.EnrichmentStatus).
 		Suffix("RETURNING id")
 
 	var id int
-	err := q.RunWith(r.db).QueryRow().Scan(&id)
+	err = r.inTx(ctx, func(tx *sqlx.Tx) error {
+		if unique {
+			if err := lockArtistSongs(ctx, tx, song.ArtistID); err != nil {
+				return err
+			}
+
+			existing, err := findDuplicate(ctx, tx, song.ArtistID, song.Song)
+			if err == nil {
+				return &models.DuplicateSongError{SongID: existing}
+			}
+			if !errors.Is(err, sql.ErrNoRows) {
+				return err
+			}
+		}
+
+		return q.RunWith(tx).QueryRowContext(ctx).Scan(&id)
+	})
 	if err != nil {
 		return 0, fmt.Errorf("%s: %w", op, err)
 	}
@@ -55,101 +117,244 @@ func (r *Repository) CreateSong(song *models.Song) (int, error) {
 	return id, nil
 }
 
-func (r *Repository) UpdateSong(song *models.UpdateSong) error {
+// UpdateSong writes the song and sets its new version. With song.IfMatch set, a song at another version is
+// left as is and ErrSongVersionMismatch is returned.
+func (r *Repository) UpdateSong(ctx context.Context, song *models.UpdateSong) error {
 	const op = "repository.UpdateSong"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	releaseDate, precision, err := releaseDateValues(song.ReleaseDate)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
 
 	q := squirrel.Update(consts.SongsTableName).
 		PlaceholderFormat(squirrel.Dollar).
 		Set(consts.SongColumn, song.Song).
-		Set(consts.GroupColumn, song.Group).
-		Set(consts.ReleaseDateColumn, song.ReleaseDate).
+		Set(consts.ArtistIDColumn, song.ArtistID).
+		Set(consts.ReleaseDateColumn, releaseDate).
+		Set(consts.ReleaseDatePrecisionColumn, precision).
 		Set(consts.TextColumn, song.Text).
 		Set(consts.LinkColumn, song.Link).
-		Where(squirrel.Eq{consts.IDColumn: song.ID})
+		Where(squirrel.Eq{consts.IDColumn: song.ID}).
+		Suffix("RETURNING " + consts.VersionColumn)
 
-	res, err := q.RunWith(r.db).Exec()
-	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+	if len(song.IfMatch) != 0 {
+		q = q.Where(squirrel.Eq{consts.VersionColumn: song.IfMatch})
 	}
 
-	rowsAffected, err := res.RowsAffected()
+	err = r.inTx(ctx, func(tx *sqlx.Tx) error {
+		err := q.RunWith(tx).QueryRowContext(ctx).Scan(&song.Version)
+		if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		var exists bool
+		err = tx.QueryRowContext(ctx, songExistsQuery, song.ID).Scan(&exists)
+		if err != nil {
+			return err
+		}
+
+		if !exists {
+			return ErrSongNotFound
+		}
+
+		return ErrSongVersionMismatch
+	})
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	if rowsAffected == 0 {
-		return ErrSongNotFound
-	}
-
 	return nil
 }
 
-func (r *Repository) DeleteSong(id int) error {
+func (r *Repository) DeleteSong(ctx context.Context, id int) error {
 	const op = "repository.DeleteSong"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
 
 	q := squirrel.Delete(consts.SongsTableName).
 		PlaceholderFormat(squirrel.Dollar).
 		Where(squirrel.Eq{consts.IDColumn: id})
 
-	res, err := q.RunWith(r.db).Exec()
-	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
-	}
+	err := r.inTx(ctx, func(tx *sqlx.Tx) error {
+		if err := r.detachPlaylistEntries(ctx, tx, id); err != nil {
+			return err
+		}
 
-	rowsAffected, err := res.RowsAffected()
+		return execOne(ctx, q.RunWith(tx), ErrSongNotFound)
+	})
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	if rowsAffected == 0 {
-		return ErrSongNotFound
-	}
-
 	return nil
 }
 
-func (r *Repository) ListSongs(filter *models.SongsFilter) (models.Songs, error) {
+// ListSongs returns a page of songs in the order of the filter's sort. Pages are taken by offset or, with a cursor,
+// by key values, which neither skips nor repeats songs when others are added between requests.
+func (r *Repository) ListSongs(ctx context.Context, filter *models.SongsFilter) (*models.SongsPage, error) {
 	const op = "repository.ListSongs"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
 
-	q := squirrel.
-		Select(consts.IDColumn, consts.SongColumn, consts.GroupColumn, consts.ReleaseDateColumn, consts.LinkColumn).
-		PlaceholderFormat(squirrel.Dollar).
-		From(consts.SongsTableName).
-		OrderBy(consts.IDColumn + " ASC")
+	cursor, err := filter.DecodeCursor()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	q := selectSongs()
+
+	if filter.Lyrics != "" {
+		q = converter.LyricsColumns(q, filter)
+	}
 
 	q = converter.SongFilterToSqlFilters(q, filter)
+	q = converter.SongsOrder(q, filter, cursor)
+	q = converter.SongsPagination(q, filter)
+
+	if filter.Lyrics != "" {
+		q = converter.LyricsSnippets(q, filter, cursor)
+	}
 
-	rows, err := q.RunWith(r.db).Query()
+	rows, err := q.RunWith(r.conn()).QueryContext(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	songs := make([]models.Song, 0, filter.Limit)
+	defer rows.Close()
+
+	songs := make([]models.Song, 0, filter.Limit+1)
+	keys := make([][]string, 0, filter.Limit+1)
 
 	for rows.Next() {
 		var song models.Song
-		if err = rows.Scan(
-			&song.ID,
-			&song.Song,
-			&song.Group,
-			&song.ReleaseDate,
-			&song.Link); err != nil {
+
+		dest := songDest(&song)
+		if filter.Lyrics != "" {
+			dest = append(dest, &song.Rank)
+		}
+
+		key := make([]string, converter.SortKeysCount(filter))
+		for i := range key {
+			dest = append(dest, &key[i])
+		}
+
+		if filter.Lyrics != "" {
+			dest = append(dest, &song.Snippet)
+		}
+
+		if err = rows.Scan(dest...); err != nil {
 			return nil, fmt.Errorf("%s: %w", op, err)
 		}
 
 		songs = append(songs, song)
+		keys = append(keys, key)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	page := songsPage(filter, cursor, songs, keys)
+
+	if filter.WithTotal {
+		total, err := r.countSongs(ctx, filter)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		page.Total = &total
+	}
+
+	return page, nil
+}
+
+// songsPage trims the extra song selected past the page and makes the cursors of the neighbouring pages.
+// Songs selected backwards from a prev cursor are put back in order.
+func songsPage(filter *models.SongsFilter, cursor *models.Cursor, songs models.Songs, keys [][]string) *models.SongsPage {
+	backward := cursor != nil && cursor.Prev
+
+	more := len(songs) > filter.Limit
+	if more {
+		songs, keys = songs[:filter.Limit], keys[:filter.Limit]
+	}
+
+	if backward {
+		slices.Reverse(songs)
+		slices.Reverse(keys)
+	}
+
+	page := models.SongsPage{Songs: songs}
+	if len(songs) == 0 {
+		return &page
+	}
+
+	sort := models.FormatSort(filter.SortKeys())
+
+	// Going forward there is a previous page if the list didn't start at its beginning, and the other way round.
+	hasNext, hasPrev := more, cursor != nil || filter.Page > 1
+	if backward {
+		hasNext, hasPrev = true, more
 	}
 
-	err = rows.Close()
+	if hasNext {
+		page.NextCursor = models.EncodeCursor(&models.Cursor{Sort: sort, Values: keys[len(keys)-1]})
+	}
+
+	if hasPrev {
+		page.PrevCursor = models.EncodeCursor(&models.Cursor{Sort: sort, Prev: true, Values: keys[0]})
+	}
+
+	return &page
+}
+
+// countSongs counts the songs that match the filter on all pages.
+func (r *Repository) countSongs(ctx context.Context, filter *models.SongsFilter) (int, error) {
+	q := squirrel.Select("count(*)").
+		PlaceholderFormat(squirrel.Dollar).
+		From(consts.SongsTableName).
+		Join(consts.ArtistsTableName + " ON " + consts.ArtistsIDColumn + " = " + consts.SongsArtistIDColumn)
+
+	var total int
+	err := converter.SongFilterToSqlFilters(q, filter).RunWith(r.conn()).QueryRowContext(ctx).Scan(&total)
+
+	return total, err
+}
+
+// GetSong returns the song with its text.
+func (r *Repository) GetSong(ctx context.Context, id int) (*models.Song, error) {
+	const op = "repository.GetSong"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	var song models.Song
+
+	err := selectSongs().
+		Column(consts.TextColumn).
+		Where(squirrel.Eq{consts.SongsIDColumn: id}).
+		RunWith(r.conn()).QueryRowContext(ctx).
+		Scan(append(songDest(&song), &song.Text)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSongNotFound
+	}
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return songs, nil
+	return &song, nil
 }
 
-func (r *Repository) GetTextBySongID(songID int) (string, error) {
+func (r *Repository) GetTextBySongID(ctx context.Context, songID int) (string, error) {
 	const op = "repository.GetTextBySongID"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
 
 	q := squirrel.Select(consts.TextColumn).
 		PlaceholderFormat(squirrel.Dollar).
@@ -157,7 +362,7 @@ func (r *Repository) GetTextBySongID(songID int) (string, error) {
 		Where(squirrel.Eq{consts.IDColumn: songID})
 
 	var text string
-	err := q.RunWith(r.db).QueryRow().Scan(&text)
+	err := q.RunWith(r.conn()).QueryRowContext(ctx).Scan(&text)
 	if errors.Is(err, sql.ErrNoRows) {
 		return "", ErrSongNotFound
 	}
@@ -167,3 +372,74 @@ func (r *Repository) GetTextBySongID(songID int) (string, error) {
 
 	return text, nil
 }
+
+const songExistsQuery = "SELECT EXISTS (SELECT 1 FROM " + consts.SongsTableName + " WHERE " + consts.IDColumn + " = $1)"
+
+func selectSongs() squirrel.SelectBuilder {
+	return squirrel.
+		Select(
+			consts.SongsIDColumn,
+			consts.SongColumn,
+			consts.SongsArtistIDColumn,
+			consts.ArtistsNameColumn,
+			consts.ReleaseDateExpr,
+			consts.LinkColumn,
+			consts.EnrichmentStatusColumn,
+			consts.VersionColumn,
+		).
+		PlaceholderFormat(squirrel.Dollar).
+		From(consts.SongsTableName).
+		Join(consts.ArtistsTableName + " ON " + consts.ArtistsIDColumn + " = " + consts.SongsArtistIDColumn)
+}
+
+// songDest lists the scan destinations of the selectSongs columns.
+func songDest(song *models.Song) []any {
+	return []any{
+		&song.ID,
+		&song.Song,
+		&song.ArtistID,
+		&song.Group,
+		&song.ReleaseDate,
+		&song.Link,
+		&song.EnrichmentStatus,
+		&song.Version,
+	}
+}
+
+// releaseDateValues returns the release_date and release_date_precision of a date in any known format.
+// An empty date is stored as unknown.
+func releaseDateValues(s string) (any, string, error) {
+	if s == "" {
+		return nil, releasedate.PrecisionDay, nil
+	}
+
+	date, err := releasedate.Parse(s)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %q", models.ErrInvalidReleaseDate, s)
+	}
+
+	return date.Time.Format(time.DateOnly), date.Precision, nil
+}
+
+type execerContext interface {
+	ExecContext(ctx context.Context) (sql.Result, error)
+}
+
+// execOne runs an update or delete and returns notFound if it did not touch any row.
+func execOne(ctx context.Context, q execerContext, notFound error) error {
+	res, err := q.ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return notFound
+	}
+
+	return nil
+}
@@ -0,0 +1,225 @@
+package respository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"songs-library/internal/consts"
+	"songs-library/internal/models"
+	"songs-library/pkg/tracing"
+	"time"
+)
+
+var ErrRevisionNotFound = errors.New("revision not found")
+
+// ListRevisions returns the history of the song, oldest first. Snapshots are listed without the text.
+func (r *Repository) ListRevisions(ctx context.Context, songID int) (models.Revisions, error) {
+	const op = "repository.ListRevisions"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	q := selectRevisions(consts.SnapshotColumn + " - '" + consts.TextColumn + "'").
+		Where(squirrel.Eq{consts.SongIDColumn: songID}).
+		OrderBy(consts.RevisionColumn + " ASC")
+
+	rows, err := q.RunWith(r.conn()).QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close()
+
+	var revisions models.Revisions
+
+	for rows.Next() {
+		revision, err := scanRevision(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		revisions = append(revisions, *revision)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(revisions) == 0 {
+		return nil, ErrSongNotFound
+	}
+
+	return revisions, nil
+}
+
+func (r *Repository) GetRevision(ctx context.Context, songID, revision int) (*models.Revision, error) {
+	const op = "repository.GetRevision"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	rev, err := getRevision(ctx, r.conn(), songID, revision)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRevisionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return rev, nil
+}
+
+// RestoreRevision writes the snapshot of the revision back to the song, recreating the song if it was deleted.
+// The artist is resolved by name, as the one the song had may have been merged away since. A snapshot holds
+// the song's own fields only: a recreated song comes back without its albums, tags, genres, synced lyrics
+// and playlist entries, with enrichment status ok and no enrichment job.
+//
+// It returns the latest revision of the song once restored: the one the restore made, or, if the song
+// already matched the snapshot and no revision was made, the one it was at.
+func (r *Repository) RestoreRevision(ctx context.Context, songID, revision int) (*models.Revision, error) {
+	const op = "repository.RestoreRevision"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	var restored *models.Revision
+
+	err := r.inTx(ctx, func(tx *sqlx.Tx) error {
+		_, err := tx.ExecContext(ctx, setRevisionActionQuery, models.RevisionRestore)
+		if err != nil {
+			return err
+		}
+
+		rev, err := getRevision(ctx, tx, songID, revision)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrRevisionNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		artistID, err := resolveArtist(ctx, tx, rev.Snapshot.Group)
+		if err != nil {
+			return err
+		}
+
+		releaseDate, precision, err := releaseDateValues(rev.Snapshot.ReleaseDate)
+		if err != nil {
+			return err
+		}
+
+		_, err = squirrel.Insert(consts.SongsTableName).
+			PlaceholderFormat(squirrel.Dollar).
+			Columns(
+				consts.IDColumn,
+				consts.SongColumn,
+				consts.ArtistIDColumn,
+				consts.ReleaseDateColumn,
+				consts.ReleaseDatePrecisionColumn,
+				consts.TextColumn,
+				consts.LinkColumn,
+				consts.EnrichmentStatusColumn,
+			).
+			Values(
+				songID,
+				rev.Snapshot.Song,
+				artistID,
+				releaseDate,
+				precision,
+				rev.Snapshot.Text,
+				rev.Snapshot.Link,
+				models.EnrichmentOK,
+			).
+			Suffix("ON CONFLICT (" + consts.IDColumn + ") DO UPDATE SET " +
+				upsertSet(
+					consts.SongColumn,
+					consts.ArtistIDColumn,
+					consts.ReleaseDateColumn,
+					consts.ReleaseDatePrecisionColumn,
+					consts.TextColumn,
+					consts.LinkColumn,
+				)).
+			RunWith(tx).ExecContext(ctx)
+		if err != nil {
+			return err
+		}
+
+		// The write holds the song's row until commit, so no other revision of it can come in between.
+		restored, err = scanRevision(selectRevisions(consts.SnapshotColumn).
+			Where(squirrel.Eq{consts.SongIDColumn: songID}).
+			OrderBy(consts.RevisionColumn + " DESC").
+			Limit(1).
+			RunWith(tx).QueryRowContext(ctx))
+
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return restored, nil
+}
+
+func getRevision(ctx context.Context, runner squirrel.BaseRunner, songID, revision int) (*models.Revision, error) {
+	row := selectRevisions(consts.SnapshotColumn).
+		Where(squirrel.Eq{consts.SongIDColumn: songID, consts.RevisionColumn: revision}).
+		RunWith(runner).QueryRowContext(ctx)
+
+	return scanRevision(row)
+}
+
+func selectRevisions(snapshot string) squirrel.SelectBuilder {
+	return squirrel.
+		Select(
+			consts.SongIDColumn,
+			consts.RevisionColumn,
+			consts.ActionColumn,
+			consts.AuthorColumn,
+			consts.CreatedAtColumn,
+			snapshot,
+		).
+		PlaceholderFormat(squirrel.Dollar).
+		From(consts.SongRevisionsTableName)
+}
+
+func scanRevision(row squirrel.RowScanner) (*models.Revision, error) {
+	var (
+		revision models.Revision
+		snapshot []byte
+	)
+
+	err := row.Scan(
+		&revision.SongID,
+		&revision.Revision,
+		&revision.Action,
+		&revision.Author,
+		&revision.CreatedAt,
+		&snapshot,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = json.Unmarshal(snapshot, &revision.Snapshot); err != nil {
+		return nil, err
+	}
+
+	return &revision, nil
+}
+
+// upsertSet lists columns for ON CONFLICT DO UPDATE, taking each value from the rejected row.
+func upsertSet(columns ...string) string {
+	set := ""
+	for i, column := range columns {
+		if i > 0 {
+			set += ", "
+		}
+		set += column + " = EXCLUDED." + column
+	}
+
+	return set
+}
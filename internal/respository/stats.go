@@ -0,0 +1,94 @@
+package respository
+
+import (
+	"context"
+	"fmt"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"songs-library/internal/models"
+	"songs-library/pkg/tracing"
+	"time"
+)
+
+const libraryStatsQuery = `
+SELECT (SELECT count(*) FROM songs),
+       (SELECT count(*) FROM artists),
+       (SELECT count(*) FROM albums),
+       (SELECT count(*) FROM playlists),
+       count(*) FILTER (WHERE failed_at IS NULL AND locked_at IS NULL),
+       count(*) FILTER (WHERE failed_at IS NULL AND locked_at IS NOT NULL),
+       count(*) FILTER (WHERE failed_at IS NOT NULL)
+FROM enrichment_jobs`
+
+func (r *Repository) LibraryStats(ctx context.Context) (*models.LibraryStats, error) {
+	const op = "repository.LibraryStats"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	var stats models.LibraryStats
+	err := r.conn().QueryRowContext(ctx, libraryStatsQuery).Scan(
+		&stats.Songs,
+		&stats.Artists,
+		&stats.Albums,
+		&stats.Playlists,
+		&stats.EnrichmentQueued,
+		&stats.EnrichmentRunning,
+		&stats.EnrichmentFailed,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &stats, nil
+}
+
+// registerMetrics adds the timings of the repository methods and the stats of the connection pool.
+// Without a registerer the timings are still kept, only not served.
+func (r *Repository) registerMetrics(reg prometheus.Registerer) {
+	factory := promauto.With(reg)
+
+	r.queryDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "repository_query_duration_seconds",
+		Help:    "Time spent in repository methods, by op.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"op"})
+
+	factory.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "db_max_open_connections",
+		Help: "Maximum number of open connections to the database.",
+	}, func() float64 { return float64(r.db.Stats().MaxOpenConnections) })
+	factory.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "db_open_connections",
+		Help: "Established connections, in use and idle.",
+	}, func() float64 { return float64(r.db.Stats().OpenConnections) })
+	factory.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "db_in_use_connections",
+		Help: "Connections in use.",
+	}, func() float64 { return float64(r.db.Stats().InUse) })
+	factory.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "db_idle_connections",
+		Help: "Idle connections.",
+	}, func() float64 { return float64(r.db.Stats().Idle) })
+	factory.NewCounterFunc(prometheus.CounterOpts{
+		Name: "db_wait_count_total",
+		Help: "Connections waited for.",
+	}, func() float64 { return float64(r.db.Stats().WaitCount) })
+	factory.NewCounterFunc(prometheus.CounterOpts{
+		Name: "db_wait_duration_seconds_total",
+		Help: "Time blocked waiting for a connection.",
+	}, func() float64 { return r.db.Stats().WaitDuration.Seconds() })
+	factory.NewCounterFunc(prometheus.CounterOpts{
+		Name: "db_max_idle_closed_total",
+		Help: "Connections closed due to the idle connections limit.",
+	}, func() float64 { return float64(r.db.Stats().MaxIdleClosed) })
+	factory.NewCounterFunc(prometheus.CounterOpts{
+		Name: "db_max_lifetime_closed_total",
+		Help: "Connections closed due to their maximum lifetime.",
+	}, func() float64 { return float64(r.db.Stats().MaxLifetimeClosed) })
+}
+
+// observe records the time the repository method op took since start.
+func (r *Repository) observe(op string, start time.Time) {
+	r.queryDuration.WithLabelValues(op).Observe(time.Since(start).Seconds())
+}
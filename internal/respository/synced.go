@@ -0,0 +1,131 @@
+package respository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"songs-library/internal/consts"
+	"songs-library/internal/models"
+	"songs-library/pkg/tracing"
+	"time"
+)
+
+var ErrSyncedLyricsNotFound = errors.New("synced lyrics not found")
+
+// SaveSyncedLyrics replaces the synced lyrics of the song and sets its plain text to text.
+func (r *Repository) SaveSyncedLyrics(ctx context.Context, lyrics *models.SyncedLyrics, text string) error {
+	const op = "repository.SaveSyncedLyrics"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	tags, err := json.Marshal(lyrics.Tags)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	lines := squirrel.Insert(consts.SyncedLyricsLinesTableName).
+		PlaceholderFormat(squirrel.Dollar).
+		Columns(consts.SongIDColumn, consts.PositionColumn, consts.TimeMsColumn, consts.TextColumn)
+
+	for i, line := range lyrics.Lines {
+		lines = lines.Values(lyrics.SongID, i+1, line.TimeMs, line.Text)
+	}
+
+	err = r.inTx(ctx, func(tx *sqlx.Tx) error {
+		err := execOne(ctx, squirrel.Update(consts.SongsTableName).
+			PlaceholderFormat(squirrel.Dollar).
+			Set(consts.TextColumn, text).
+			Where(squirrel.Eq{consts.IDColumn: lyrics.SongID}).
+			RunWith(tx), ErrSongNotFound)
+		if err != nil {
+			return err
+		}
+
+		_, err = squirrel.Insert(consts.SyncedLyricsTableName).
+			PlaceholderFormat(squirrel.Dollar).
+			Columns(consts.SongIDColumn, consts.TagsColumn, consts.OffsetMsColumn).
+			Values(lyrics.SongID, string(tags), lyrics.OffsetMs).
+			Suffix("ON CONFLICT (" + consts.SongIDColumn + ") DO UPDATE SET " +
+				consts.TagsColumn + " = EXCLUDED." + consts.TagsColumn + ", " +
+				consts.OffsetMsColumn + " = EXCLUDED." + consts.OffsetMsColumn + ", " +
+				consts.UpdatedAtColumn + " = now()").
+			RunWith(tx).ExecContext(ctx)
+		if err != nil {
+			return err
+		}
+
+		_, err = squirrel.Delete(consts.SyncedLyricsLinesTableName).
+			PlaceholderFormat(squirrel.Dollar).
+			Where(squirrel.Eq{consts.SongIDColumn: lyrics.SongID}).
+			RunWith(tx).ExecContext(ctx)
+		if err != nil {
+			return err
+		}
+
+		_, err = lines.RunWith(tx).ExecContext(ctx)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *Repository) GetSyncedLyrics(ctx context.Context, songID int) (*models.SyncedLyrics, error) {
+	const op = "repository.GetSyncedLyrics"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	lyrics := models.SyncedLyrics{SongID: songID}
+
+	var tags []byte
+	err := squirrel.Select(consts.TagsColumn, consts.OffsetMsColumn).
+		PlaceholderFormat(squirrel.Dollar).
+		From(consts.SyncedLyricsTableName).
+		Where(squirrel.Eq{consts.SongIDColumn: songID}).
+		RunWith(r.conn()).QueryRowContext(ctx).Scan(&tags, &lyrics.OffsetMs)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSyncedLyricsNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = json.Unmarshal(tags, &lyrics.Tags); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := squirrel.Select(consts.TimeMsColumn, consts.TextColumn).
+		PlaceholderFormat(squirrel.Dollar).
+		From(consts.SyncedLyricsLinesTableName).
+		Where(squirrel.Eq{consts.SongIDColumn: songID}).
+		OrderBy(consts.PositionColumn + " ASC").
+		RunWith(r.conn()).QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var line models.SyncedLine
+		if err = rows.Scan(&line.TimeMs, &line.Text); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		lyrics.Lines = append(lyrics.Lines, line)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &lyrics, nil
+}
@@ -0,0 +1,314 @@
+package respository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"songs-library/internal/consts"
+	"songs-library/internal/converter"
+	"songs-library/internal/models"
+	"songs-library/pkg/tracing"
+	"time"
+)
+
+var (
+	ErrTagNotFound      = errors.New("tag not found")
+	ErrTagAlreadyExists = errors.New("tag already exists")
+)
+
+func (r *Repository) CreateTag(ctx context.Context, tag *models.Tag) (int, error) {
+	const op = "repository.CreateTag"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	var id int
+	err := squirrel.Insert(consts.TagsTableName).
+		PlaceholderFormat(squirrel.Dollar).
+		Columns(consts.NameColumn, consts.NormalizedNameColumn).
+		Values(models.CleanArtistName(tag.Name), models.NormalizeArtistName(tag.Name)).
+		Suffix("RETURNING " + consts.IDColumn).
+		RunWith(r.conn()).QueryRowContext(ctx).Scan(&id)
+	if isUniqueViolation(err) {
+		return 0, ErrTagAlreadyExists
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+func (r *Repository) GetTag(ctx context.Context, id int) (*models.Tag, error) {
+	const op = "repository.GetTag"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	var tag models.Tag
+	err := selectTags().
+		Where(squirrel.Eq{consts.TagsIDColumn: id}).
+		RunWith(r.conn()).QueryRowContext(ctx).Scan(&tag.ID, &tag.Name, &tag.SongsCount)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTagNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &tag, nil
+}
+
+func (r *Repository) ListTags(ctx context.Context, filter *models.TagsFilter) (models.Tags, error) {
+	const op = "repository.ListTags"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	q := selectTags().
+		OrderBy(consts.TagsNormalizedNameColumn + " ASC")
+
+	q = converter.TagsFilterToSqlFilters(q, filter)
+
+	tags, err := queryTags(ctx, q.RunWith(r.conn()), filter.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tags, nil
+}
+
+func (r *Repository) UpdateTag(ctx context.Context, tag *models.UpdateTag) error {
+	const op = "repository.UpdateTag"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	res, err := squirrel.Update(consts.TagsTableName).
+		PlaceholderFormat(squirrel.Dollar).
+		Set(consts.NameColumn, models.CleanArtistName(tag.Name)).
+		Set(consts.NormalizedNameColumn, models.NormalizeArtistName(tag.Name)).
+		Where(squirrel.Eq{consts.IDColumn: tag.ID}).
+		RunWith(r.conn()).ExecContext(ctx)
+	if isUniqueViolation(err) {
+		return ErrTagAlreadyExists
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrTagNotFound
+	}
+
+	return nil
+}
+
+// DeleteTag deletes the tag and takes it from its songs.
+func (r *Repository) DeleteTag(ctx context.Context, id int) error {
+	const op = "repository.DeleteTag"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	res, err := squirrel.Delete(consts.TagsTableName).
+		PlaceholderFormat(squirrel.Dollar).
+		Where(squirrel.Eq{consts.IDColumn: id}).
+		RunWith(r.conn()).ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrTagNotFound
+	}
+
+	return nil
+}
+
+// TagSongs adds the tags and genres to the songs in one transaction, creating the tags not seen before.
+// A song or genre that doesn't exist fails the whole call.
+func (r *Repository) TagSongs(ctx context.Context, in *models.TagSongs) (*models.Tagged, error) {
+	const op = "repository.TagSongs"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	var tagged models.Tagged
+	err := r.inTx(ctx, func(tx *sqlx.Tx) error {
+		err := checkExist(ctx, tx, consts.SongsTableName, in.SongIDs, ErrSongNotFound)
+		if err != nil {
+			return err
+		}
+
+		if tagged.Tags, err = addSongTags(ctx, tx, in.SongIDs, in.Tags); err != nil {
+			return err
+		}
+
+		tagged.Genres, err = addSongGenres(ctx, tx, in.SongIDs, in.GenreIDs)
+
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &tagged, nil
+}
+
+// UntagSongs removes the tags and genres from the songs. Unknown tags are skipped; tags left without songs
+// are kept.
+func (r *Repository) UntagSongs(ctx context.Context, in *models.TagSongs) (*models.Tagged, error) {
+	const op = "repository.UntagSongs"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	var tagged models.Tagged
+	err := r.inTx(ctx, func(tx *sqlx.Tx) (err error) {
+		if tagged.Tags, err = removeSongTags(ctx, tx, in.SongIDs, in.Tags); err != nil {
+			return err
+		}
+
+		tagged.Genres, err = removeSongGenres(ctx, tx, in.SongIDs, in.GenreIDs)
+
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &tagged, nil
+}
+
+func (r *Repository) ListSongTags(ctx context.Context, songID int) (models.Tags, error) {
+	const op = "repository.ListSongTags"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+	defer r.observe(op, time.Now())
+
+	q := selectTags().
+		Where(consts.TagsIDColumn+" IN (SELECT "+consts.TagIDColumn+" FROM "+
+			consts.SongTagsTableName+" WHERE "+consts.SongIDColumn+" = ?)", songID).
+		OrderBy(consts.TagsNormalizedNameColumn + " ASC")
+
+	tags, err := queryTags(ctx, q.RunWith(r.conn()), 0)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tags, nil
+}
+
+// resolveTag returns the id of the tag with the same normalized name, creating the tag if there is none.
+func resolveTag(ctx context.Context, runner squirrel.BaseRunner, name string) (int, error) {
+	var id int
+	err := squirrel.Insert(consts.TagsTableName).
+		PlaceholderFormat(squirrel.Dollar).
+		Columns(consts.NameColumn, consts.NormalizedNameColumn).
+		Values(models.CleanArtistName(name), models.NormalizeArtistName(name)).
+		Suffix("ON CONFLICT (" + consts.NormalizedNameColumn + ") DO UPDATE SET " +
+			consts.NormalizedNameColumn + " = EXCLUDED." + consts.NormalizedNameColumn + " RETURNING id").
+		RunWith(runner).QueryRowContext(ctx).Scan(&id)
+
+	return id, err
+}
+
+func selectTags() squirrel.SelectBuilder {
+	return squirrel.
+		Select(
+			consts.TagsIDColumn,
+			consts.TagsNameColumn,
+			"count("+consts.SongTagsSongIDColumn+") AS "+consts.SongsCountColumn,
+		).
+		PlaceholderFormat(squirrel.Dollar).
+		From(consts.TagsTableName).
+		LeftJoin(consts.SongTagsTableName + " ON " + consts.SongTagsTagIDColumn + " = " +
+			consts.TagsIDColumn).
+		GroupBy(consts.TagsIDColumn)
+}
+
+func queryTags(ctx context.Context, q squirrel.SelectBuilder, capacity int) (models.Tags, error) {
+	rows, err := q.QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := make(models.Tags, 0, capacity)
+	for rows.Next() {
+		var tag models.Tag
+		if err = rows.Scan(&tag.ID, &tag.Name, &tag.SongsCount); err != nil {
+			return nil, err
+		}
+
+		tags = append(tags, tag)
+	}
+
+	return tags, rows.Err()
+}
+
+func addSongTags(ctx context.Context, tx *sqlx.Tx, songIDs []int, tags []string) (int, error) {
+	if len(tags) == 0 {
+		return 0, nil
+	}
+
+	tagIDs := make([]int, 0, len(tags))
+	for _, tag := range tags {
+		id, err := resolveTag(ctx, tx, tag)
+		if err != nil {
+			return 0, err
+		}
+
+		tagIDs = append(tagIDs, id)
+	}
+
+	res, err := tx.ExecContext(ctx, `
+INSERT INTO song_tags (song_id, tag_id)
+SELECT s.song_id, t.tag_id FROM unnest($1::int[]) AS s(song_id) CROSS JOIN unnest($2::int[]) AS t(tag_id)
+ON CONFLICT DO NOTHING`, pq.Array(songIDs), pq.Array(tagIDs))
+	if err != nil {
+		return 0, err
+	}
+
+	added, err := res.RowsAffected()
+
+	return int(added), err
+}
+
+func removeSongTags(ctx context.Context, tx *sqlx.Tx, songIDs []int, tags []string) (int, error) {
+	if len(tags) == 0 {
+		return 0, nil
+	}
+
+	res, err := squirrel.Delete(consts.SongTagsTableName).
+		PlaceholderFormat(squirrel.Dollar).
+		Where(squirrel.Eq{consts.SongIDColumn: songIDs}).
+		Where(
+			consts.TagIDColumn+" IN (SELECT "+consts.IDColumn+" FROM "+consts.TagsTableName+
+				" WHERE "+consts.NormalizedNameColumn+" = ANY(?))",
+			pq.Array(models.NormalizeTagNames(tags)),
+		).
+		RunWith(tx).ExecContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	removed, err := res.RowsAffected()
+
+	return int(removed), err
+}
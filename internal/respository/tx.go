@@ -0,0 +1,222 @@
+package respository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"math/rand/v2"
+	"songs-library/internal"
+	"songs-library/pkg/principal"
+	"time"
+)
+
+const (
+	setAuthorQuery         = "SELECT set_config('app.author', $1, true)"
+	setRevisionActionQuery = "SELECT set_config('app.revision_action', $1, true)"
+)
+
+const (
+	serializationFailureCode = "40001"
+	deadlockDetectedCode     = "40P01"
+)
+
+const (
+	// maxTxAttempts bounds the runs of a unit of work that keeps failing to serialize.
+	maxTxAttempts = 5
+	txRetryDelay  = 20 * time.Millisecond
+)
+
+// dbtx runs statements: on the pool, or on the transaction of a unit of work.
+type dbtx interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// unitOfWork is the transaction shared by the repository methods run in WithTx.
+type unitOfWork struct {
+	tx         *sqlx.Tx
+	savepoints int
+}
+
+// conn is where the methods run their statements: the transaction of the unit of work, or the pool.
+func (r *Repository) conn() dbtx {
+	if r.uow != nil {
+		return r.uow.tx
+	}
+
+	return r.db
+}
+
+// WithTx runs fn in a transaction and commits it if fn succeeds. Every method of the repository fn gets
+// runs in the transaction; opts sets its isolation level, nil for the default. A transaction that fails
+// to serialize or deadlocks is rolled back and fn is run again, so fn must not keep state between runs.
+// Called within a unit of work, WithTx runs fn under a savepoint of it instead: an error rolls back only
+// what fn did, and opts is ignored.
+func (r *Repository) WithTx(ctx context.Context, opts *sql.TxOptions, fn func(repo internal.Repository) error) error {
+	if r.uow != nil {
+		return r.inSavepoint(ctx, func() error {
+			return fn(r)
+		})
+	}
+
+	return retryTx(ctx, func() error {
+		return r.runTx(ctx, opts, fn)
+	})
+}
+
+// retryTx runs the transaction run until it commits or fails for a reason other than losing
+// to a concurrent transaction, at most maxTxAttempts times.
+func retryTx(ctx context.Context, run func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := run()
+		if !isRetryable(err) || attempt == maxTxAttempts {
+			return err
+		}
+
+		// Jitter keeps the transactions that conflicted from conflicting again.
+		delay := txRetryDelay<<(attempt-1) + rand.N(txRetryDelay)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		}
+	}
+}
+
+func (r *Repository) runTx(ctx context.Context, opts *sql.TxOptions, fn func(repo internal.Repository) error) (err error) {
+	tx, err := r.db.BeginTxx(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if _, err = tx.ExecContext(ctx, setAuthorQuery, principal.Name(ctx)); err != nil {
+		return err
+	}
+
+	txRepo := *r
+	txRepo.uow = &unitOfWork{tx: tx}
+
+	if err = fn(&txRepo); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// inTx runs fn in a transaction and commits it if fn succeeds. The author from ctx is set
+// for the transaction, so the songs revision trigger records who made the change. Like WithTx it runs fn
+// again when the transaction fails to serialize or deadlocks, so fn must not keep state between runs.
+// Within a unit of work fn runs under a savepoint of its transaction, and the unit of work retries.
+func (r *Repository) inTx(ctx context.Context, fn func(tx *sqlx.Tx) error) error {
+	if r.uow != nil {
+		return r.inSavepoint(ctx, func() error {
+			if err := fn(r.uow.tx); err != nil {
+				return err
+			}
+
+			// The action set for this method's revisions must not stick to later methods of the unit of work.
+			_, err := r.uow.tx.ExecContext(ctx, setRevisionActionQuery, "")
+			return err
+		})
+	}
+
+	return retryTx(ctx, func() error {
+		return r.runInTx(ctx, fn)
+	})
+}
+
+func (r *Repository) runInTx(ctx context.Context, fn func(tx *sqlx.Tx) error) (err error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if _, err = tx.ExecContext(ctx, setAuthorQuery, principal.Name(ctx)); err != nil {
+		return err
+	}
+
+	if err = fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// readTx returns a transaction for a read that needs one, and the func that ends it. Within a unit of work
+// it is the transaction of the unit of work under a savepoint, with its isolation level rather than the one of opts.
+func (r *Repository) readTx(ctx context.Context, opts *sql.TxOptions) (*sqlx.Tx, func(), error) {
+	if r.uow == nil {
+		tx, err := r.db.BeginTxx(ctx, opts)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return tx, func() { _ = tx.Rollback() }, nil
+	}
+
+	name, err := r.savepoint(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return r.uow.tx, func() { r.rollbackTo(ctx, name) }, nil
+}
+
+// inSavepoint runs fn under a savepoint, rolling back to it if fn fails.
+func (r *Repository) inSavepoint(ctx context.Context, fn func() error) error {
+	name, err := r.savepoint(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = fn(); err != nil {
+		r.rollbackTo(ctx, name)
+		return err
+	}
+
+	_, err = r.uow.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name)
+
+	return err
+}
+
+func (r *Repository) savepoint(ctx context.Context) (string, error) {
+	r.uow.savepoints++
+	name := fmt.Sprintf("sp_%d", r.uow.savepoints)
+
+	_, err := r.uow.tx.ExecContext(ctx, "SAVEPOINT "+name)
+
+	return name, err
+}
+
+// rollbackTo undoes the work since the savepoint and drops it. A failure leaves the transaction aborted,
+// which the unit of work reports when it commits.
+func (r *Repository) rollbackTo(ctx context.Context, name string) {
+	ctx = context.WithoutCancel(ctx)
+
+	if _, err := r.uow.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name); err == nil {
+		_, _ = r.uow.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name)
+	}
+}
+
+// isRetryable tells a transaction that lost to a concurrent one, and may succeed when run again.
+func isRetryable(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && (pqErr.Code == serializationFailureCode || pqErr.Code == deadlockDetectedCode)
+}
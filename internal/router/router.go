@@ -3,47 +3,198 @@ package router
 import (
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	httpSwagger "github.com/swaggo/http-swagger"
 	"log/slog"
 	"songs-library/internal/api/http"
+	"songs-library/internal/consts"
+	"songs-library/pkg/health"
 	"songs-library/pkg/middlewares"
+	"songs-library/pkg/principal"
+	"songs-library/pkg/ratelimit"
+	"time"
 )
 
+// requestTimeout matches the server write timeout: past it the response can't be sent, so the work is cancelled.
+const requestTimeout = 10 * time.Second
+
 type Router struct {
 	log     *slog.Logger
 	handler *http.Handler
+	opts    Options
+}
+
+type Options struct {
+	Auth middlewares.AuthOptions
+	// RateLimitStore keeps the rate limit buckets. Without it requests are not limited.
+	RateLimitStore ratelimit.Store
+	RateLimits     RateLimits
+	// Metrics, if set, gets the request metrics and is served at /metrics.
+	Metrics *prometheus.Registry
+	// Health, if set, is served at /healthz and /readyz.
+	Health *health.Checker
 }
 
-func NewRouter(log *slog.Logger, handler *http.Handler) *Router {
+// RateLimits are the limits of each client per route group.
+type RateLimits struct {
+	// IP limits each address before authentication, so failed and guessed credentials are limited too.
+	IP     ratelimit.Limit
+	Reads  ratelimit.Limit
+	Writes ratelimit.Limit
+	// Enrichment limits the calls that enqueue enrichment jobs: creating, importing and requeueing songs.
+	Enrichment ratelimit.Limit
+}
+
+func NewRouter(log *slog.Logger, handler *http.Handler, opts Options) *Router {
 	return &Router{
 		log:     log,
 		handler: handler,
+		opts:    opts,
 	}
 }
 
 func (r *Router) Init() *chi.Mux {
 	router := chi.NewRouter()
 	router.Use(middleware.RequestID)
+	router.Use(middlewares.NewMiddlewareTracing())
 	router.Use(middlewares.NewMiddlewareLogger(r.log))
+	if r.opts.Metrics != nil {
+		router.Use(middlewares.NewMiddlewareMetrics(r.opts.Metrics))
+	}
 	router.Use(middleware.Recoverer)
 	router.Use(middleware.URLFormat)
 	router.Route("/api", func(router chi.Router) {
 		router.Route("/v1", func(router chi.Router) {
-			router.Route("/songs", func(router chi.Router) {
-				router.Post("/", r.handler.CreateSong)
-				router.Delete("/{id}", r.handler.DeleteSong)
-				router.Put("/", r.handler.UpdateSong)
-				router.Post("/list", r.handler.ListSongs)
-				router.Route("/texts", func(router chi.Router) {
-					router.Get("/", r.handler.GetTextBySongID)
+			// The principal is unknown before auth, so this limit counts each IP.
+			router.Use(r.rateLimit("ip", r.opts.RateLimits.IP)...)
+			// Every role may read; changes need an editor, and keys an admin.
+			router.Use(middlewares.NewMiddlewareAuth(r.log, r.opts.Auth))
+			editor := middlewares.NewMiddlewareRole(principal.RoleEditor)
+			admin := middlewares.NewMiddlewareRole(principal.RoleAdmin)
+
+			// Each route counts against the limit of its group.
+			read := r.rateLimit("reads", r.opts.RateLimits.Reads)
+			writes := r.rateLimit("writes", r.opts.RateLimits.Writes)
+			write := append(chi.Middlewares{editor}, writes...)
+			enrich := append(chi.Middlewares{editor}, r.rateLimit("enrichment", r.opts.RateLimits.Enrichment)...)
+
+			// The export streams for as long as the library takes, past the request timeout.
+			router.With(read...).Get("/songs/export", r.handler.ExportSongs)
+			// An import reads and stores the whole document, it has a longer timeout of its own.
+			router.With(enrich...).With(middleware.Timeout(consts.ImportTimeout)).
+				Post("/songs/import", r.handler.ImportSongs)
+
+			router.Group(func(router chi.Router) {
+				router.Use(middleware.Timeout(requestTimeout))
+
+				router.Route("/songs", func(router chi.Router) {
+					router.With(enrich...).Post("/", r.handler.CreateSong)
+					router.With(read...).Get("/duplicates", r.handler.ListDuplicates)
+					router.With(read...).Get("/{id}", r.handler.GetSong)
+					router.With(write...).Delete("/{id}", r.handler.DeleteSong)
+					router.With(write...).Patch("/{id}", r.handler.PatchSong)
+					router.With(write...).Post("/{id}/merge", r.handler.MergeSongs)
+					router.With(read...).Get("/{id}/tags", r.handler.GetSongTags)
+					router.With(write...).Post("/tags/add", r.handler.TagSongs)
+					router.With(write...).Post("/tags/remove", r.handler.UntagSongs)
+					router.With(read...).Get("/{id}/enrichment", r.handler.GetEnrichment)
+					router.With(enrich...).Post("/enrichment/requeue", r.handler.RequeueEnrichment)
+					router.Route("/{id}/lyrics/synced", func(router chi.Router) {
+						router.With(write...).Put("/", r.handler.UploadSyncedLyrics)
+						router.With(read...).Get("/", r.handler.GetSyncedLyrics)
+						router.With(read...).Get("/active", r.handler.GetActiveLine)
+						router.With(read...).Get("/export", r.handler.ExportSyncedLyrics)
+					})
+					router.Route("/{id}/revisions", func(router chi.Router) {
+						router.With(read...).Get("/", r.handler.ListRevisions)
+						router.With(read...).Get("/diff", r.handler.DiffRevisions)
+						router.With(read...).Get("/{revision}", r.handler.GetRevision)
+						router.With(write...).Post("/{revision}/restore", r.handler.RestoreRevision)
+					})
+					router.With(write...).Put("/", r.handler.UpdateSong)
+					router.With(read...).Post("/list", r.handler.ListSongs)
+					router.Route("/texts", func(router chi.Router) {
+						router.With(read...).Get("/", r.handler.GetTextBySongID)
+					})
+				})
+				router.Route("/albums", func(router chi.Router) {
+					router.With(write...).Post("/", r.handler.CreateAlbum)
+					router.With(write...).Put("/", r.handler.UpdateAlbum)
+					router.With(read...).Post("/list", r.handler.ListAlbums)
+					router.With(read...).Get("/{id}", r.handler.GetAlbum)
+					router.With(write...).Delete("/{id}", r.handler.DeleteAlbum)
+				})
+				router.Route("/genres", func(router chi.Router) {
+					router.With(write...).Post("/", r.handler.CreateGenre)
+					router.With(write...).Put("/", r.handler.UpdateGenre)
+					router.With(read...).Get("/", r.handler.ListGenres)
+					router.With(read...).Get("/{id}", r.handler.GetGenre)
+					router.With(write...).Delete("/{id}", r.handler.DeleteGenre)
+				})
+				router.Route("/tags", func(router chi.Router) {
+					router.With(write...).Post("/", r.handler.CreateTag)
+					router.With(write...).Put("/", r.handler.UpdateTag)
+					router.With(read...).Post("/list", r.handler.ListTags)
+					router.With(write...).Delete("/{id}", r.handler.DeleteTag)
+				})
+				router.Route("/playlists", func(router chi.Router) {
+					router.With(write...).Post("/", r.handler.CreatePlaylist)
+					router.With(write...).Put("/", r.handler.UpdatePlaylist)
+					router.With(read...).Post("/list", r.handler.ListPlaylists)
+					router.With(read...).Get("/{id}", r.handler.GetPlaylist)
+					router.With(write...).Delete("/{id}", r.handler.DeletePlaylist)
+					router.With(write...).Post("/{id}/duplicate", r.handler.DuplicatePlaylist)
+					router.With(read...).Get("/{id}/export", r.handler.ExportPlaylist)
+					router.With(write...).Post("/{id}/entries", r.handler.AddPlaylistEntries)
+					router.With(write...).Post("/{id}/entries/{entry_id}/move", r.handler.MovePlaylistEntry)
+					router.With(write...).Delete("/{id}/entries/{entry_id}", r.handler.RemovePlaylistEntry)
+				})
+				router.Route("/admin/keys", func(router chi.Router) {
+					router.Use(admin)
+					router.Use(writes...)
+
+					router.Post("/", r.handler.IssueAPIKey)
+					router.Get("/", r.handler.ListAPIKeys)
+					router.Post("/{id}/rotate", r.handler.RotateAPIKey)
+					router.Delete("/{id}", r.handler.RevokeAPIKey)
+				})
+				router.Route("/artists", func(router chi.Router) {
+					router.With(write...).Post("/", r.handler.CreateArtist)
+					router.With(write...).Put("/", r.handler.UpdateArtist)
+					router.With(read...).Post("/list", r.handler.ListArtists)
+					router.With(read...).Get("/{id}", r.handler.GetArtist)
+					router.With(write...).Post("/{id}/merge", r.handler.MergeArtists)
 				})
 			})
 		})
 	})
 
+	if r.opts.Health != nil {
+		router.Get("/healthz", r.opts.Health.Liveness)
+		router.Get("/readyz", r.opts.Health.Readiness)
+	}
+
+	if r.opts.Metrics != nil {
+		// A failed collector is logged and the other metrics are still served.
+		router.Get("/metrics", promhttp.InstrumentMetricHandler(r.opts.Metrics, promhttp.HandlerFor(r.opts.Metrics, promhttp.HandlerOpts{
+			ErrorLog:      slog.NewLogLogger(r.log.With(slog.String("component", "metrics")).Handler(), slog.LevelError),
+			ErrorHandling: promhttp.ContinueOnError,
+		})).ServeHTTP)
+	}
+
 	router.Get("/swagger/*", httpSwagger.Handler(
 		httpSwagger.URL("http://localhost:8080/swagger/doc.json"),
 	))
 
 	return router
 }
+
+// rateLimit limits each client to the limit in the group; without a store it limits nothing.
+func (r *Router) rateLimit(group string, limit ratelimit.Limit) chi.Middlewares {
+	if r.opts.RateLimitStore == nil {
+		return nil
+	}
+
+	return chi.Middlewares{middlewares.NewMiddlewareRateLimit(r.log, r.opts.RateLimitStore, group, limit)}
+}
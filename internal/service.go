@@ -1,13 +1,76 @@
 package internal
 
 import (
+	"context"
+	"io"
 	"songs-library/internal/models"
+	"songs-library/pkg/principal"
 )
 
 type Service interface {
-	CreateSong(*models.CreateSong) (*models.Song, error)
-	UpdateSong(song *models.UpdateSong) (*models.UpdateSong, error)
-	DeleteSong(int) error
-	ListSongs(*models.SongsFilter) (models.Songs, error)
-	GetTextBySongID(*models.GetText) (*models.Text, error)
+	CreateSong(context.Context, *models.CreateSong) (*models.Song, error)
+	UpdateSong(ctx context.Context, song *models.UpdateSong) (*models.UpdateSong, error)
+	PatchSong(context.Context, *models.PatchSong) (*models.Song, error)
+	DeleteSong(context.Context, int) error
+	ListSongs(context.Context, *models.SongsFilter) (*models.SongsPage, error)
+	GetSong(context.Context, *models.GetSong) (*models.SongWithText, error)
+	ImportSongs(ctx context.Context, r io.Reader, opts *models.ImportSongs) (*models.ImportReport, error)
+	ExportSongs(ctx context.Context, in *models.ExportSongs, w io.Writer) error
+	GetTextBySongID(context.Context, *models.GetText) (*models.Text, error)
+	UploadSyncedLyrics(ctx context.Context, songID int, lrc string) (*models.SyncedLyrics, error)
+	GetSyncedLyrics(ctx context.Context, songID int) (*models.SyncedLyrics, error)
+	GetActiveLine(context.Context, *models.GetActiveLine) (*models.ActiveLine, error)
+	ExportSyncedLyrics(context.Context, *models.ExportSyncedLyrics) (string, error)
+	ListRevisions(ctx context.Context, songID int) (models.Revisions, error)
+	GetRevision(context.Context, *models.GetRevision) (*models.Revision, error)
+	DiffRevisions(context.Context, *models.DiffRevisions) (*models.RevisionDiff, error)
+	RestoreRevision(context.Context, *models.GetRevision) (*models.Revision, error)
+	ListDuplicates(context.Context, *models.DuplicatesFilter) (models.DuplicateClusters, error)
+	MergeSongs(context.Context, *models.MergeSongs) (*models.SongWithText, error)
+
+	CreateArtist(context.Context, *models.CreateArtist) (*models.Artist, error)
+	GetArtist(context.Context, int) (*models.Artist, error)
+	ListArtists(context.Context, *models.ArtistsFilter) (models.Artists, error)
+	UpdateArtist(context.Context, *models.UpdateArtist) (*models.Artist, error)
+	MergeArtists(context.Context, *models.MergeArtists) (*models.Artist, error)
+
+	CreateAlbum(context.Context, *models.CreateAlbum) (*models.AlbumWithTracks, error)
+	GetAlbum(context.Context, int) (*models.AlbumWithTracks, error)
+	ListAlbums(context.Context, *models.AlbumsFilter) (models.Albums, error)
+	UpdateAlbum(context.Context, *models.UpdateAlbum) (*models.AlbumWithTracks, error)
+	DeleteAlbum(context.Context, int) error
+
+	CreateGenre(context.Context, *models.CreateGenre) (*models.Genre, error)
+	GetGenre(context.Context, int) (*models.Genre, error)
+	ListGenres(context.Context) (models.Genres, error)
+	UpdateGenre(context.Context, *models.UpdateGenre) (*models.Genre, error)
+	DeleteGenre(context.Context, int) error
+
+	CreateTag(context.Context, *models.CreateTag) (*models.Tag, error)
+	ListTags(context.Context, *models.TagsFilter) (models.Tags, error)
+	UpdateTag(context.Context, *models.UpdateTag) (*models.Tag, error)
+	DeleteTag(context.Context, int) error
+	GetSongTags(ctx context.Context, songID int) (*models.SongTags, error)
+	TagSongs(context.Context, *models.TagSongs) (*models.Tagged, error)
+	UntagSongs(context.Context, *models.TagSongs) (*models.Tagged, error)
+
+	CreatePlaylist(context.Context, *models.CreatePlaylist) (*models.PlaylistWithEntries, error)
+	GetPlaylist(context.Context, int) (*models.PlaylistWithEntries, error)
+	ListPlaylists(context.Context, *models.PlaylistsFilter) (models.Playlists, error)
+	UpdatePlaylist(context.Context, *models.UpdatePlaylist) (*models.PlaylistWithEntries, error)
+	DeletePlaylist(context.Context, int) error
+	DuplicatePlaylist(ctx context.Context, id int, in *models.DuplicatePlaylist) (*models.PlaylistWithEntries, error)
+	AddPlaylistEntries(ctx context.Context, playlistID int, in *models.AddPlaylistEntries) (*models.PlaylistWithEntries, error)
+	MovePlaylistEntry(ctx context.Context, playlistID, entryID int, in *models.MovePlaylistEntry) (*models.PlaylistWithEntries, error)
+	RemovePlaylistEntry(ctx context.Context, playlistID, entryID int) error
+	ExportPlaylist(ctx context.Context, id int, format string, w io.Writer) error
+
+	GetEnrichment(ctx context.Context, songID int) (*models.Enrichment, error)
+	RequeueEnrichment(context.Context, *models.RequeueEnrichment) (*models.Requeued, error)
+
+	IssueAPIKey(context.Context, *models.IssueAPIKey) (*models.IssuedAPIKey, error)
+	ListAPIKeys(context.Context) (models.APIKeys, error)
+	RotateAPIKey(context.Context, int) (*models.IssuedAPIKey, error)
+	RevokeAPIKey(context.Context, int) error
+	AuthenticateAPIKey(ctx context.Context, key string) (principal.Principal, error)
 }
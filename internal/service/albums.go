@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"songs-library/internal/models"
+	"songs-library/pkg/logger/sl"
+	"songs-library/pkg/tracing"
+)
+
+func (s *Service) CreateAlbum(ctx context.Context, in *models.CreateAlbum) (*models.AlbumWithTracks, error) {
+	const op = "service.CreateAlbum"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	log := s.log.With(
+		slog.String("op", op),
+		sl.RequestID(ctx),
+		sl.Principal(ctx),
+		sl.TraceID(ctx),
+	)
+
+	id, err := s.repo.CreateAlbum(ctx, in)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("created album", slog.Int("albumID", id), slog.String("title", in.Title))
+
+	return s.repo.GetAlbum(ctx, id)
+}
+
+func (s *Service) GetAlbum(ctx context.Context, id int) (*models.AlbumWithTracks, error) {
+	const op = "service.GetAlbum"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	return s.repo.GetAlbum(ctx, id)
+}
+
+func (s *Service) ListAlbums(ctx context.Context, filter *models.AlbumsFilter) (models.Albums, error) {
+	const op = "service.ListAlbums"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	return s.repo.ListAlbums(ctx, filter)
+}
+
+func (s *Service) UpdateAlbum(ctx context.Context, in *models.UpdateAlbum) (*models.AlbumWithTracks, error) {
+	const op = "service.UpdateAlbum"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	log := s.log.With(
+		slog.String("op", op),
+		sl.RequestID(ctx),
+		sl.Principal(ctx),
+		sl.TraceID(ctx),
+	)
+
+	err := s.repo.UpdateAlbum(ctx, in)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("updated album", slog.Int("albumID", in.ID))
+
+	return s.repo.GetAlbum(ctx, in.ID)
+}
+
+func (s *Service) DeleteAlbum(ctx context.Context, id int) error {
+	const op = "service.DeleteAlbum"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	log := s.log.With(
+		slog.String("op", op),
+		sl.RequestID(ctx),
+		sl.Principal(ctx),
+		sl.TraceID(ctx),
+	)
+
+	err := s.repo.DeleteAlbum(ctx, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("deleted album", slog.Int("albumID", id))
+
+	return nil
+}
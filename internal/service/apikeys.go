@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"songs-library/internal/apikey"
+	"songs-library/internal/models"
+	"songs-library/internal/respository"
+	"songs-library/pkg/logger/sl"
+	"songs-library/pkg/middlewares"
+	"songs-library/pkg/principal"
+	"songs-library/pkg/tracing"
+)
+
+// IssueAPIKey creates a key. The key itself is returned only here; the library keeps just its hash.
+func (s *Service) IssueAPIKey(ctx context.Context, in *models.IssueAPIKey) (*models.IssuedAPIKey, error) {
+	const op = "service.IssueAPIKey"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	log := s.log.With(
+		slog.String("op", op),
+		sl.RequestID(ctx),
+		sl.Principal(ctx),
+		sl.TraceID(ctx),
+	)
+
+	key, prefix, hash, err := apikey.Generate()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	created, err := s.repo.CreateAPIKey(ctx, in, prefix, hash, principal.Name(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("issued api key", slog.Int("keyID", created.ID), slog.String("name", in.Name), slog.String("role", in.Role))
+
+	return &models.IssuedAPIKey{APIKey: *created, Key: key}, nil
+}
+
+func (s *Service) ListAPIKeys(ctx context.Context) (models.APIKeys, error) {
+	const op = "service.ListAPIKeys"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	return s.repo.ListAPIKeys(ctx)
+}
+
+// RotateAPIKey gives the key a new key, keeping its name and role. The old key stops working at once.
+func (s *Service) RotateAPIKey(ctx context.Context, id int) (*models.IssuedAPIKey, error) {
+	const op = "service.RotateAPIKey"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	log := s.log.With(
+		slog.String("op", op),
+		sl.RequestID(ctx),
+		sl.Principal(ctx),
+		sl.TraceID(ctx),
+	)
+
+	key, prefix, hash, err := apikey.Generate()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rotated, err := s.repo.RotateAPIKey(ctx, id, prefix, hash)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("rotated api key", slog.Int("keyID", id))
+
+	return &models.IssuedAPIKey{APIKey: *rotated, Key: key}, nil
+}
+
+func (s *Service) RevokeAPIKey(ctx context.Context, id int) error {
+	const op = "service.RevokeAPIKey"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	log := s.log.With(
+		slog.String("op", op),
+		sl.RequestID(ctx),
+		sl.Principal(ctx),
+		sl.TraceID(ctx),
+	)
+
+	err := s.repo.RevokeAPIKey(ctx, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("revoked api key", slog.Int("keyID", id))
+
+	return nil
+}
+
+// AuthenticateAPIKey returns the principal of a key that is neither revoked nor expired.
+// Any other key is middlewares.ErrInvalidCredentials.
+func (s *Service) AuthenticateAPIKey(ctx context.Context, key string) (principal.Principal, error) {
+	const op = "service.AuthenticateAPIKey"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	found, err := s.repo.FindAPIKey(ctx, apikey.Hash(key))
+	if errors.Is(err, respository.ErrAPIKeyNotFound) {
+		return principal.Principal{}, fmt.Errorf("%w: unknown, revoked or expired api key", middlewares.ErrInvalidCredentials)
+	}
+	if err != nil {
+		return principal.Principal{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return principal.Principal{
+		Name: models.APIKeyPrincipalName(found.Name),
+		Role: principal.Role(found.Role),
+	}, nil
+}
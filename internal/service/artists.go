@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"songs-library/internal/models"
+	"songs-library/pkg/logger/sl"
+	"songs-library/pkg/tracing"
+)
+
+func (s *Service) CreateArtist(ctx context.Context, in *models.CreateArtist) (*models.Artist, error) {
+	const op = "service.CreateArtist"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	log := s.log.With(
+		slog.String("op", op),
+		sl.RequestID(ctx),
+		sl.Principal(ctx),
+		sl.TraceID(ctx),
+	)
+
+	artist := models.Artist{
+		Name: models.CleanArtistName(in.Name),
+	}
+
+	id, err := s.repo.CreateArtist(ctx, &artist)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	artist.ID = id
+
+	log.Info("created artist", slog.Any("artist", artist))
+	return &artist, nil
+}
+
+func (s *Service) GetArtist(ctx context.Context, id int) (*models.Artist, error) {
+	const op = "service.GetArtist"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	return s.repo.GetArtist(ctx, id)
+}
+
+func (s *Service) ListArtists(ctx context.Context, filter *models.ArtistsFilter) (models.Artists, error) {
+	const op = "service.ListArtists"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	return s.repo.ListArtists(ctx, filter)
+}
+
+func (s *Service) UpdateArtist(ctx context.Context, in *models.UpdateArtist) (*models.Artist, error) {
+	const op = "service.UpdateArtist"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	log := s.log.With(
+		slog.String("op", op),
+		sl.RequestID(ctx),
+		sl.Principal(ctx),
+		sl.TraceID(ctx),
+	)
+
+	err := s.repo.UpdateArtist(ctx, in)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("renamed artist", slog.Int("artistID", in.ID), slog.String("name", in.Name))
+
+	return s.repo.GetArtist(ctx, in.ID)
+}
+
+func (s *Service) MergeArtists(ctx context.Context, in *models.MergeArtists) (*models.Artist, error) {
+	const op = "service.MergeArtists"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	log := s.log.With(
+		slog.String("op", op),
+		sl.RequestID(ctx),
+		sl.Principal(ctx),
+		sl.TraceID(ctx),
+	)
+
+	err := s.repo.MergeArtists(ctx, in.TargetID, in.SourceIDs)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("merged artists", slog.Int("targetID", in.TargetID), slog.Any("sourceIDs", in.SourceIDs))
+
+	return s.repo.GetArtist(ctx, in.TargetID)
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"songs-library/internal/models"
+	"songs-library/pkg/logger/sl"
+	"songs-library/pkg/tracing"
+)
+
+func (s *Service) ListDuplicates(ctx context.Context, filter *models.DuplicatesFilter) (models.DuplicateClusters, error) {
+	const op = "service.ListDuplicates"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	clusters, err := s.repo.ListDuplicateClusters(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return clusters, nil
+}
+
+func (s *Service) MergeSongs(ctx context.Context, in *models.MergeSongs) (*models.SongWithText, error) {
+	const op = "service.MergeSongs"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	log := s.log.With(
+		slog.String("op", op),
+		sl.RequestID(ctx),
+		sl.Principal(ctx),
+		sl.TraceID(ctx),
+	)
+
+	err := s.repo.MergeSongs(ctx, in.TargetID, in.SourceIDs)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("merged songs", slog.Int("targetID", in.TargetID), slog.Any("sourceIDs", in.SourceIDs))
+
+	return s.GetSong(ctx, &models.GetSong{ID: in.TargetID, Include: []string{models.IncludeText}})
+}
@@ -0,0 +1,206 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"songs-library/internal"
+	"songs-library/internal/models"
+	"songs-library/internal/provider"
+	"songs-library/internal/releasedate"
+	"songs-library/internal/respository"
+	"songs-library/pkg/logger/sl"
+	"songs-library/pkg/principal"
+	"songs-library/pkg/tracing"
+	"sync"
+	"time"
+)
+
+const (
+	enrichmentBaseBackoff = 5 * time.Second
+	enrichmentMaxBackoff  = 10 * time.Minute
+
+	// enrichmentAuthor is recorded as the author of the revisions made by the workers.
+	enrichmentAuthor = "enrichment"
+)
+
+type EnrichmentConfig struct {
+	Workers      int
+	MaxAttempts  int
+	PollInterval time.Duration
+	Lease        time.Duration
+}
+
+// EnrichmentWorker fills in release date, text and link of pending songs from the detail provider in the background.
+type EnrichmentWorker struct {
+	log      *slog.Logger
+	repo     internal.Repository
+	provider internal.SongDetailProvider
+	cfg      EnrichmentConfig
+}
+
+func NewEnrichmentWorker(
+	log *slog.Logger,
+	repo internal.Repository,
+	provider internal.SongDetailProvider,
+	cfg EnrichmentConfig,
+) *EnrichmentWorker {
+	return &EnrichmentWorker{
+		log:      log.With(slog.String("component", "enrichment")),
+		repo:     repo,
+		provider: provider,
+		cfg:      cfg,
+	}
+}
+
+// Run starts the worker pool and blocks until ctx is cancelled and every worker has finished its current job.
+func (w *EnrichmentWorker) Run(ctx context.Context) {
+	ctx = principal.With(ctx, enrichmentAuthor)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < w.cfg.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.loop(ctx, w.log.With(slog.Int("worker", i)))
+		}()
+	}
+
+	w.log.Info("enrichment workers started", slog.Int("workers", w.cfg.Workers))
+
+	wg.Wait()
+
+	w.log.Info("enrichment workers stopped")
+}
+
+func (w *EnrichmentWorker) loop(ctx context.Context, log *slog.Logger) {
+	for ctx.Err() == nil {
+		err := w.processNext(ctx, log)
+		if err == nil {
+			continue
+		}
+
+		if !errors.Is(err, respository.ErrNoEnrichmentJobs) {
+			log.Error("failed to process enrichment job", sl.Err(err))
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(w.cfg.PollInterval):
+		}
+	}
+}
+
+func (w *EnrichmentWorker) processNext(ctx context.Context, log *slog.Logger) error {
+	const op = "enrichment.processNext"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	job, err := w.repo.ClaimEnrichmentJob(ctx, w.cfg.Lease)
+	if err != nil {
+		return err
+	}
+
+	log = log.With(
+		slog.String("op", op),
+		slog.Int("songID", job.SongID),
+		slog.Int("attempt", job.Attempts),
+		sl.TraceID(ctx),
+	)
+
+	detail, err := w.provider.GetSongDetail(ctx, job.Song, job.Group)
+	// A chain returns what its other providers found along with the error of a failed one; the last attempt keeps it.
+	if err != nil && detail != nil && job.Attempts >= w.cfg.MaxAttempts && ctx.Err() == nil {
+		log.Warn("enrichment incomplete, keeping partial details", sl.Err(err))
+		err = nil
+	}
+
+	// The outcome is saved even if shutdown cancelled ctx meanwhile, otherwise the job waits for its lease to expire.
+	stopped := ctx.Err() != nil
+	ctx = context.WithoutCancel(ctx)
+
+	if err == nil {
+		if _, dateErr := releasedate.Normalize(detail.ReleaseDate); dateErr != nil {
+			log.Warn("unknown release date format, leaving it empty", slog.String("releaseDate", detail.ReleaseDate))
+			detail.ReleaseDate = ""
+		}
+
+		if err = w.repo.CompleteEnrichment(ctx, job, detail); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		log.Info("song enriched")
+		return nil
+	}
+
+	if stopped || errors.Is(err, context.Canceled) {
+		log.Info("enrichment interrupted, releasing job")
+
+		// Shutdown isn't the provider's fault, so the attempt isn't counted.
+		if err = w.repo.ReleaseEnrichmentJob(ctx, job); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		return nil
+	}
+
+	if errors.Is(err, provider.ErrNotFound) || job.Attempts >= w.cfg.MaxAttempts {
+		log.Error("enrichment failed, giving up", sl.Err(err))
+
+		if err = w.repo.FailEnrichment(ctx, job, err.Error()); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		return nil
+	}
+
+	runAt := time.Now().Add(backoff(job.Attempts))
+	log.Warn("enrichment failed, retrying", sl.Err(err), slog.Time("runAt", runAt))
+
+	if err = w.repo.RetryEnrichmentJob(ctx, job, runAt, err.Error()); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// backoff doubles the delay with every attempt and adds up to 20% jitter so retries of a burst don't align.
+func backoff(attempt int) time.Duration {
+	d := enrichmentMaxBackoff
+	if attempt < 20 {
+		d = min(enrichmentBaseBackoff<<(attempt-1), enrichmentMaxBackoff)
+	}
+
+	return d + rand.N(d/5+1)
+}
+
+func (s *Service) GetEnrichment(ctx context.Context, songID int) (*models.Enrichment, error) {
+	const op = "service.GetEnrichment"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	return s.repo.GetEnrichment(ctx, songID)
+}
+
+func (s *Service) RequeueEnrichment(ctx context.Context, in *models.RequeueEnrichment) (*models.Requeued, error) {
+	const op = "service.RequeueEnrichment"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	log := s.log.With(
+		slog.String("op", op),
+		sl.RequestID(ctx),
+		sl.Principal(ctx),
+		sl.TraceID(ctx),
+	)
+
+	count, err := s.repo.RequeueEnrichment(ctx, in.IDs)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("requeued enrichment", slog.Any("songIDs", in.IDs), slog.Int("count", count))
+
+	return &models.Requeued{Count: count}, nil
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"songs-library/internal/consts"
+	"songs-library/internal/exporter"
+	"songs-library/internal/models"
+	"songs-library/pkg/logger/sl"
+	"songs-library/pkg/tracing"
+)
+
+// ExportSongs writes the songs that match the filter to w as they are read. If w can be flushed, it is flushed
+// after every batch, so the output doesn't pile up in a buffer.
+func (s *Service) ExportSongs(ctx context.Context, in *models.ExportSongs, w io.Writer) error {
+	const op = "service.ExportSongs"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	log := s.log.With(
+		slog.String("op", op),
+		sl.RequestID(ctx),
+		sl.Principal(ctx),
+		sl.TraceID(ctx),
+	)
+
+	out, err := exporter.NewWriter(w, in.Format, in.IncludesText())
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	flusher, _ := w.(interface{ Flush() error })
+
+	count := 0
+	err = s.repo.ExportSongs(ctx, &in.Filter, in.IncludesText(), func(song *models.SongWithText) error {
+		if err := out.Write(song); err != nil {
+			return err
+		}
+
+		count++
+		if flusher == nil || count%consts.ExportBatchSize != 0 {
+			return nil
+		}
+
+		if err := out.Flush(); err != nil {
+			return err
+		}
+
+		return flusher.Flush()
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = out.Close(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("exported songs", slog.String("format", in.Format), slog.Int("count", count))
+
+	return nil
+}
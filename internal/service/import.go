@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"songs-library/internal/importer"
+	"songs-library/internal/models"
+	"songs-library/pkg/logger/sl"
+	"songs-library/pkg/tracing"
+)
+
+// ImportSongs reads the songs of the document and inserts the valid ones in batches. Every row is reported:
+// rows that fail to decode or validate don't stop the import, and a batch the database rejects is reported
+// as failed while the next ones go on.
+func (s *Service) ImportSongs(ctx context.Context, r io.Reader, opts *models.ImportSongs) (*models.ImportReport, error) {
+	const op = "service.ImportSongs"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	log := s.log.With(
+		slog.String("op", op),
+		sl.RequestID(ctx),
+		sl.Principal(ctx),
+		sl.TraceID(ctx),
+	)
+
+	report := models.ImportReport{Rows: []models.ImportRow{}}
+	batch := make([]models.ImportSong, 0, opts.BatchSize)
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		rows, err := s.repo.ImportSongs(ctx, batch, opts.Enrich)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			log.Error("failed to import batch", sl.Err(err), slog.Int("firstRow", batch[0].Row))
+
+			for _, song := range batch {
+				report.Add(models.ImportRow{Row: song.Row, Status: models.ImportFailed, Error: "failed to save song"})
+			}
+		}
+
+		for _, row := range rows {
+			report.Add(row)
+		}
+
+		batch = batch[:0]
+		return nil
+	}
+
+	err := importer.Read(r, opts.Format, func(row int, song *models.ImportSong, err error) error {
+		if err == nil {
+			err = song.Validate()
+		}
+		if err != nil {
+			report.Add(models.ImportRow{Row: row, Status: models.ImportFailed, Error: err.Error()})
+			return nil
+		}
+
+		batch = append(batch, *song)
+		if len(batch) < opts.BatchSize {
+			return nil
+		}
+
+		return flush()
+	})
+	// Rows read before the document broke off are still imported.
+	if flushErr := flush(); err == nil {
+		err = flushErr
+	}
+	if err != nil {
+		log.Error("import stopped", sl.Err(err), slog.Int("rows", len(report.Rows)))
+		return &report, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("imported songs",
+		slog.Int("created", report.Created),
+		slog.Int("duplicates", report.Duplicates),
+		slog.Int("failed", report.Failed),
+	)
+
+	return &report, nil
+}
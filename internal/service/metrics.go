@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/prometheus/client_golang/prometheus"
+	"songs-library/internal"
+	"time"
+)
+
+// libraryStatsTimeout bounds the query of the library stats run each time the metrics are gathered.
+const libraryStatsTimeout = 5 * time.Second
+
+// RegisterLibraryMetrics adds the gauges of the library size and the enrichment backlog.
+// They are read from the repository each time the metrics are gathered.
+func RegisterLibraryMetrics(reg prometheus.Registerer, repo internal.Repository) {
+	reg.MustRegister(&libraryCollector{
+		repo:      repo,
+		songs:     prometheus.NewDesc("library_songs", "Songs in the library.", nil, nil),
+		artists:   prometheus.NewDesc("library_artists", "Artists in the library.", nil, nil),
+		albums:    prometheus.NewDesc("library_albums", "Albums in the library.", nil, nil),
+		playlists: prometheus.NewDesc("library_playlists", "Playlists in the library.", nil, nil),
+		jobs: prometheus.NewDesc("enrichment_jobs",
+			"Enrichment jobs by state: queued for a worker, running, or failed until requeued.", []string{"state"}, nil),
+	})
+}
+
+type libraryCollector struct {
+	repo                                    internal.Repository
+	songs, artists, albums, playlists, jobs *prometheus.Desc
+}
+
+func (c *libraryCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.songs
+	ch <- c.artists
+	ch <- c.albums
+	ch <- c.playlists
+	ch <- c.jobs
+}
+
+// Collect reads the stats; when that fails the gatherer reports the error and serves the other metrics.
+func (c *libraryCollector) Collect(ch chan<- prometheus.Metric) {
+	const op = "service.libraryCollector.Collect"
+
+	ctx, cancel := context.WithTimeout(context.Background(), libraryStatsTimeout)
+	defer cancel()
+
+	stats, err := c.repo.LibraryStats(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.songs, fmt.Errorf("%s: %w", op, err))
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.songs, prometheus.GaugeValue, float64(stats.Songs))
+	ch <- prometheus.MustNewConstMetric(c.artists, prometheus.GaugeValue, float64(stats.Artists))
+	ch <- prometheus.MustNewConstMetric(c.albums, prometheus.GaugeValue, float64(stats.Albums))
+	ch <- prometheus.MustNewConstMetric(c.playlists, prometheus.GaugeValue, float64(stats.Playlists))
+	ch <- prometheus.MustNewConstMetric(c.jobs, prometheus.GaugeValue, float64(stats.EnrichmentQueued), "queued")
+	ch <- prometheus.MustNewConstMetric(c.jobs, prometheus.GaugeValue, float64(stats.EnrichmentRunning), "running")
+	ch <- prometheus.MustNewConstMetric(c.jobs, prometheus.GaugeValue, float64(stats.EnrichmentFailed), "failed")
+}
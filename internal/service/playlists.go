@@ -0,0 +1,216 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"songs-library/internal/exporter"
+	"songs-library/internal/models"
+	"songs-library/pkg/logger/sl"
+	"songs-library/pkg/tracing"
+)
+
+func (s *Service) CreatePlaylist(ctx context.Context, in *models.CreatePlaylist) (*models.PlaylistWithEntries, error) {
+	const op = "service.CreatePlaylist"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	log := s.log.With(
+		slog.String("op", op),
+		sl.RequestID(ctx),
+		sl.Principal(ctx),
+		sl.TraceID(ctx),
+	)
+
+	id, err := s.repo.CreatePlaylist(ctx, in)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("created playlist", slog.Int("playlistID", id), slog.String("name", in.Name))
+
+	return s.repo.GetPlaylist(ctx, id)
+}
+
+func (s *Service) GetPlaylist(ctx context.Context, id int) (*models.PlaylistWithEntries, error) {
+	const op = "service.GetPlaylist"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	return s.repo.GetPlaylist(ctx, id)
+}
+
+func (s *Service) ListPlaylists(ctx context.Context, filter *models.PlaylistsFilter) (models.Playlists, error) {
+	const op = "service.ListPlaylists"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	return s.repo.ListPlaylists(ctx, filter)
+}
+
+func (s *Service) UpdatePlaylist(ctx context.Context, in *models.UpdatePlaylist) (*models.PlaylistWithEntries, error) {
+	const op = "service.UpdatePlaylist"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	log := s.log.With(
+		slog.String("op", op),
+		sl.RequestID(ctx),
+		sl.Principal(ctx),
+		sl.TraceID(ctx),
+	)
+
+	err := s.repo.UpdatePlaylist(ctx, in)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("updated playlist", slog.Int("playlistID", in.ID), slog.String("name", in.Name))
+
+	return s.repo.GetPlaylist(ctx, in.ID)
+}
+
+func (s *Service) DeletePlaylist(ctx context.Context, id int) error {
+	const op = "service.DeletePlaylist"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	log := s.log.With(
+		slog.String("op", op),
+		sl.RequestID(ctx),
+		sl.Principal(ctx),
+		sl.TraceID(ctx),
+	)
+
+	err := s.repo.DeletePlaylist(ctx, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("deleted playlist", slog.Int("playlistID", id))
+
+	return nil
+}
+
+func (s *Service) DuplicatePlaylist(
+	ctx context.Context,
+	id int,
+	in *models.DuplicatePlaylist,
+) (*models.PlaylistWithEntries, error) {
+	const op = "service.DuplicatePlaylist"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	log := s.log.With(
+		slog.String("op", op),
+		sl.RequestID(ctx),
+		sl.Principal(ctx),
+		sl.TraceID(ctx),
+	)
+
+	copyID, err := s.repo.DuplicatePlaylist(ctx, id, in.Name)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("duplicated playlist", slog.Int("playlistID", id), slog.Int("copyID", copyID))
+
+	return s.repo.GetPlaylist(ctx, copyID)
+}
+
+func (s *Service) AddPlaylistEntries(
+	ctx context.Context,
+	playlistID int,
+	in *models.AddPlaylistEntries,
+) (*models.PlaylistWithEntries, error) {
+	const op = "service.AddPlaylistEntries"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	log := s.log.With(
+		slog.String("op", op),
+		sl.RequestID(ctx),
+		sl.Principal(ctx),
+		sl.TraceID(ctx),
+	)
+
+	err := s.repo.AddPlaylistEntries(ctx, playlistID, in)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("added playlist entries", slog.Int("playlistID", playlistID), slog.Any("songIDs", in.SongIDs))
+
+	return s.repo.GetPlaylist(ctx, playlistID)
+}
+
+func (s *Service) MovePlaylistEntry(
+	ctx context.Context,
+	playlistID, entryID int,
+	in *models.MovePlaylistEntry,
+) (*models.PlaylistWithEntries, error) {
+	const op = "service.MovePlaylistEntry"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	log := s.log.With(
+		slog.String("op", op),
+		sl.RequestID(ctx),
+		sl.Principal(ctx),
+		sl.TraceID(ctx),
+	)
+
+	err := s.repo.MovePlaylistEntry(ctx, playlistID, entryID, in.Position)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("moved playlist entry",
+		slog.Int("playlistID", playlistID),
+		slog.Int("entryID", entryID),
+		slog.Int("position", in.Position),
+	)
+
+	return s.repo.GetPlaylist(ctx, playlistID)
+}
+
+func (s *Service) RemovePlaylistEntry(ctx context.Context, playlistID, entryID int) error {
+	const op = "service.RemovePlaylistEntry"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	log := s.log.With(
+		slog.String("op", op),
+		sl.RequestID(ctx),
+		sl.Principal(ctx),
+		sl.TraceID(ctx),
+	)
+
+	err := s.repo.RemovePlaylistEntry(ctx, playlistID, entryID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("removed playlist entry", slog.Int("playlistID", playlistID), slog.Int("entryID", entryID))
+
+	return nil
+}
+
+// ExportPlaylist writes the playlist to w as M3U or XSPF. Nothing is written if the playlist isn't found.
+func (s *Service) ExportPlaylist(ctx context.Context, id int, format string, w io.Writer) error {
+	const op = "service.ExportPlaylist"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	playlist, err := s.repo.GetPlaylist(ctx, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = exporter.WritePlaylist(w, format, playlist); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
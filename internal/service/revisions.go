@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"songs-library/internal/diff"
+	"songs-library/internal/models"
+	"songs-library/pkg/logger/sl"
+	"songs-library/pkg/tracing"
+	"strconv"
+	"strings"
+)
+
+func (s *Service) ListRevisions(ctx context.Context, songID int) (models.Revisions, error) {
+	const op = "service.ListRevisions"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	return s.repo.ListRevisions(ctx, songID)
+}
+
+func (s *Service) GetRevision(ctx context.Context, in *models.GetRevision) (*models.Revision, error) {
+	const op = "service.GetRevision"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	return s.repo.GetRevision(ctx, in.SongID, in.Revision)
+}
+
+// DiffRevisions compares two revisions of the song: the changed fields and the text line by line.
+func (s *Service) DiffRevisions(ctx context.Context, in *models.DiffRevisions) (*models.RevisionDiff, error) {
+	const op = "service.DiffRevisions"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	from, err := s.repo.GetRevision(ctx, in.SongID, in.From)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	to, err := s.repo.GetRevision(ctx, in.SongID, in.To)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	text, err := diff.Lines(splitLines(from.Snapshot.Text), splitLines(to.Snapshot.Text))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &models.RevisionDiff{
+		SongID: in.SongID,
+		From:   in.From,
+		To:     in.To,
+		Fields: diffFields(&from.Snapshot, &to.Snapshot),
+		Text:   text,
+	}, nil
+}
+
+// RestoreRevision brings the song back to the state of the revision and returns the revision it made.
+func (s *Service) RestoreRevision(ctx context.Context, in *models.GetRevision) (*models.Revision, error) {
+	const op = "service.RestoreRevision"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	log := s.log.With(
+		slog.String("op", op),
+		sl.RequestID(ctx),
+		sl.Principal(ctx),
+		sl.TraceID(ctx),
+	)
+
+	restored, err := s.repo.RestoreRevision(ctx, in.SongID, in.Revision)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("restored song revision",
+		slog.Int("songID", in.SongID),
+		slog.Int("from", in.Revision),
+		slog.Int("revision", restored.Revision),
+	)
+
+	return restored, nil
+}
+
+func diffFields(from, to *models.SongSnapshot) []models.FieldChange {
+	fields := []models.FieldChange{
+		{Field: "song", From: from.Song, To: to.Song},
+		{Field: "artist_id", From: strconv.Itoa(from.ArtistID), To: strconv.Itoa(to.ArtistID)},
+		{Field: "group", From: from.Group, To: to.Group},
+		{Field: "release_date", From: from.ReleaseDate, To: to.ReleaseDate},
+		{Field: "link", From: from.Link, To: to.Link},
+	}
+
+	changes := make([]models.FieldChange, 0, len(fields))
+	for _, field := range fields {
+		if field.From != field.To {
+			changes = append(changes, field)
+		}
+	}
+
+	return changes
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+
+	return strings.Split(text, "\n")
+}
@@ -1,71 +1,107 @@
 package service
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"log/slog"
-	"net/http"
-	"net/url"
+	"slices"
 	"songs-library/internal"
+	"songs-library/internal/lyrics"
+	"songs-library/internal/mergepatch"
 	"songs-library/internal/models"
+	"songs-library/internal/releasedate"
+	"songs-library/internal/respository"
+	"songs-library/pkg/logger/sl"
+	"songs-library/pkg/tracing"
 	"strings"
 )
 
 type Service struct {
-	log             *slog.Logger
-	repo            internal.Repository
-	songsInfoAPIURL string
+	log  *slog.Logger
+	repo internal.Repository
 }
 
-func NewService(log *slog.Logger, repo internal.Repository, songsInfoAPIURL string) internal.Service {
+func NewService(log *slog.Logger, repo internal.Repository) internal.Service {
 	return &Service{
-		log:             log,
-		repo:            repo,
-		songsInfoAPIURL: songsInfoAPIURL,
+		log:  log,
+		repo: repo,
 	}
 }
 
-func (s *Service) CreateSong(in *models.CreateSong) (*models.Song, error) {
+func (s *Service) CreateSong(ctx context.Context, in *models.CreateSong) (*models.Song, error) {
 	const op = "service.CreateSong"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
 
 	log := s.log.With(
 		slog.String("op", op),
+		sl.RequestID(ctx),
+		sl.Principal(ctx),
+		sl.TraceID(ctx),
 	)
 
-	details, err := s.getSongDetail(in.Song, in.Group)
-	if err != nil {
-		return nil, fmt.Errorf("%s: %w", op, err)
-	}
-
-	song := models.Song{
-		Song:        in.Song,
-		Group:       in.Group,
-		ReleaseDate: details.ReleaseDate,
-		Text:        details.Text,
-		Link:        details.Link,
+	// The artist, the song and its enrichment job are created together: a song is never left out of the queue.
+	var song models.Song
+	err := s.repo.WithTx(ctx, nil, func(repo internal.Repository) error {
+		artistID, err := repo.ResolveArtist(ctx, in.Group)
+		if err != nil {
+			return err
+		}
+
+		song = models.Song{
+			Song:             in.Song,
+			ArtistID:         artistID,
+			Group:            models.CleanArtistName(in.Group),
+			EnrichmentStatus: models.EnrichmentPending,
+		}
+
+		song.ID, err = repo.CreateSong(ctx, &song, in.OnDuplicate != models.OnDuplicateAllow)
+		if err != nil {
+			return err
+		}
+
+		return repo.EnqueueEnrichment(ctx, song.ID)
+	})
+
+	var dup *models.DuplicateSongError
+	if errors.As(err, &dup) {
+		existing, err := s.repo.GetSong(ctx, dup.SongID)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if in.OnDuplicate == models.OnDuplicateReturn {
+			log.Info("returned existing song", slog.Int("songID", existing.ID))
+			return existing, nil
+		}
+
+		dup.Song = existing
+		return nil, fmt.Errorf("%s: %w", op, dup)
 	}
-
-	id, err := s.repo.CreateSong(&song)
-
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	song.ID = id
-
 	log.Info("created song", slog.Any("song", song))
 	return &song, err
 }
 
-func (s *Service) DeleteSong(id int) error {
+func (s *Service) DeleteSong(ctx context.Context, id int) error {
 	const op = "service.DeleteSong"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
 
 	log := s.log.With(
 		slog.String("op", op),
+		sl.RequestID(ctx),
+		sl.Principal(ctx),
+		sl.TraceID(ctx),
 	)
 
-	err := s.repo.DeleteSong(id)
+	err := s.repo.DeleteSong(ctx, id)
 	if err != nil {
 		return err
 	}
@@ -75,16 +111,39 @@ func (s *Service) DeleteSong(id int) error {
 	return nil
 }
 
-func (s *Service) UpdateSong(song *models.UpdateSong) (*models.UpdateSong, error) {
-	const op = "service.CreateSong"
+func (s *Service) UpdateSong(ctx context.Context, song *models.UpdateSong) (*models.UpdateSong, error) {
+	const op = "service.UpdateSong"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
 
 	log := s.log.With(
 		slog.String("op", op),
+		sl.RequestID(ctx),
+		sl.Principal(ctx),
+		sl.TraceID(ctx),
 	)
 
-	err := s.repo.UpdateSong(song)
+	releaseDate, err := releasedate.Normalize(song.ReleaseDate)
 	if err != nil {
-		return nil, err
+		return nil, models.ErrInvalidReleaseDate
+	}
+
+	song.ReleaseDate = releaseDate
+
+	// The artist is created with the update, so a failed update doesn't leave it behind.
+	err = s.repo.WithTx(ctx, nil, func(repo internal.Repository) error {
+		artistID, err := repo.ResolveArtist(ctx, song.Group)
+		if err != nil {
+			return err
+		}
+
+		song.ArtistID = artistID
+		song.Group = models.CleanArtistName(song.Group)
+
+		return repo.UpdateSong(ctx, song)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
 	log.Debug("updated song", slog.Any("song", song))
@@ -92,78 +151,248 @@ func (s *Service) UpdateSong(song *models.UpdateSong) (*models.UpdateSong, error
 	return song, nil
 }
 
-func (s *Service) ListSongs(filter *models.SongsFilter) (models.Songs, error) {
-	return s.repo.ListSongs(filter)
-}
+// PatchSong applies a JSON Merge Patch to the song. The update is made only if the song is still at the version
+// the patch was applied to, so a concurrent write is reported as a mismatch instead of being overwritten.
+func (s *Service) PatchSong(ctx context.Context, in *models.PatchSong) (*models.Song, error) {
+	const op = "service.PatchSong"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	log := s.log.With(
+		slog.String("op", op),
+		sl.RequestID(ctx),
+		sl.Principal(ctx),
+		sl.TraceID(ctx),
+	)
+
+	song, err := s.repo.GetSong(ctx, in.ID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(in.IfMatch) != 0 && !slices.Contains(in.IfMatch, song.Version) {
+		return nil, respository.ErrSongVersionMismatch
+	}
 
-func (s *Service) GetTextBySongID(in *models.GetText) (*models.Text, error) {
-	text, err := s.repo.GetTextBySongID(in.SongID)
+	fields, err := patchFields(song, in.Patch)
 	if err != nil {
 		return nil, err
 	}
 
-	return &models.Text{
-		SongID: in.SongID,
-		Text:   s.paginateText(text, in.Page, in.PerPage),
-	}, nil
+	// Validated by patchFields.
+	fields.ReleaseDate, _ = releasedate.Normalize(fields.ReleaseDate)
+
+	update := models.UpdateSong{
+		ID:          song.ID,
+		Song:        fields.Song,
+		Group:       models.CleanArtistName(fields.Group),
+		ReleaseDate: fields.ReleaseDate,
+		Text:        fields.Text,
+		Link:        fields.Link,
+		IfMatch:     []int{song.Version},
+	}
+
+	// As in UpdateSong, the artist is only kept if the update is made.
+	err = s.repo.WithTx(ctx, nil, func(repo internal.Repository) error {
+		artistID, err := repo.ResolveArtist(ctx, fields.Group)
+		if err != nil {
+			return err
+		}
+
+		update.ArtistID = artistID
+
+		return repo.UpdateSong(ctx, &update)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	song.Song = update.Song
+	song.ArtistID = update.ArtistID
+	song.Group = update.Group
+	song.ReleaseDate = update.ReleaseDate
+	song.Text = update.Text
+	song.Link = update.Link
+	song.Version = update.Version
+
+	log.Debug("patched song", slog.Any("song", song))
+
+	return song, nil
 }
 
-func (s *Service) paginateText(text string, page, perPage int) string {
-	verses := strings.Split(text, "\n\n")
+// patchFields applies the merge patch to the fields of the song. Errors wrap models.ErrInvalidPatch.
+func patchFields(song *models.Song, patch []byte) (*models.SongFields, error) {
+	doc, err := json.Marshal(models.SongFields{
+		Song:        song.Song,
+		Group:       song.Group,
+		ReleaseDate: song.ReleaseDate,
+		Text:        song.Text,
+		Link:        song.Link,
+	})
+	if err != nil {
+		return nil, err
+	}
 
-	if page < 1 {
-		page = 1
+	merged, err := mergepatch.Apply(doc, patch)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", models.ErrInvalidPatch, err)
 	}
 
-	if perPage < 1 {
-		perPage = len(verses)
+	var fields models.SongFields
+
+	dec := json.NewDecoder(bytes.NewReader(merged))
+	dec.DisallowUnknownFields()
+
+	if err = dec.Decode(&fields); err != nil {
+		return nil, fmt.Errorf("%w: %v", models.ErrInvalidPatch, err)
 	}
 
-	totalVerses := len(verses)
-	if totalVerses == 0 {
-		return ""
+	if err = fields.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", models.ErrInvalidPatch, err)
 	}
-	start := (page - 1) * perPage
-	if start >= totalVerses {
-		return ""
+
+	return &fields, nil
+}
+
+func (s *Service) ListSongs(ctx context.Context, filter *models.SongsFilter) (*models.SongsPage, error) {
+	const op = "service.ListSongs"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	return s.repo.ListSongs(ctx, filter)
+}
+
+// GetSong returns the song, with the text only if it is included.
+func (s *Service) GetSong(ctx context.Context, in *models.GetSong) (*models.SongWithText, error) {
+	const op = "service.GetSong"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	song, err := s.repo.GetSong(ctx, in.ID)
+	if err != nil {
+		return nil, err
 	}
 
-	end := start + perPage
-	if end > totalVerses {
-		end = totalVerses
+	res := models.SongWithText{Song: *song}
+	if in.IncludesText() {
+		res.Text = song.Text
 	}
 
-	return strings.Join(verses[start:end], "\n\n")
+	return &res, nil
 }
 
-func (s *Service) getSongDetail(song, group string) (models.SongDetail, error) {
-	params := url.Values{}
-	params.Add("group", group)
-	params.Add("song", song)
+func (s *Service) GetTextBySongID(ctx context.Context, in *models.GetText) (*models.Text, error) {
+	const op = "service.GetTextBySongID"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	text, err := s.repo.GetTextBySongID(ctx, in.SongID)
+	if err != nil {
+		return nil, err
+	}
+
+	if in.Section == "" && (in.By == "" || in.By == models.PaginateByVerse) {
+		return &models.Text{
+			SongID: in.SongID,
+			Text:   strings.Join(paginate(strings.Split(text, "\n\n"), in.Page, in.PerPage), "\n\n"),
+		}, nil
+	}
 
-	resp, err := http.Get(s.songsInfoAPIURL + "/info" + "?" + params.Encode())
+	sections, err := s.sections(ctx, in.SongID, text)
 	if err != nil {
-		return models.SongDetail{}, fmt.Errorf("cannot get song detail: %w", err)
+		return nil, err
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return models.SongDetail{}, fmt.Errorf("request failed: %v", resp.Status)
+	if in.Section != "" {
+		sections = filterSections(sections, in.Section)
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	if in.By == models.PaginateByLine {
+		lines := strings.Split(joinSections(sections), "\n")
+
+		return &models.Text{
+			SongID: in.SongID,
+			Text:   strings.Join(paginate(lines, in.Page, in.PerPage), "\n"),
+		}, nil
+	}
+
+	sections = paginate(sections, in.Page, in.PerPage)
+
+	return &models.Text{
+		SongID:   in.SongID,
+		Text:     joinSections(sections),
+		Sections: sections,
+	}, nil
+}
+
+// sections returns the stored sections of the song, parsing and storing them first if the text changed since.
+func (s *Service) sections(ctx context.Context, songID int, text string) (models.Sections, error) {
+	const op = "service.sections"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	sections, err := s.repo.ListSections(ctx, songID)
 	if err != nil {
-		return models.SongDetail{}, fmt.Errorf("cannot get response body: %w", err)
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	var songDetail models.SongDetail
-	if err = json.Unmarshal(body, &songDetail); err != nil {
-		return models.SongDetail{}, fmt.Errorf("cannot unmarshal song detail: %w", err)
+	if len(sections) != 0 || text == "" {
+		return sections, nil
 	}
 
-	err = resp.Body.Close()
+	sections = lyrics.Parse(text)
+
+	err = s.repo.SaveSections(ctx, songID, text, sections)
 	if err != nil {
-		return models.SongDetail{}, fmt.Errorf("cannot close response body: %w", err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return sections, nil
+}
+
+func filterSections(sections models.Sections, sectionType string) models.Sections {
+	filtered := make(models.Sections, 0, len(sections))
+	for _, section := range sections {
+		if section.Type == sectionType {
+			filtered = append(filtered, section)
+		}
+	}
+
+	return filtered
+}
+
+func joinSections(sections models.Sections) string {
+	texts := make([]string, 0, len(sections))
+	for _, section := range sections {
+		texts = append(texts, section.Text)
+	}
+
+	return strings.Join(texts, "\n\n")
+}
+
+// paginate returns the page of items. Without perPage the whole list is one page.
+func paginate[T any](items []T, page, perPage int) []T {
+	if page < 1 {
+		page = 1
+	}
+
+	if perPage < 1 {
+		perPage = len(items)
+	}
+
+	total := len(items)
+	if total == 0 {
+		return nil
+	}
+	start := (page - 1) * perPage
+	if start >= total {
+		return nil
+	}
+
+	end := start + perPage
+	if end > total {
+		end = total
 	}
 
-	return songDetail, nil
+	return items[start:end]
 }
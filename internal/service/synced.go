@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"songs-library/internal/consts"
+	"songs-library/internal/lrc"
+	"songs-library/internal/models"
+	"songs-library/pkg/logger/sl"
+	"songs-library/pkg/tracing"
+)
+
+// UploadSyncedLyrics parses an LRC document and stores it. The plain song text is replaced with the synced lines.
+func (s *Service) UploadSyncedLyrics(ctx context.Context, songID int, document string) (*models.SyncedLyrics, error) {
+	const op = "service.UploadSyncedLyrics"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	log := s.log.With(
+		slog.String("op", op),
+		sl.RequestID(ctx),
+		sl.Principal(ctx),
+		sl.TraceID(ctx),
+	)
+
+	lyrics, err := lrc.Parse(document)
+	if err != nil {
+		return nil, err
+	}
+
+	lyrics.SongID = songID
+
+	err = s.repo.SaveSyncedLyrics(ctx, lyrics, lrc.Text(lyrics))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("uploaded synced lyrics", slog.Int("songID", songID), slog.Int("lines", len(lyrics.Lines)))
+
+	return lyrics, nil
+}
+
+func (s *Service) GetSyncedLyrics(ctx context.Context, songID int) (*models.SyncedLyrics, error) {
+	const op = "service.GetSyncedLyrics"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	return s.repo.GetSyncedLyrics(ctx, songID)
+}
+
+func (s *Service) GetActiveLine(ctx context.Context, in *models.GetActiveLine) (*models.ActiveLine, error) {
+	const op = "service.GetActiveLine"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	lyrics, err := s.repo.GetSyncedLyrics(ctx, in.SongID)
+	if err != nil {
+		return nil, err
+	}
+
+	active := models.ActiveLine{
+		SongID: in.SongID,
+		AtMs:   in.AtMs,
+		Index:  lrc.ActiveLine(lyrics, in.AtMs),
+	}
+
+	if active.Index >= 0 {
+		active.Line = &lyrics.Lines[active.Index]
+	}
+
+	if next := active.Index + 1; next < len(lyrics.Lines) {
+		active.Next = &lyrics.Lines[next]
+	}
+
+	return &active, nil
+}
+
+func (s *Service) ExportSyncedLyrics(ctx context.Context, in *models.ExportSyncedLyrics) (string, error) {
+	const op = "service.ExportSyncedLyrics"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	lyrics, err := s.repo.GetSyncedLyrics(ctx, in.SongID)
+	if err != nil {
+		return "", err
+	}
+
+	if in.Format == models.ExportFormatVTT {
+		return lrc.FormatVTT(lyrics, consts.SyncedLastCueMs), nil
+	}
+
+	return lrc.FormatLRC(lyrics), nil
+}
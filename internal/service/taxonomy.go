@@ -0,0 +1,237 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"songs-library/internal/models"
+	"songs-library/pkg/logger/sl"
+	"songs-library/pkg/tracing"
+)
+
+func (s *Service) CreateGenre(ctx context.Context, in *models.CreateGenre) (*models.Genre, error) {
+	const op = "service.CreateGenre"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	log := s.log.With(
+		slog.String("op", op),
+		sl.RequestID(ctx),
+		sl.Principal(ctx),
+		sl.TraceID(ctx),
+	)
+
+	id, err := s.repo.CreateGenre(ctx, in)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("created genre", slog.Int("genreID", id), slog.String("name", in.Name))
+
+	return s.repo.GetGenre(ctx, id)
+}
+
+func (s *Service) GetGenre(ctx context.Context, id int) (*models.Genre, error) {
+	const op = "service.GetGenre"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	return s.repo.GetGenre(ctx, id)
+}
+
+func (s *Service) ListGenres(ctx context.Context) (models.Genres, error) {
+	const op = "service.ListGenres"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	return s.repo.ListGenres(ctx)
+}
+
+func (s *Service) UpdateGenre(ctx context.Context, in *models.UpdateGenre) (*models.Genre, error) {
+	const op = "service.UpdateGenre"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	log := s.log.With(
+		slog.String("op", op),
+		sl.RequestID(ctx),
+		sl.Principal(ctx),
+		sl.TraceID(ctx),
+	)
+
+	err := s.repo.UpdateGenre(ctx, in)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("updated genre", slog.Int("genreID", in.ID), slog.String("name", in.Name))
+
+	return s.repo.GetGenre(ctx, in.ID)
+}
+
+func (s *Service) DeleteGenre(ctx context.Context, id int) error {
+	const op = "service.DeleteGenre"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	log := s.log.With(
+		slog.String("op", op),
+		sl.RequestID(ctx),
+		sl.Principal(ctx),
+		sl.TraceID(ctx),
+	)
+
+	err := s.repo.DeleteGenre(ctx, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("deleted genre", slog.Int("genreID", id))
+
+	return nil
+}
+
+func (s *Service) CreateTag(ctx context.Context, in *models.CreateTag) (*models.Tag, error) {
+	const op = "service.CreateTag"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	log := s.log.With(
+		slog.String("op", op),
+		sl.RequestID(ctx),
+		sl.Principal(ctx),
+		sl.TraceID(ctx),
+	)
+
+	tag := models.Tag{
+		Name: models.CleanArtistName(in.Name),
+	}
+
+	id, err := s.repo.CreateTag(ctx, &tag)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tag.ID = id
+
+	log.Info("created tag", slog.Any("tag", tag))
+	return &tag, nil
+}
+
+func (s *Service) ListTags(ctx context.Context, filter *models.TagsFilter) (models.Tags, error) {
+	const op = "service.ListTags"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	return s.repo.ListTags(ctx, filter)
+}
+
+func (s *Service) UpdateTag(ctx context.Context, in *models.UpdateTag) (*models.Tag, error) {
+	const op = "service.UpdateTag"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	log := s.log.With(
+		slog.String("op", op),
+		sl.RequestID(ctx),
+		sl.Principal(ctx),
+		sl.TraceID(ctx),
+	)
+
+	err := s.repo.UpdateTag(ctx, in)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("renamed tag", slog.Int("tagID", in.ID), slog.String("name", in.Name))
+
+	return s.repo.GetTag(ctx, in.ID)
+}
+
+func (s *Service) DeleteTag(ctx context.Context, id int) error {
+	const op = "service.DeleteTag"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	log := s.log.With(
+		slog.String("op", op),
+		sl.RequestID(ctx),
+		sl.Principal(ctx),
+		sl.TraceID(ctx),
+	)
+
+	err := s.repo.DeleteTag(ctx, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("deleted tag", slog.Int("tagID", id))
+
+	return nil
+}
+
+func (s *Service) GetSongTags(ctx context.Context, songID int) (*models.SongTags, error) {
+	const op = "service.GetSongTags"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	if _, err := s.repo.GetSong(ctx, songID); err != nil {
+		return nil, err
+	}
+
+	genres, err := s.repo.ListSongGenres(ctx, songID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tags, err := s.repo.ListSongTags(ctx, songID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &models.SongTags{SongID: songID, Genres: genres, Tags: tags}, nil
+}
+
+func (s *Service) TagSongs(ctx context.Context, in *models.TagSongs) (*models.Tagged, error) {
+	const op = "service.TagSongs"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	log := s.log.With(
+		slog.String("op", op),
+		sl.RequestID(ctx),
+		sl.Principal(ctx),
+		sl.TraceID(ctx),
+	)
+
+	tagged, err := s.repo.TagSongs(ctx, in)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("tagged songs", slog.Any("songIDs", in.SongIDs), slog.Any("added", tagged))
+
+	return tagged, nil
+}
+
+func (s *Service) UntagSongs(ctx context.Context, in *models.TagSongs) (*models.Tagged, error) {
+	const op = "service.UntagSongs"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	log := s.log.With(
+		slog.String("op", op),
+		sl.RequestID(ctx),
+		sl.Principal(ctx),
+		sl.TraceID(ctx),
+	)
+
+	tagged, err := s.repo.UntagSongs(ctx, in)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("untagged songs", slog.Any("songIDs", in.SongIDs), slog.Any("removed", tagged))
+
+	return tagged, nil
+}
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table songs
+    add column text_search tsvector
+        generated always as (to_tsvector('simple', coalesce(text, ''))) stored;
+
+create index songs_text_search_idx on songs using gin (text_search);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index if exists songs_text_search_idx;
+
+alter table songs drop column if exists text_search;
+-- +goose StatementEnd
@@ -0,0 +1,48 @@
+-- +goose Up
+-- +goose StatementBegin
+create table artists (
+    id serial primary key,
+    name varchar not null,
+    normalized_name varchar not null unique,
+    created_at timestamptz not null default now()
+);
+
+insert into artists (name, normalized_name)
+select distinct on (normalized_name) name, normalized_name
+from (
+    select id,
+           btrim(regexp_replace(author, '\s+', ' ', 'g')) as name,
+           lower(btrim(regexp_replace(author, '\s+', ' ', 'g'))) as normalized_name
+    from songs
+) s
+order by normalized_name, id;
+
+alter table songs add column artist_id int references artists (id);
+
+update songs
+set artist_id = artists.id
+from artists
+where artists.normalized_name = lower(btrim(regexp_replace(songs.author, '\s+', ' ', 'g')));
+
+alter table songs alter column artist_id set not null;
+
+alter table songs drop column author;
+
+create index songs_artist_id_idx on songs (artist_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table songs add column author varchar;
+
+update songs
+set author = artists.name
+from artists
+where artists.id = songs.artist_id;
+
+alter table songs alter column author set not null;
+
+alter table songs drop column artist_id;
+
+drop table artists;
+-- +goose StatementEnd
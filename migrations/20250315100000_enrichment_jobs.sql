@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table songs add primary key (id);
+
+alter table songs add column enrichment_status varchar not null default 'ok';
+
+alter table songs alter column enrichment_status set default 'pending';
+
+create table enrichment_jobs (
+    id serial primary key,
+    song_id int not null unique references songs (id) on delete cascade,
+    attempts int not null default 0,
+    run_at timestamptz not null default now(),
+    locked_at timestamptz,
+    failed_at timestamptz,
+    last_error varchar,
+    created_at timestamptz not null default now()
+);
+
+create index enrichment_jobs_run_at_idx on enrichment_jobs (run_at) where failed_at is null;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table enrichment_jobs;
+
+alter table songs drop column enrichment_status;
+
+alter table songs drop constraint songs_pkey;
+-- +goose StatementEnd
@@ -0,0 +1,38 @@
+-- +goose Up
+-- +goose StatementBegin
+create table song_sections (
+    id serial primary key,
+    song_id int not null references songs (id) on delete cascade,
+    position int not null,
+    type varchar not null,
+    ordinal int not null,
+    label varchar not null default '',
+    text varchar not null,
+    unique (song_id, position)
+);
+
+create index song_sections_type_idx on song_sections (song_id, type);
+
+-- Sections are parsed from songs.text, so they are dropped whenever the text changes and parsed again on read.
+create function song_sections_invalidate() returns trigger as $$
+begin
+    delete from song_sections where song_id = new.id;
+    return new;
+end;
+$$ language plpgsql;
+
+create trigger songs_text_changed
+    after update of text on songs
+    for each row
+    when (old.text is distinct from new.text)
+    execute function song_sections_invalidate();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop trigger songs_text_changed on songs;
+
+drop function song_sections_invalidate();
+
+drop table song_sections;
+-- +goose StatementEnd
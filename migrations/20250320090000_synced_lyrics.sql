@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+create table synced_lyrics (
+    song_id int primary key references songs (id) on delete cascade,
+    tags jsonb not null default '{}',
+    offset_ms int not null default 0,
+    updated_at timestamptz not null default now()
+);
+
+create table synced_lyrics_lines (
+    song_id int not null references synced_lyrics (song_id) on delete cascade,
+    position int not null,
+    time_ms int not null,
+    text varchar not null,
+    primary key (song_id, position)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table synced_lyrics_lines;
+
+drop table synced_lyrics;
+-- +goose StatementEnd
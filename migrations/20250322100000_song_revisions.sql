@@ -0,0 +1,84 @@
+-- +goose Up
+-- +goose StatementBegin
+create table song_revisions (
+    id serial primary key,
+    song_id int not null,
+    revision int not null,
+    action varchar not null,
+    author varchar not null,
+    snapshot jsonb not null,
+    created_at timestamptz not null default now(),
+    unique (song_id, revision)
+);
+
+create function song_snapshot(s songs) returns jsonb as $$
+    select jsonb_build_object(
+        'song', s.song,
+        'artist_id', s.artist_id,
+        'group', (select name from artists where id = s.artist_id),
+        'release_date', s.release_date,
+        'text', coalesce(s.text, ''),
+        'link', coalesce(s.link, '')
+    );
+$$ language sql stable;
+
+-- Every change of a song is recorded with the author and action the repository sets for the transaction
+-- in app.author and app.revision_action. Status-only updates, e.g. by the enrichment queue, are not revisions.
+create function song_revisions_record() returns trigger as $$
+declare
+    rec songs;
+    act varchar;
+begin
+    if tg_op = 'DELETE' then
+        rec := old;
+    else
+        rec := new;
+    end if;
+
+    act := coalesce(nullif(current_setting('app.revision_action', true), ''), lower(tg_op));
+    if act = 'insert' then
+        act := 'create';
+    end if;
+
+    insert into song_revisions (song_id, revision, action, author, snapshot)
+    values (
+        rec.id,
+        coalesce((select max(revision) from song_revisions where song_id = rec.id), 0) + 1,
+        act,
+        coalesce(nullif(current_setting('app.author', true), ''), 'system'),
+        song_snapshot(rec)
+    );
+
+    return null;
+end;
+$$ language plpgsql;
+
+create trigger songs_revision_insert
+    after insert or delete on songs
+    for each row
+    execute function song_revisions_record();
+
+create trigger songs_revision_update
+    after update on songs
+    for each row
+    when ((old.song, old.artist_id, old.release_date, old.text, old.link)
+        is distinct from (new.song, new.artist_id, new.release_date, new.text, new.link))
+    execute function song_revisions_record();
+
+insert into song_revisions (song_id, revision, action, author, snapshot)
+select id, 1, 'create', 'migration', song_snapshot(songs)
+from songs;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop trigger songs_revision_update on songs;
+
+drop trigger songs_revision_insert on songs;
+
+drop function song_revisions_record();
+
+drop function song_snapshot(songs);
+
+drop table song_revisions;
+-- +goose StatementEnd
@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table songs add column version int not null default 1;
+
+-- The version is the song's ETag: it changes with any of the fields the API returns.
+create function songs_bump_version() returns trigger as $$
+begin
+    new.version := old.version + 1;
+    return new;
+end;
+$$ language plpgsql;
+
+create trigger songs_version_bump
+    before update on songs
+    for each row
+    when ((old.song, old.artist_id, old.release_date, old.text, old.link, old.enrichment_status)
+        is distinct from (new.song, new.artist_id, new.release_date, new.text, new.link, new.enrichment_status))
+    execute function songs_bump_version();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop trigger songs_version_bump on songs;
+
+drop function songs_bump_version();
+
+alter table songs drop column version;
+-- +goose StatementEnd
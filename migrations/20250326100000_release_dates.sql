@@ -0,0 +1,133 @@
+-- +goose Up
+-- +goose StatementBegin
+create function format_release_date(d date, precision varchar) returns varchar as $$
+    select case precision
+        when 'year' then to_char(d, 'YYYY')
+        when 'month' then to_char(d, 'YYYY-MM')
+        else to_char(d, 'YYYY-MM-DD')
+    end;
+$$ language sql immutable;
+
+-- parse_release_date reads the formats stored so far. Values it does not know become null; they are still
+-- in the song revisions.
+create function parse_release_date(v varchar, out d date, out precision varchar) as $$
+begin
+    v := btrim(v);
+
+    if v ~ '^\d{4}-\d{1,2}-\d{1,2}$' then
+        d := to_date(v, 'YYYY-MM-DD');
+        precision := 'day';
+    elsif v ~ '^\d{1,2}[./]\d{1,2}[./]\d{4}$' then
+        d := to_date(translate(v, '/', '.'), 'DD.MM.YYYY');
+        precision := 'day';
+    elsif v ~ '^\d{4}-\d{1,2}$' then
+        d := to_date(v, 'YYYY-MM');
+        precision := 'month';
+    elsif v ~ '^\d{1,2}[./]\d{4}$' then
+        d := to_date(translate(v, '/', '.'), 'MM.YYYY');
+        precision := 'month';
+    elsif v ~ '^\d{4}$' then
+        d := to_date(v, 'YYYY');
+        precision := 'year';
+    end if;
+exception when others then
+    d := null;
+    precision := null;
+end;
+$$ language plpgsql;
+
+-- The triggers compare release_date, so they have to go while its type changes.
+drop trigger songs_revision_update on songs;
+
+drop trigger songs_version_bump on songs;
+
+alter table songs rename column release_date to release_date_text;
+
+alter table songs add column release_date date;
+
+alter table songs add column release_date_precision varchar not null default 'day';
+
+update songs
+set release_date = p.d,
+    release_date_precision = coalesce(p.precision, 'day')
+from (select id, (parse_release_date(release_date_text)).* from songs) p
+where p.id = songs.id;
+
+alter table songs drop column release_date_text;
+
+drop function parse_release_date(varchar);
+
+create index songs_release_date_idx on songs (release_date);
+
+create or replace function song_snapshot(s songs) returns jsonb as $$
+    select jsonb_build_object(
+        'song', s.song,
+        'artist_id', s.artist_id,
+        'group', (select name from artists where id = s.artist_id),
+        'release_date', coalesce(format_release_date(s.release_date, s.release_date_precision), ''),
+        'text', coalesce(s.text, ''),
+        'link', coalesce(s.link, '')
+    );
+$$ language sql stable;
+
+create trigger songs_revision_update
+    after update on songs
+    for each row
+    when ((old.song, old.artist_id, old.release_date, old.release_date_precision, old.text, old.link)
+        is distinct from (new.song, new.artist_id, new.release_date, new.release_date_precision, new.text, new.link))
+    execute function song_revisions_record();
+
+create trigger songs_version_bump
+    before update on songs
+    for each row
+    when ((old.song, old.artist_id, old.release_date, old.release_date_precision, old.text, old.link, old.enrichment_status)
+        is distinct from (new.song, new.artist_id, new.release_date, new.release_date_precision, new.text, new.link, new.enrichment_status))
+    execute function songs_bump_version();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop trigger songs_revision_update on songs;
+
+drop trigger songs_version_bump on songs;
+
+alter table songs add column release_date_text varchar not null default '';
+
+update songs
+set release_date_text = coalesce(format_release_date(release_date, release_date_precision), '');
+
+alter table songs alter column release_date_text drop default;
+
+alter table songs drop column release_date;
+
+alter table songs drop column release_date_precision;
+
+alter table songs rename column release_date_text to release_date;
+
+create or replace function song_snapshot(s songs) returns jsonb as $$
+    select jsonb_build_object(
+        'song', s.song,
+        'artist_id', s.artist_id,
+        'group', (select name from artists where id = s.artist_id),
+        'release_date', s.release_date,
+        'text', coalesce(s.text, ''),
+        'link', coalesce(s.link, '')
+    );
+$$ language sql stable;
+
+create trigger songs_revision_update
+    after update on songs
+    for each row
+    when ((old.song, old.artist_id, old.release_date, old.text, old.link)
+        is distinct from (new.song, new.artist_id, new.release_date, new.text, new.link))
+    execute function song_revisions_record();
+
+create trigger songs_version_bump
+    before update on songs
+    for each row
+    when ((old.song, old.artist_id, old.release_date, old.text, old.link, old.enrichment_status)
+        is distinct from (new.song, new.artist_id, new.release_date, new.text, new.link, new.enrichment_status))
+    execute function songs_bump_version();
+
+drop function format_release_date(date, varchar);
+-- +goose StatementEnd
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table songs add column created_at timestamptz not null default now();
+
+-- Songs older than the revisions keep the time of their backfilled first revision.
+update songs
+set created_at = r.created_at
+from song_revisions r
+where r.song_id = songs.id
+  and r.revision = 1;
+
+create index songs_created_at_id_idx on songs (created_at, id);
+
+create index songs_song_id_idx on songs (song, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index songs_song_id_idx;
+
+drop index songs_created_at_id_idx;
+
+alter table songs drop column created_at;
+-- +goose StatementEnd
@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Imports look up an artist's song by title, ignoring case, to skip duplicates.
+create index songs_artist_id_lower_song_idx on songs (artist_id, lower(song));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index songs_artist_id_lower_song_idx;
+-- +goose StatementEnd
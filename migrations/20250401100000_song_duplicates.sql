@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+create extension if not exists pg_trgm;
+
+-- normalize_title folds the spellings of one title together for duplicate detection: case, punctuation,
+-- runs of whitespace and a trailing "feat."/"ft."/"featuring" credit are dropped.
+create function normalize_title(title varchar) returns varchar as $$
+    select btrim(regexp_replace(regexp_replace(
+        regexp_replace(lower(title), '(\s|[(\[])+(feat|ft|featuring)\M.*$', ''),
+        '[^[:alnum:][:space:]]+', ' ', 'g'),
+        '\s+', ' ', 'g'));
+$$ language sql immutable parallel safe;
+
+alter table songs add column normalized_song varchar generated always as (normalize_title(song)) stored;
+
+create index songs_normalized_song_trgm_idx on songs using gin (normalized_song gin_trgm_ops);
+
+drop index songs_artist_id_lower_song_idx;
+
+create index songs_artist_id_normalized_song_idx on songs (artist_id, normalized_song);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index songs_artist_id_normalized_song_idx;
+
+create index songs_artist_id_lower_song_idx on songs (artist_id, lower(song));
+
+drop index songs_normalized_song_trgm_idx;
+
+alter table songs drop column normalized_song;
+
+drop function normalize_title(varchar);
+-- +goose StatementEnd
@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+create table albums (
+    id serial primary key,
+    title varchar not null,
+    artist_id int not null references artists (id),
+    release_date date,
+    release_date_precision varchar not null default 'day',
+    cover_link varchar not null default '',
+    type varchar not null default 'lp',
+    created_at timestamptz not null default now()
+);
+
+-- Albums from info APIs and imports are looked up by artist and normalized title.
+create index albums_artist_id_title_idx on albums (artist_id, normalize_title(title));
+
+create table album_tracks (
+    album_id int not null references albums (id) on delete cascade,
+    song_id int not null references songs (id) on delete cascade,
+    disc_number int not null default 1,
+    track_number int not null,
+    primary key (album_id, disc_number, track_number),
+    unique (album_id, song_id)
+);
+
+create index album_tracks_song_id_idx on album_tracks (song_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table album_tracks;
+
+drop table albums;
+-- +goose StatementEnd
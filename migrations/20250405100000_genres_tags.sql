@@ -0,0 +1,46 @@
+-- +goose Up
+-- +goose StatementBegin
+create table genres (
+    id serial primary key,
+    name varchar not null,
+    parent_id int references genres (id),
+    created_at timestamptz not null default now()
+);
+
+-- Sibling genres differ by more than case; roots are siblings of each other.
+create unique index genres_parent_id_name_idx on genres (coalesce(parent_id, 0), lower(name));
+
+create table song_genres (
+    song_id int not null references songs (id) on delete cascade,
+    genre_id int not null references genres (id) on delete cascade,
+    primary key (song_id, genre_id)
+);
+
+create index song_genres_genre_id_idx on song_genres (genre_id);
+
+create table tags (
+    id serial primary key,
+    name varchar not null,
+    normalized_name varchar not null unique,
+    created_at timestamptz not null default now()
+);
+
+create table song_tags (
+    song_id int not null references songs (id) on delete cascade,
+    tag_id int not null references tags (id) on delete cascade,
+    primary key (song_id, tag_id)
+);
+
+create index song_tags_tag_id_idx on song_tags (tag_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table song_tags;
+
+drop table tags;
+
+drop table song_genres;
+
+drop table genres;
+-- +goose StatementEnd
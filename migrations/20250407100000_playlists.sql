@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+create table playlists (
+    id serial primary key,
+    name varchar not null,
+    description varchar not null default '',
+    created_at timestamptz not null default now(),
+    updated_at timestamptz not null default now()
+);
+
+-- Entries are ordered by a fractional position: an entry added or moved between two others takes a position
+-- between theirs, so the rest of the playlist is not renumbered. A song may appear in a playlist more than once.
+-- When a song is deleted its entries are either deleted or kept as unavailable, with the song and group names
+-- copied to the entry; see PLAYLIST_ON_SONG_DELETE.
+create table playlist_entries (
+    id serial primary key,
+    playlist_id int not null references playlists (id) on delete cascade,
+    song_id int references songs (id) on delete set null,
+    position double precision not null,
+    song varchar not null default '',
+    group_name varchar not null default '',
+    created_at timestamptz not null default now()
+);
+
+create index playlist_entries_playlist_id_position_idx on playlist_entries (playlist_id, position);
+
+create index playlist_entries_song_id_idx on playlist_entries (song_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table playlist_entries;
+
+drop table playlists;
+-- +goose StatementEnd
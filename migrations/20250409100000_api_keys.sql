@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Keys are stored by their SHA-256 hash; prefix is the start of the key, kept to tell keys apart.
+-- Revoked keys are kept to show who made the changes recorded under their names.
+create table api_keys (
+    id serial primary key,
+    name varchar not null,
+    role varchar not null,
+    prefix varchar not null,
+    key_hash bytea not null unique,
+    created_by varchar not null,
+    created_at timestamptz not null default now(),
+    rotated_at timestamptz,
+    expires_at timestamptz,
+    last_used_at timestamptz,
+    revoked_at timestamptz
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table api_keys;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Token buckets shared by the instances of the server. The table is unlogged: losing it in a crash only refills the buckets.
+create unlogged table rate_limit_buckets (
+    key varchar primary key,
+    tokens double precision not null,
+    allowed boolean not null,
+    updated_at timestamptz not null default now()
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table rate_limit_buckets;
+-- +goose StatementEnd
@@ -4,6 +4,14 @@ type Response struct {
 	Success bool   `json:"success"`
 	Message string `json:"message,omitempty"`
 	Data    any    `json:"data,omitempty"`
+	Page    *Page  `json:"page,omitempty"`
+}
+
+// Page links a list to its neighbouring pages by opaque cursors. Total is set only when it was asked for.
+type Page struct {
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+	Total      *int   `json:"total,omitempty"`
 }
 
 func OK(data any) *Response {
@@ -13,9 +21,26 @@ func OK(data any) *Response {
 	}
 }
 
+func OKPage(data any, page *Page) *Response {
+	return &Response{
+		Success: true,
+		Data:    data,
+		Page:    page,
+	}
+}
+
 func Error(msg string) *Response {
 	return &Response{
 		Success: false,
 		Message: msg,
 	}
 }
+
+// ErrorData is an error that comes with the partial result of the request.
+func ErrorData(msg string, data any) *Response {
+	return &Response{
+		Success: false,
+		Message: msg,
+		Data:    data,
+	}
+}
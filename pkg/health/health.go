@@ -0,0 +1,127 @@
+// Package health reports whether the process is alive and whether it is ready to serve,
+// by checking the dependencies it needs.
+package health
+
+import (
+	"context"
+	"github.com/go-chi/render"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusOK = "ok"
+	// StatusDegraded means an optional dependency failed: the service still serves.
+	StatusDegraded    = "degraded"
+	StatusUnavailable = "unavailable"
+	// StatusShuttingDown means the process is draining before it stops.
+	StatusShuttingDown = "shutting_down"
+)
+
+// Check is a dependency check. Only a failed required check makes the service not ready.
+type Check struct {
+	Name     string
+	Required bool
+	Check    func(ctx context.Context) error
+}
+
+type CheckResult struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	Required  bool    `json:"required"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type Report struct {
+	Status string        `json:"status"`
+	Checks []CheckResult `json:"checks,omitempty"`
+}
+
+// Checker runs the checks, each with the timeout, in parallel.
+type Checker struct {
+	checks       []Check
+	timeout      time.Duration
+	shuttingDown atomic.Bool
+}
+
+func NewChecker(timeout time.Duration, checks ...Check) *Checker {
+	return &Checker{checks: checks, timeout: timeout}
+}
+
+// ShutDown makes the service report not ready from now on, so it is taken out of rotation before it stops serving.
+func (c *Checker) ShutDown() {
+	c.shuttingDown.Store(true)
+}
+
+func (c *Checker) Ready(ctx context.Context) *Report {
+	if c.shuttingDown.Load() {
+		return &Report{Status: StatusShuttingDown}
+	}
+
+	report := &Report{
+		Status: StatusOK,
+		Checks: make([]CheckResult, len(c.checks)),
+	}
+
+	var wg sync.WaitGroup
+	for i, check := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			report.Checks[i] = c.run(ctx, check)
+		}()
+	}
+	wg.Wait()
+
+	for _, res := range report.Checks {
+		switch {
+		case res.Status == StatusOK:
+		case res.Required:
+			report.Status = StatusUnavailable
+		case report.Status == StatusOK:
+			report.Status = StatusDegraded
+		}
+	}
+
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, check Check) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	t1 := time.Now()
+	err := check.Check(ctx)
+
+	res := CheckResult{
+		Name:      check.Name,
+		Status:    StatusOK,
+		Required:  check.Required,
+		LatencyMS: float64(time.Since(t1).Microseconds()) / 1000,
+	}
+	if err != nil {
+		res.Status = StatusUnavailable
+		res.Error = err.Error()
+	}
+
+	return res
+}
+
+// Liveness answers 200 while the process can serve HTTP at all; it checks no dependency,
+// so a broken database doesn't get the process restarted.
+func (c *Checker) Liveness(w http.ResponseWriter, r *http.Request) {
+	render.JSON(w, r, &Report{Status: StatusOK})
+}
+
+// Readiness answers 200 while the required dependencies work, and 503 otherwise or once shutting down.
+func (c *Checker) Readiness(w http.ResponseWriter, r *http.Request) {
+	report := c.Ready(r.Context())
+
+	if report.Status != StatusOK && report.Status != StatusDegraded {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	render.JSON(w, r, report)
+}
@@ -1,6 +1,12 @@
 package sl
 
-import "log/slog"
+import (
+	"context"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel/trace"
+	"log/slog"
+	"songs-library/pkg/principal"
+)
 
 func Err(err error) slog.Attr {
 	return slog.Attr{
@@ -8,3 +14,23 @@ func Err(err error) slog.Attr {
 		Value: slog.StringValue(err.Error()),
 	}
 }
+
+// RequestID returns the id the request middleware stored in ctx, empty outside of a request.
+func RequestID(ctx context.Context) slog.Attr {
+	return slog.String("request_id", middleware.GetReqID(ctx))
+}
+
+// Principal returns the name of whoever makes the request, as stored in ctx.
+func Principal(ctx context.Context) slog.Attr {
+	return slog.String("principal", principal.Name(ctx))
+}
+
+// TraceID returns the id of the trace the span in ctx belongs to, empty without one.
+func TraceID(ctx context.Context) slog.Attr {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return slog.String("trace_id", "")
+	}
+
+	return slog.String("trace_id", sc.TraceID().String())
+}
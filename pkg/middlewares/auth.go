@@ -0,0 +1,116 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"songs-library/pkg/api/response"
+	"songs-library/pkg/logger/sl"
+	"songs-library/pkg/principal"
+	"strings"
+	"time"
+)
+
+const APIKeyHeader = "X-API-Key"
+
+// ErrInvalidCredentials is what an APIKeyAuthenticator returns for a key it doesn't accept.
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// APIKeyAuthenticator returns the principal that holds the key.
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, key string) (principal.Principal, error)
+}
+
+type AuthOptions struct {
+	Keys APIKeyAuthenticator
+	// JWTSecret is the HMAC key bearer JWTs are signed with. Without it, only API keys are accepted.
+	JWTSecret []byte
+}
+
+// NewMiddlewareAuth lets a request through only with an API key or a JWT, and stores the principal
+// it names in the request context. An API key comes in the X-API-Key header or as a bearer token;
+// a bearer token with three dot separated parts is taken for a JWT.
+func NewMiddlewareAuth(log *slog.Logger, opts AuthOptions) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		log := log.With(
+			slog.String("component", "middlewares/auth"),
+		)
+
+		log.Info("auth middlewares enabled", slog.Bool("jwt", len(opts.JWTSecret) > 0))
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			log := log.With(slog.String("request_id", middleware.GetReqID(r.Context())))
+
+			p, err := authenticate(r, opts)
+			if errors.Is(err, ErrInvalidCredentials) {
+				log.Warn("authentication failed", sl.Err(err))
+
+				w.Header().Set("WWW-Authenticate", `Bearer realm="songs-library"`)
+				w.WriteHeader(http.StatusUnauthorized)
+				render.JSON(w, r, response.Error(err.Error()))
+				return
+			}
+			if err != nil {
+				log.Error("failed to authenticate", sl.Err(err))
+
+				w.WriteHeader(http.StatusInternalServerError)
+				render.JSON(w, r, response.Error("failed to authenticate"))
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(principal.WithPrincipal(r.Context(), p)))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// NewMiddlewareRole lets a request through only if its principal has the role or a higher one.
+// It goes after NewMiddlewareAuth.
+func NewMiddlewareRole(role principal.Role) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if p, _ := principal.From(r.Context()); !p.Role.Allows(role) {
+				w.WriteHeader(http.StatusForbidden)
+				render.JSON(w, r, response.Error("the "+string(role)+" role is required"))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+func authenticate(r *http.Request, opts AuthOptions) (principal.Principal, error) {
+	key := r.Header.Get(APIKeyHeader)
+
+	if key == "" {
+		scheme, token, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+		if !strings.EqualFold(scheme, "Bearer") || token == "" {
+			return principal.Principal{}, fmt.Errorf("%w: credentials are required", ErrInvalidCredentials)
+		}
+
+		if isJWT(token) {
+			if len(opts.JWTSecret) == 0 {
+				return principal.Principal{}, fmt.Errorf("%w: tokens are not accepted", ErrInvalidCredentials)
+			}
+
+			p, err := parseJWT(token, opts.JWTSecret, time.Now())
+			if err != nil {
+				return principal.Principal{}, fmt.Errorf("%w: %w", ErrInvalidCredentials, err)
+			}
+
+			return p, nil
+		}
+
+		key = token
+	}
+
+	return opts.Keys.AuthenticateAPIKey(r.Context(), key)
+}
@@ -0,0 +1,130 @@
+package middlewares
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"songs-library/pkg/principal"
+	"testing"
+	"time"
+)
+
+// signJWT builds a token of the given header and claims JSON, signed with HS256.
+func signJWT(header, claims string, secret []byte) string {
+	unsigned := base64.RawURLEncoding.EncodeToString([]byte(header)) + "." +
+		base64.RawURLEncoding.EncodeToString([]byte(claims))
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(unsigned))
+
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestParseJWT(t *testing.T) {
+	secret := []byte("secret")
+	now := time.Unix(1700000000, 0)
+	hs256 := `{"alg":"HS256","typ":"JWT"}`
+
+	tests := []struct {
+		name    string
+		token   string
+		want    principal.Principal
+		wantErr error
+	}{
+		{
+			name:  "valid",
+			token: signJWT(hs256, `{"sub":"alice","role":"editor","exp":1700000600}`, secret),
+			want:  principal.Principal{Name: "alice", Role: principal.RoleEditor},
+		},
+		{
+			name:  "expired within leeway",
+			token: signJWT(hs256, `{"sub":"alice","role":"reader","exp":1699999980}`, secret),
+			want:  principal.Principal{Name: "alice", Role: principal.RoleReader},
+		},
+		{
+			name:  "not before within leeway",
+			token: signJWT(hs256, `{"sub":"root","role":"admin","exp":1700000600,"nbf":1700000020}`, secret),
+			want:  principal.Principal{Name: "root", Role: principal.RoleAdmin},
+		},
+		{
+			name:  "fractional expiry",
+			token: signJWT(hs256, `{"sub":"alice","role":"reader","exp":1700000000.5}`, secret),
+			want:  principal.Principal{Name: "alice", Role: principal.RoleReader},
+		},
+		{
+			name:    "expired",
+			token:   signJWT(hs256, `{"sub":"alice","role":"reader","exp":1699999960}`, secret),
+			wantErr: errTokenExpired,
+		},
+		{
+			name:    "not valid yet",
+			token:   signJWT(hs256, `{"sub":"alice","role":"reader","exp":1700000600,"nbf":1700000040}`, secret),
+			wantErr: errTokenExpired,
+		},
+		{
+			name:    "no expiry",
+			token:   signJWT(hs256, `{"sub":"alice","role":"reader"}`, secret),
+			wantErr: errTokenClaims,
+		},
+		{
+			name:    "no subject",
+			token:   signJWT(hs256, `{"role":"reader","exp":1700000600}`, secret),
+			wantErr: errTokenClaims,
+		},
+		{
+			name:    "unknown role",
+			token:   signJWT(hs256, `{"sub":"alice","role":"owner","exp":1700000600}`, secret),
+			wantErr: errTokenClaims,
+		},
+		{
+			name:    "other secret",
+			token:   signJWT(hs256, `{"sub":"alice","role":"reader","exp":1700000600}`, []byte("other")),
+			wantErr: errTokenSignature,
+		},
+		{
+			name: "unsigned",
+			token: base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`)) + "." +
+				base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"alice","role":"admin","exp":1700000600}`)) + ".",
+			wantErr: errTokenAlgorithm,
+		},
+		{
+			name:    "other algorithm",
+			token:   signJWT(`{"alg":"HS512"}`, `{"sub":"alice","role":"reader","exp":1700000600}`, secret),
+			wantErr: errTokenAlgorithm,
+		},
+		{
+			name:    "claims not json",
+			token:   signJWT(hs256, `alice`, secret),
+			wantErr: errMalformedToken,
+		},
+		{
+			name:    "header not base64",
+			token:   "!!!.e30.sig",
+			wantErr: errMalformedToken,
+		},
+		{
+			name:    "signature not base64",
+			token:   signJWT(hs256, `{}`, secret) + "=",
+			wantErr: errMalformedToken,
+		},
+		{
+			name:    "two parts",
+			token:   "e30.e30",
+			wantErr: errMalformedToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseJWT(tt.token, secret, now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("parseJWT() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("parseJWT() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package middlewares
+
+import (
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"songs-library/pkg/api/response"
+	"songs-library/pkg/logger/sl"
+	"songs-library/pkg/principal"
+	"songs-library/pkg/ratelimit"
+	"strconv"
+	"time"
+)
+
+// NewMiddlewareRateLimit takes a token for each request from the bucket of its client in the group,
+// and answers 429 with Retry-After once the bucket is empty. The client is the principal, so it goes
+// after NewMiddlewareAuth; a request without one is counted by its IP. Every response gets the
+// RateLimit-* headers. When the store fails the request is counted in memory instead, so an outage
+// of a shared store leaves each instance limiting on its own rather than not limiting at all.
+func NewMiddlewareRateLimit(
+	log *slog.Logger,
+	store ratelimit.Store,
+	group string,
+	limit ratelimit.Limit,
+) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		log := log.With(
+			slog.String("component", "middlewares/ratelimit"),
+			slog.String("group", group),
+		)
+
+		log.Info("rate limit middlewares enabled", slog.String("limit", limit.String()))
+
+		fallback := ratelimit.NewMemoryStore()
+
+		policy := strconv.Itoa(limit.Requests) + ";w=" + strconv.Itoa(ceilSeconds(limit.Period))
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			client := clientKey(r)
+
+			res, err := store.Take(r.Context(), group+":"+client, limit)
+			if err != nil {
+				log.Error("failed to take rate limit token, counting in memory",
+					slog.String("request_id", middleware.GetReqID(r.Context())),
+					sl.Err(err),
+				)
+
+				// The memory store doesn't fail.
+				res, _ = fallback.Take(r.Context(), group+":"+client, limit)
+			}
+
+			w.Header().Set("RateLimit-Policy", policy)
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(limit.Requests))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+
+			if !res.Allowed {
+				log.Warn("rate limit exceeded",
+					slog.String("request_id", middleware.GetReqID(r.Context())),
+					slog.String("client", client),
+				)
+
+				w.Header().Set("Retry-After", strconv.Itoa(max(1, ceilSeconds(res.RetryAfter))))
+				w.WriteHeader(http.StatusTooManyRequests)
+				render.JSON(w, r, response.Error("rate limit exceeded, retry later"))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// clientKey names the client of the request: its principal, or its IP without one.
+func clientKey(r *http.Request) string {
+	if p, ok := principal.From(r.Context()); ok && p.Name != "" {
+		return p.Name
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	return "ip:" + host
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
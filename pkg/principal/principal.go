@@ -0,0 +1,64 @@
+package principal
+
+import "context"
+
+const Anonymous = "anonymous"
+
+// Role is what a principal may do. Each role may do everything the roles before it may.
+type Role string
+
+const (
+	RoleReader Role = "reader"
+	RoleEditor Role = "editor"
+	RoleAdmin  Role = "admin"
+)
+
+var roleRanks = map[Role]int{
+	RoleReader: 1,
+	RoleEditor: 2,
+	RoleAdmin:  3,
+}
+
+// Valid reports whether r is one of the known roles.
+func (r Role) Valid() bool {
+	_, ok := roleRanks[r]
+	return ok
+}
+
+// Allows reports whether r may do what the required role may.
+func (r Role) Allows(required Role) bool {
+	return r.Valid() && roleRanks[r] >= roleRanks[required]
+}
+
+// Principal is whoever makes a request: the holder of an API key or the subject of a token.
+type Principal struct {
+	Name string
+	Role Role
+}
+
+type ctxKey struct{}
+
+// With returns a copy of ctx that carries the name of whoever makes the request, with no role.
+func With(ctx context.Context, name string) context.Context {
+	return WithPrincipal(ctx, Principal{Name: name})
+}
+
+// WithPrincipal returns a copy of ctx that carries p.
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, ctxKey{}, p)
+}
+
+// From returns the principal stored in ctx and whether there is one.
+func From(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(ctxKey{}).(Principal)
+	return p, ok
+}
+
+// Name returns the name stored in ctx, Anonymous if there is none.
+func Name(ctx context.Context) string {
+	if p, ok := From(ctx); ok && p.Name != "" {
+		return p.Name
+	}
+
+	return Anonymous
+}
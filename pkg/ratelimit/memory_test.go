@@ -0,0 +1,105 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreTake(t *testing.T) {
+	// Two requests per two seconds: a token comes back each second.
+	limit := Limit{Requests: 2, Period: 2 * time.Second}
+	start := time.Unix(1700000000, 0)
+
+	tests := []struct {
+		name  string
+		key   string
+		after time.Duration
+		want  Result
+	}{
+		{
+			name: "full bucket",
+			key:  "a",
+			want: Result{Allowed: true, Remaining: 1, Reset: time.Second},
+		},
+		{
+			name: "last token",
+			key:  "a",
+			want: Result{Allowed: true, RetryAfter: time.Second, Reset: 2 * time.Second},
+		},
+		{
+			name: "empty bucket",
+			key:  "a",
+			want: Result{RetryAfter: time.Second, Reset: 2 * time.Second},
+		},
+		{
+			name: "other key has its own bucket",
+			key:  "b",
+			want: Result{Allowed: true, Remaining: 1, Reset: time.Second},
+		},
+		{
+			name:  "half a token back",
+			key:   "a",
+			after: 500 * time.Millisecond,
+			want:  Result{RetryAfter: 500 * time.Millisecond, Reset: 1500 * time.Millisecond},
+		},
+		{
+			name:  "token back",
+			key:   "a",
+			after: 1500 * time.Millisecond,
+			want:  Result{Allowed: true, RetryAfter: 500 * time.Millisecond, Reset: 1500 * time.Millisecond},
+		},
+		{
+			name:  "refill stops at the limit",
+			key:   "a",
+			after: 10 * time.Second,
+			want:  Result{Allowed: true, Remaining: 1, Reset: time.Second},
+		},
+	}
+
+	store := NewMemoryStore()
+
+	// The cases run in order on the same store, each after its time from the start.
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store.now = func() time.Time { return start.Add(tt.after) }
+
+			got, err := store.Take(context.Background(), tt.key, limit)
+			if err != nil {
+				t.Fatalf("Take(%q) error = %v", tt.key, err)
+			}
+
+			if got != tt.want {
+				t.Errorf("Take(%q) = %+v, want %+v", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreSweep(t *testing.T) {
+	limit := Limit{Requests: 2, Period: 2 * time.Second}
+	now := time.Unix(1700000000, 0)
+
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+
+	for _, key := range []string{"a", "b"} {
+		if _, err := store.Take(context.Background(), key, limit); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Both buckets are full by the next sweep; only a is made again by the request after it.
+	now = now.Add(sweepInterval)
+	if _, err := store.Take(context.Background(), "a", limit); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := store.buckets["b"]; ok {
+		t.Error("full bucket b was not swept")
+	}
+
+	if _, ok := store.buckets["a"]; !ok {
+		t.Error("bucket a was swept")
+	}
+}
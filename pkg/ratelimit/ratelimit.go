@@ -0,0 +1,85 @@
+// Package ratelimit limits requests with token buckets: a bucket holds up to Limit.Requests tokens,
+// refills at Limit.Requests per Limit.Period, and each request takes a token.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidLimit = errors.New(`limit must look like "600/1m": a positive number of requests per a positive duration`)
+
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// ParseLimit reads a limit written as requests per period, e.g. "600/1m".
+func ParseLimit(s string) (Limit, error) {
+	requests, period, ok := strings.Cut(s, "/")
+	if !ok {
+		return Limit{}, ErrInvalidLimit
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(requests))
+	if err != nil || n < 1 {
+		return Limit{}, ErrInvalidLimit
+	}
+
+	d, err := time.ParseDuration(strings.TrimSpace(period))
+	if err != nil || d <= 0 {
+		return Limit{}, ErrInvalidLimit
+	}
+
+	return Limit{Requests: n, Period: d}, nil
+}
+
+// Rate is the number of tokens a bucket gets back per second.
+func (l Limit) Rate() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+func (l Limit) String() string {
+	return strconv.Itoa(l.Requests) + "/" + l.Period.String()
+}
+
+// Result is the state of a bucket after a request tried to take a token.
+type Result struct {
+	Allowed bool
+	// Remaining is the number of whole tokens left.
+	Remaining int
+	// RetryAfter is how long until the bucket has a token again; zero if it has one.
+	RetryAfter time.Duration
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+}
+
+// Store keeps the buckets.
+type Store interface {
+	// Take takes a token from the bucket with the key, creating a full one if there is none.
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// NewResult describes a bucket left with tokens after a request that was allowed or not.
+func NewResult(limit Limit, tokens float64, allowed bool) Result {
+	rate := limit.Rate()
+
+	res := Result{
+		Allowed:   allowed,
+		Remaining: int(tokens),
+		Reset:     seconds((float64(limit.Requests) - tokens) / rate),
+	}
+
+	if tokens < 1 {
+		res.RetryAfter = seconds((1 - tokens) / rate)
+	}
+
+	return res
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
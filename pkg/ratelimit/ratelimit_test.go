@@ -0,0 +1,95 @@
+package ratelimit
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Limit
+		wantErr bool
+	}{
+		{in: "600/1m", want: Limit{Requests: 600, Period: time.Minute}},
+		{in: " 10 / 1s ", want: Limit{Requests: 10, Period: time.Second}},
+		{in: "1/1h30m", want: Limit{Requests: 1, Period: 90 * time.Minute}},
+		{in: "", wantErr: true},
+		{in: "600", wantErr: true},
+		{in: "600/", wantErr: true},
+		{in: "/1m", wantErr: true},
+		{in: "0/1m", wantErr: true},
+		{in: "-1/1m", wantErr: true},
+		{in: "600/0s", wantErr: true},
+		{in: "600/-1m", wantErr: true},
+		{in: "600/minute", wantErr: true},
+		{in: "1.5/1m", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseLimit(tt.in)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidLimit) {
+					t.Errorf("ParseLimit(%q) = %v, %v, want ErrInvalidLimit", tt.in, got, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ParseLimit(%q) error = %v", tt.in, err)
+			}
+
+			if got != tt.want {
+				t.Errorf("ParseLimit(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewResult(t *testing.T) {
+	// Ten requests per ten seconds: a token comes back each second.
+	limit := Limit{Requests: 10, Period: 10 * time.Second}
+
+	tests := []struct {
+		name    string
+		tokens  float64
+		allowed bool
+		want    Result
+	}{
+		{
+			name:    "full after the request",
+			tokens:  10,
+			allowed: true,
+			want:    Result{Allowed: true, Remaining: 10},
+		},
+		{
+			name:    "tokens left",
+			tokens:  7.5,
+			allowed: true,
+			want:    Result{Allowed: true, Remaining: 7, Reset: 2500 * time.Millisecond},
+		},
+		{
+			name:    "last token taken",
+			tokens:  0,
+			allowed: true,
+			want:    Result{Allowed: true, RetryAfter: time.Second, Reset: 10 * time.Second},
+		},
+		{
+			name:    "denied with part of a token",
+			tokens:  0.25,
+			allowed: false,
+			want:    Result{RetryAfter: 750 * time.Millisecond, Reset: 9750 * time.Millisecond},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewResult(limit, tt.tokens, tt.allowed)
+			if got != tt.want {
+				t.Errorf("NewResult(%v, %v, %v) = %+v, want %+v", limit, tt.tokens, tt.allowed, got, tt.want)
+			}
+		})
+	}
+}
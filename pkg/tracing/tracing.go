@@ -0,0 +1,26 @@
+// Package tracing starts OpenTelemetry spans for the work done for a request. The spans go to the global
+// tracer provider NewProvider installs; the trace is carried between services in the W3C traceparent header.
+package tracing
+
+import (
+	"context"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+)
+
+// instrumentationName names the tracer of the spans started here.
+const instrumentationName = "songs-library"
+
+// Start starts a span named name, the child of the span in ctx, or of the remote span extracted into it.
+// Without either it starts a trace.
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, opts...)
+}
+
+// StartRequest starts a span named name for the handling of r, and returns r with the span in its context.
+func StartRequest(r *http.Request, name string) (*http.Request, trace.Span) {
+	ctx, span := Start(r.Context(), name)
+
+	return r.WithContext(ctx), span
+}